@@ -1,18 +1,33 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"vietick/config"
 	"vietick/internal/models"
+	"vietick/internal/services"
 	"vietick/routes"
 
 	"github.com/gin-gonic/gin"
 )
 
+// shutdownTimeout is how long in-flight requests get to finish after SIGINT/SIGTERM
+const shutdownTimeout = 15 * time.Second
+
 func main() {
 	gin.SetMode(gin.DebugMode)
+	// Email, 2FA challenge and OAuth state tokens are HMAC-signed with a key derived from this secret
+	if err := services.ValidateTokenSecret(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 	// Initialize database
 	if err := config.InitDB(); err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
@@ -30,12 +45,38 @@ func main() {
 			&models.User{},
 			&models.Tag{},
 			&models.Follow{},
+			&models.TagFollow{},
+			&models.QuestionWatch{},
 			&models.Notification{},
+			&models.NotificationPreference{},
+			&models.NotificationMute{},
+			&models.Job{},
+			&models.EmailDigestSetting{},
+			&models.UserToken{},
+			&models.Upload{},
+			&models.UserIdentity{},
+			&models.TwoFactor{},
+			&models.RecoveryCode{},
+			&models.SigningKey{},
+			&models.PersonalAccessToken{},
+			&models.Bookmark{},
+			&models.BookmarkCollection{},
+			&models.UserBadge{},
+			&models.Bounty{},
+			&models.PointTransaction{},
 		); err != nil {
 			log.Fatalf("Failed to drop tables: %v", err)
 		}
 	}
 
+	// Votes must be unique per user and answer before the unique index can be created
+	if err := services.RemoveDuplicateVotes(); err != nil {
+		log.Fatalf("Failed to remove duplicate votes: %v", err)
+	}
+
+	// Accounts created before email verification existed are grandfathered in once the column is added
+	backfillEmailVerification := services.NeedsEmailVerificationBackfill()
+
 	// Auto migrate database schema
 	if err := config.DB.AutoMigrate(
 		&models.User{},
@@ -44,17 +85,111 @@ func main() {
 		&models.Answer{},
 		&models.Vote{},
 		&models.Follow{},
+		&models.TagFollow{},
+		&models.QuestionWatch{},
 		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.NotificationMute{},
+		&models.Job{},
+		&models.EmailDigestSetting{},
+		&models.UserToken{},
+		&models.Upload{},
+		&models.UserIdentity{},
+		&models.TwoFactor{},
+		&models.RecoveryCode{},
+		&models.SigningKey{},
+		&models.PersonalAccessToken{},
+		&models.BookmarkCollection{},
+		&models.Bookmark{},
+		&models.UserBadge{},
+		&models.Bounty{},
+		&models.PointTransaction{},
 	); err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
+	// Bounties used to cascade-delete with their question/sponsor; drop those foreign keys so
+	// bounty rows survive as part of the point audit trail
+	for _, constraint := range []string{"fk_bounties_question", "fk_bounties_user"} {
+		if config.DB.Migrator().HasConstraint(&models.Bounty{}, constraint) {
+			if err := config.DB.Migrator().DropConstraint(&models.Bounty{}, constraint); err != nil {
+				log.Fatalf("Failed to drop constraint %s: %v", constraint, err)
+			}
+		}
+	}
+
+	if backfillEmailVerification {
+		if err := services.BackfillEmailVerification(); err != nil {
+			log.Fatalf("Failed to backfill email verification: %v", err)
+		}
+	}
+
+	// Credit reputation for votes and verifications made before reputation accrued automatically
+	services.BackfillReputation()
+
+	// Load JWT signing keys and start scheduled rotation (RS256/EdDSA only)
+	signingKeyService := services.NewSigningKeyService()
+	if err := signingKeyService.StartRotation(); err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+	defer signingKeyService.StopRotation()
+
+	// Start background job workers (notification fan-out, ...)
+	jobQueue := services.DefaultJobQueue()
+	if workers, err := strconv.Atoi(os.Getenv("JOB_WORKERS")); err == nil {
+		jobQueue.SetWorkers(workers)
+	}
+	jobQueue.Start()
+	defer jobQueue.Stop()
+
+	// Render Markdown for content created before content_html existed
+	go services.BackfillRenderedContent()
+
+	// Start email digest scheduler
+	digestService := services.NewDigestService()
+	digestService.StartScheduler()
+	defer digestService.StopScheduler()
+
+	// Start flushing buffered question views to the database
+	viewService := services.NewViewService()
+	viewService.StartFlusher()
+	defer viewService.StopFlusher()
+
+	// Start recomputing cached leaderboards
+	leaderboardService := services.NewLeaderboardService()
+	leaderboardService.StartRefresher()
+	defer leaderboardService.StopRefresher()
+
+	// Start closing expired bounties
+	bountyService := services.NewBountyService()
+	bountyService.StartExpiryScheduler()
+	defer bountyService.StopExpiryScheduler()
+
 	// Setup router
 	r := routes.SetupRouter()
 
 	// Start server
-	log.Println("Server starting on :8080")
-	if err := r.Run(":8080"); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	srv := &http.Server{Addr: ":8080", Handler: r}
+	go func() {
+		log.Println("Server starting on :8080")
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	// Stop accepting requests on SIGINT/SIGTERM and let in-flight ones finish, so the deferred
+	// stops above run (flush buffered views, finish running jobs, close the database)
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-quit
+	log.Printf("Received %s, shutting down server...", sig)
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		// SSE streams stay open until the client disconnects; close whatever is left
+		log.Printf("Graceful shutdown did not finish in %s, closing remaining connections: %v", shutdownTimeout, err)
+		srv.Close()
 	}
+	log.Println("Server stopped")
 }
@@ -2,7 +2,6 @@ package controllers
 
 import (
     "net/http"
-    "strconv"
 
     "github.com/gin-gonic/gin"
     "github.com/google/uuid"
@@ -11,11 +10,13 @@ import (
 
 type AnswerController struct {
     answerService *services.AnswerService
+    viewerService *services.ViewerService
 }
 
 func NewAnswerController() *AnswerController {
     return &AnswerController{
         answerService: services.NewAnswerService(),
+        viewerService: services.NewViewerService(),
     }
 }
 
@@ -63,21 +64,25 @@ func (c *AnswerController) GetAnswers(ctx *gin.Context) {
         return
     }
 
-    page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
-    limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+    pagination, ok := parsePagination(ctx, 10)
+    if !ok {
+        return
+    }
 
-    answers, total, err := c.answerService.GetAnswers(questionID, page, limit)
+    answers, total, nextCursor, err := c.answerService.GetAnswers(questionID, pagination)
     if err != nil {
         ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
     }
 
-    ctx.JSON(http.StatusOK, gin.H{
-        "data":  answers,
-        "total": total,
-        "page":  page,
-        "limit": limit,
-    })
+    userID, _ := viewerID(ctx)
+    views, err := c.viewerService.AnswerViews(answers, userID)
+    if err != nil {
+        ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, paginatedResponse(views, total, pagination, nextCursor))
 }
 
 func (c *AnswerController) VerifyAnswer(ctx *gin.Context) {
@@ -103,7 +108,7 @@ func (c *AnswerController) VerifyAnswer(ctx *gin.Context) {
     }
 
     if err := c.answerService.VerifyAnswer(answerID, verifierID); err != nil {
-        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        ctx.JSON(privilegeErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
         return
     }
 
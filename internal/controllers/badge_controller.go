@@ -0,0 +1,52 @@
+package controllers
+
+import (
+    "errors"
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+    "vietick/internal/services"
+)
+
+type BadgeController struct {
+    badgeService *services.BadgeService
+}
+
+func NewBadgeController() *BadgeController {
+    return &BadgeController{
+        badgeService: services.NewBadgeService(),
+    }
+}
+
+// GetBadges liệt kê mọi huy hiệu và số người đã nhận
+func (c *BadgeController) GetBadges(ctx *gin.Context) {
+    badges, err := c.badgeService.GetBadges()
+    if err != nil {
+        ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, gin.H{"data": badges})
+}
+
+// GetUserBadges liệt kê huy hiệu của một user
+func (c *BadgeController) GetUserBadges(ctx *gin.Context) {
+    userID, err := uuid.Parse(ctx.Param("id"))
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+        return
+    }
+
+    badges, err := c.badgeService.GetUserBadges(userID)
+    if err != nil {
+        status := http.StatusInternalServerError
+        if errors.Is(err, services.ErrUserNotFound) {
+            status = http.StatusNotFound
+        }
+        ctx.JSON(status, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, gin.H{"data": badges})
+}
@@ -0,0 +1,333 @@
+package controllers
+
+import (
+    "errors"
+    "io"
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+    "vietick/internal/services"
+)
+
+type BookmarkController struct {
+    bookmarkService *services.BookmarkService
+}
+
+func NewBookmarkController() *BookmarkController {
+    return &BookmarkController{
+        bookmarkService: services.NewBookmarkService(),
+    }
+}
+
+// BookmarkQuestion lưu câu hỏi, hoặc chuyển bookmark sang collection khác nếu đã lưu
+func (c *BookmarkController) BookmarkQuestion(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    questionID, err := uuid.Parse(ctx.Param("id"))
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid question ID"})
+        return
+    }
+
+    // Body không bắt buộc
+    var req services.BookmarkRequest
+    if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    bookmark, created, err := c.bookmarkService.BookmarkQuestion(userIDUUID, questionID, req)
+    if err != nil {
+        ctx.JSON(bookmarkErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    status := http.StatusOK
+    if created {
+        status = http.StatusCreated
+    }
+    ctx.JSON(status, gin.H{
+        "id":            bookmark.ID,
+        "question_id":   bookmark.QuestionID,
+        "collection_id": bookmark.CollectionID,
+        "created_at":    bookmark.CreatedAt,
+    })
+}
+
+// RemoveBookmark bỏ bookmark câu hỏi
+func (c *BookmarkController) RemoveBookmark(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    questionID, err := uuid.Parse(ctx.Param("id"))
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid question ID"})
+        return
+    }
+
+    if err := c.bookmarkService.RemoveBookmark(userIDUUID, questionID); err != nil {
+        ctx.JSON(bookmarkErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, gin.H{"message": "bookmark removed"})
+}
+
+// GetMyBookmarks liệt kê bookmark của user hiện tại, lọc theo ?collection_id= nếu có
+func (c *BookmarkController) GetMyBookmarks(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    var collectionID *uuid.UUID
+    if value := ctx.Query("collection_id"); value != "" {
+        id, err := uuid.Parse(value)
+        if err != nil {
+            ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid collection ID"})
+            return
+        }
+        collectionID = &id
+    }
+
+    pagination, ok := parsePagination(ctx, 20)
+    if !ok {
+        return
+    }
+
+    bookmarks, total, nextCursor, err := c.bookmarkService.GetBookmarks(userIDUUID, collectionID, pagination)
+    if err != nil {
+        ctx.JSON(bookmarkErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, paginatedResponse(bookmarks, total, pagination, nextCursor))
+}
+
+// GetMyCollections liệt kê tất cả collection của user hiện tại, kể cả riêng tư
+func (c *BookmarkController) GetMyCollections(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    collections, err := c.bookmarkService.GetCollections(userIDUUID, userIDUUID)
+    if err != nil {
+        ctx.JSON(bookmarkErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, gin.H{"data": collections})
+}
+
+// GetUserCollections liệt kê collection công khai của một user
+func (c *BookmarkController) GetUserCollections(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    ownerID, err := uuid.Parse(ctx.Param("id"))
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+        return
+    }
+
+    collections, err := c.bookmarkService.GetCollections(ownerID, userIDUUID)
+    if err != nil {
+        ctx.JSON(bookmarkErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, gin.H{"data": collections})
+}
+
+// CreateCollection tạo collection mới
+func (c *BookmarkController) CreateCollection(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    var req services.CreateCollectionRequest
+    if err := ctx.ShouldBindJSON(&req); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    collection, err := c.bookmarkService.CreateCollection(userIDUUID, req)
+    if err != nil {
+        ctx.JSON(bookmarkErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusCreated, collection)
+}
+
+// UpdateCollection đổi tên, mô tả hoặc chế độ công khai của collection
+func (c *BookmarkController) UpdateCollection(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    collectionID, err := uuid.Parse(ctx.Param("id"))
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid collection ID"})
+        return
+    }
+
+    var req services.UpdateCollectionRequest
+    if err := ctx.ShouldBindJSON(&req); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    collection, err := c.bookmarkService.UpdateCollection(userIDUUID, collectionID, req)
+    if err != nil {
+        ctx.JSON(bookmarkErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, collection)
+}
+
+// DeleteCollection xóa collection, bookmark bên trong được giữ lại
+func (c *BookmarkController) DeleteCollection(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    collectionID, err := uuid.Parse(ctx.Param("id"))
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid collection ID"})
+        return
+    }
+
+    if err := c.bookmarkService.DeleteCollection(userIDUUID, collectionID); err != nil {
+        ctx.JSON(bookmarkErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, gin.H{"message": "collection deleted"})
+}
+
+// GetCollection xem một collection công khai (hoặc của chính mình)
+func (c *BookmarkController) GetCollection(ctx *gin.Context) {
+    collectionID, err := uuid.Parse(ctx.Param("id"))
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid collection ID"})
+        return
+    }
+
+    userID, _ := viewerID(ctx)
+    collection, err := c.bookmarkService.GetCollection(collectionID, userID)
+    if err != nil {
+        ctx.JSON(bookmarkErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, collection)
+}
+
+// GetCollectionBookmarks liệt kê câu hỏi trong một collection công khai (hoặc của chính mình)
+func (c *BookmarkController) GetCollectionBookmarks(ctx *gin.Context) {
+    collectionID, err := uuid.Parse(ctx.Param("id"))
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid collection ID"})
+        return
+    }
+
+    pagination, ok := parsePagination(ctx, 20)
+    if !ok {
+        return
+    }
+
+    userID, _ := viewerID(ctx)
+    bookmarks, total, nextCursor, err := c.bookmarkService.GetCollectionBookmarks(collectionID, userID, pagination)
+    if err != nil {
+        ctx.JSON(bookmarkErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, paginatedResponse(bookmarks, total, pagination, nextCursor))
+}
+
+func bookmarkErrorStatus(err error) int {
+    switch {
+    case errors.Is(err, services.ErrQuestionNotFound),
+        errors.Is(err, services.ErrBookmarkNotFound),
+        errors.Is(err, services.ErrCollectionNotFound),
+        errors.Is(err, services.ErrUserNotFound):
+        return http.StatusNotFound
+    case errors.Is(err, services.ErrAlreadyBookmarked),
+        errors.Is(err, services.ErrCollectionNameTaken),
+        errors.Is(err, services.ErrTooManyCollections):
+        return http.StatusConflict
+    default:
+        return http.StatusInternalServerError
+    }
+}
@@ -0,0 +1,193 @@
+package controllers
+
+import (
+    "errors"
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+    "vietick/internal/services"
+)
+
+type BountyController struct {
+    bountyService *services.BountyService
+}
+
+func NewBountyController() *BountyController {
+    return &BountyController{
+        bountyService: services.NewBountyService(),
+    }
+}
+
+// StartBounty đặt bounty cho câu hỏi, điểm được giữ lại cho tới khi bounty đóng
+func (c *BountyController) StartBounty(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    questionID, err := uuid.Parse(ctx.Param("id"))
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid question ID"})
+        return
+    }
+
+    var req services.StartBountyRequest
+    if err := ctx.ShouldBindJSON(&req); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    bounty, err := c.bountyService.StartBounty(questionID, userIDUUID, req.Amount)
+    if err != nil {
+        ctx.JSON(bountyErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusCreated, bounty)
+}
+
+// CancelBounty hủy bounty chưa có câu trả lời và hoàn điểm
+func (c *BountyController) CancelBounty(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    questionID, err := uuid.Parse(ctx.Param("id"))
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid question ID"})
+        return
+    }
+
+    bounty, err := c.bountyService.CancelBounty(questionID, userIDUUID)
+    if err != nil {
+        ctx.JSON(bountyErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, bounty)
+}
+
+// AwardBounty trao bounty cho câu trả lời được chọn
+func (c *BountyController) AwardBounty(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    questionID, err := uuid.Parse(ctx.Param("id"))
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid question ID"})
+        return
+    }
+
+    var req services.AwardBountyRequest
+    if err := ctx.ShouldBindJSON(&req); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if req.AnswerID == uuid.Nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "answer_id is required"})
+        return
+    }
+
+    bounty, err := c.bountyService.AwardBounty(questionID, userIDUUID, req.AnswerID)
+    if err != nil {
+        ctx.JSON(bountyErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, bounty)
+}
+
+// GetFeaturedQuestions liệt kê câu hỏi đang có bounty
+func (c *BountyController) GetFeaturedQuestions(ctx *gin.Context) {
+    pagination, ok := parsePagination(ctx, 20)
+    if !ok {
+        return
+    }
+    if pagination.UsesCursor() {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "featured questions are ranked by bounty, use page instead of cursor"})
+        return
+    }
+
+    questions, total, err := c.bountyService.GetFeaturedQuestions(pagination)
+    if err != nil {
+        ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    response := paginatedResponse(questions, total, pagination, "")
+    delete(response, "next_cursor")
+    ctx.JSON(http.StatusOK, response)
+}
+
+// GetMyPointTransactions liệt kê lịch sử thay đổi điểm của mình
+func (c *BountyController) GetMyPointTransactions(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    pagination, ok := parsePagination(ctx, 20)
+    if !ok {
+        return
+    }
+
+    transactions, total, nextCursor, err := c.bountyService.GetPointTransactions(userIDUUID, pagination)
+    if err != nil {
+        ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, paginatedResponse(transactions, total, pagination, nextCursor))
+}
+
+func bountyErrorStatus(err error) int {
+    switch {
+    case errors.Is(err, services.ErrInvalidBountyAmount):
+        return http.StatusBadRequest
+    case errors.Is(err, services.ErrNotBountySponsor),
+        errors.Is(err, services.ErrCannotAwardOwnAnswer),
+        errors.Is(err, services.ErrInsufficientReputation):
+        return http.StatusForbidden
+    case errors.Is(err, services.ErrQuestionNotFound),
+        errors.Is(err, services.ErrBountyNotFound),
+        errors.Is(err, services.ErrBountyAnswerNotFound):
+        return http.StatusNotFound
+    case errors.Is(err, services.ErrBountyAlreadyOpen),
+        errors.Is(err, services.ErrBountyHasAnswers):
+        return http.StatusConflict
+    default:
+        return http.StatusInternalServerError
+    }
+}
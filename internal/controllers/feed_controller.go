@@ -0,0 +1,56 @@
+package controllers
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+    "vietick/internal/services"
+)
+
+type FeedController struct {
+    feedService *services.FeedService
+}
+
+func NewFeedController() *FeedController {
+    return &FeedController{
+        feedService: services.NewFeedService(),
+    }
+}
+
+// GetFeed lấy feed cá nhân của user hiện tại
+func (c *FeedController) GetFeed(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    mode := services.FeedMode(ctx.DefaultQuery("mode", string(services.FeedModeChronological)))
+    if mode != services.FeedModeChronological && mode != services.FeedModeHot {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid feed mode"})
+        return
+    }
+
+    limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+
+    feed, err := c.feedService.GetFeed(userIDUUID, mode, limit, ctx.Query("cursor"))
+    if err != nil {
+        if errors.Is(err, services.ErrInvalidCursor) {
+            ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+            return
+        }
+        ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, feed)
+}
@@ -2,7 +2,6 @@ package controllers
 
 import (
     "net/http"
-    "strconv"
 
     "github.com/gin-gonic/gin"
     "github.com/google/uuid"
@@ -123,21 +122,18 @@ func (c *FollowController) GetFollowers(ctx *gin.Context) {
         return
     }
 
-    page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
-    limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+    pagination, ok := parsePagination(ctx, 20)
+    if !ok {
+        return
+    }
 
-    followers, total, err := c.followService.GetFollowers(userID, page, limit)
+    followers, total, nextCursor, err := c.followService.GetFollowers(userID, pagination)
     if err != nil {
         ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
     }
 
-    ctx.JSON(http.StatusOK, gin.H{
-        "data":  followers,
-        "total": total,
-        "page":  page,
-        "limit": limit,
-    })
+    ctx.JSON(http.StatusOK, paginatedResponse(followers, total, pagination, nextCursor))
 }
 
 // GetFollowing lấy danh sách những người mà user đang follow
@@ -149,21 +145,18 @@ func (c *FollowController) GetFollowing(ctx *gin.Context) {
         return
     }
 
-    page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
-    limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+    pagination, ok := parsePagination(ctx, 20)
+    if !ok {
+        return
+    }
 
-    following, total, err := c.followService.GetFollowing(userID, page, limit)
+    following, total, nextCursor, err := c.followService.GetFollowing(userID, pagination)
     if err != nil {
         ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
     }
 
-    ctx.JSON(http.StatusOK, gin.H{
-        "data":  following,
-        "total": total,
-        "page":  page,
-        "limit": limit,
-    })
+    ctx.JSON(http.StatusOK, paginatedResponse(following, total, pagination, nextCursor))
 }
 
 // GetUserFollowStats lấy thống kê follow của user
@@ -206,4 +199,92 @@ func (c *FollowController) GetMyFollowStats(ctx *gin.Context) {
     }
 
     ctx.JSON(http.StatusOK, stats)
-} 
\ No newline at end of file
+}
+
+// FollowTag follow một tag
+func (c *FollowController) FollowTag(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    // Convert userID to uuid.UUID
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    tagID, err := uuid.Parse(ctx.Param("id"))
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid tag ID"})
+        return
+    }
+
+    follow, err := c.followService.FollowTag(userIDUUID, tagID)
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusCreated, follow)
+}
+
+// UnfollowTag bỏ follow một tag
+func (c *FollowController) UnfollowTag(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    // Convert userID to uuid.UUID
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    tagID, err := uuid.Parse(ctx.Param("id"))
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid tag ID"})
+        return
+    }
+
+    if err := c.followService.UnfollowTag(userIDUUID, tagID); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, gin.H{"message": "tag unfollowed successfully"})
+}
+
+// GetMyFollowedTags lấy danh sách tag mà user hiện tại đang follow
+func (c *FollowController) GetMyFollowedTags(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    // Convert userID to uuid.UUID
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    pagination, ok := parsePagination(ctx, 20)
+    if !ok {
+        return
+    }
+
+    tags, total, nextCursor, err := c.followService.GetFollowedTags(userIDUUID, pagination)
+    if err != nil {
+        ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, paginatedResponse(tags, total, pagination, nextCursor))
+}
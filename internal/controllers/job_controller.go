@@ -0,0 +1,29 @@
+package controllers
+
+import (
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+    "vietick/internal/services"
+)
+
+type JobController struct {
+    jobQueue *services.JobQueue
+}
+
+func NewJobController(jobQueue *services.JobQueue) *JobController {
+    return &JobController{
+        jobQueue: jobQueue,
+    }
+}
+
+// GetMetrics trả về số liệu của hàng đợi job nền
+func (c *JobController) GetMetrics(ctx *gin.Context) {
+    metrics, err := c.jobQueue.Metrics()
+    if err != nil {
+        ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, metrics)
+}
@@ -0,0 +1,24 @@
+package controllers
+
+import (
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+    "vietick/internal/services"
+)
+
+type JWKSController struct {
+    signingKeyService *services.SigningKeyService
+}
+
+func NewJWKSController() *JWKSController {
+    return &JWKSController{
+        signingKeyService: services.NewSigningKeyService(),
+    }
+}
+
+// GetJWKS công bố public key để service khác tự xác thực JWT mà không cần chia sẻ secret
+func (c *JWKSController) GetJWKS(ctx *gin.Context) {
+    ctx.Header("Cache-Control", "public, max-age=300")
+    ctx.JSON(http.StatusOK, c.signingKeyService.JWKS())
+}
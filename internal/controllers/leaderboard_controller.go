@@ -0,0 +1,57 @@
+package controllers
+
+import (
+    "errors"
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+    "vietick/internal/services"
+)
+
+type LeaderboardController struct {
+    leaderboardService *services.LeaderboardService
+}
+
+func NewLeaderboardController() *LeaderboardController {
+    return &LeaderboardController{
+        leaderboardService: services.NewLeaderboardService(),
+    }
+}
+
+// GetLeaderboard trả về bảng xếp hạng theo ?period=weekly|monthly|all và ?tag=<tên tag>
+func (c *LeaderboardController) GetLeaderboard(ctx *gin.Context) {
+    period, err := services.ParseLeaderboardPeriod(ctx.Query("period"))
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    pagination, ok := parsePagination(ctx, 20)
+    if !ok {
+        return
+    }
+    if pagination.UsesCursor() {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "leaderboards are ranked by score, use page instead of cursor"})
+        return
+    }
+
+    leaderboard, err := c.leaderboardService.GetLeaderboard(period, ctx.Query("tag"), pagination)
+    if err != nil {
+        status := http.StatusInternalServerError
+        if errors.Is(err, services.ErrTagNotFound) {
+            status = http.StatusNotFound
+        }
+        ctx.JSON(status, gin.H{"error": err.Error()})
+        return
+    }
+
+    response := paginatedResponse(leaderboard.Entries, leaderboard.Total, pagination, "")
+    delete(response, "next_cursor")
+    response["period"] = leaderboard.Period
+    response["metric"] = leaderboard.Metric
+    response["computed_at"] = leaderboard.ComputedAt
+    if leaderboard.Tag != nil {
+        response["tag"] = leaderboard.Tag
+    }
+    ctx.JSON(http.StatusOK, response)
+}
@@ -1,8 +1,8 @@
 package controllers
 
 import (
+    "errors"
     "net/http"
-    "strconv"
 
     "github.com/gin-gonic/gin"
     "github.com/google/uuid"
@@ -11,11 +11,15 @@ import (
 
 type NotificationController struct {
     notificationService *services.NotificationService
+    preferenceService   *services.NotificationPreferenceService
+    digestService       *services.DigestService
 }
 
 func NewNotificationController() *NotificationController {
     return &NotificationController{
         notificationService: services.NewNotificationService(),
+        preferenceService:   services.NewNotificationPreferenceService(),
+        digestService:       services.NewDigestService(),
     }
 }
 
@@ -38,21 +42,18 @@ func (c *NotificationController) GetNotifications(ctx *gin.Context) {
         return
     }
 
-    page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
-    limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+    pagination, ok := parsePagination(ctx, 20)
+    if !ok {
+        return
+    }
 
-    notifications, total, err := c.notificationService.GetUserNotifications(userIDUUID, page, limit)
+    notifications, total, nextCursor, err := c.notificationService.GetUserNotifications(userIDUUID, pagination)
     if err != nil {
         ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
     }
 
-    ctx.JSON(http.StatusOK, gin.H{
-        "data":  notifications,
-        "total": total,
-        "page":  page,
-        "limit": limit,
-    })
+    ctx.JSON(http.StatusOK, paginatedResponse(notifications, total, pagination, nextCursor))
 }
 
 // MarkAsRead đánh dấu notification đã đọc
@@ -156,4 +157,238 @@ func (c *NotificationController) DeleteNotification(ctx *gin.Context) {
     }
 
     ctx.JSON(http.StatusOK, gin.H{"message": "notification deleted"})
-} 
\ No newline at end of file
+}
+
+// GetPreferences lấy cài đặt notification của user
+func (c *NotificationController) GetPreferences(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    preferences, err := c.preferenceService.GetPreferences(userIDUUID)
+    if err != nil {
+        ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, gin.H{"data": preferences})
+}
+
+// UpdatePreferences cập nhật cài đặt notification của user
+func (c *NotificationController) UpdatePreferences(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    var req services.UpdateNotificationPreferencesRequest
+    if err := ctx.ShouldBindJSON(&req); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    preferences, err := c.preferenceService.UpdatePreferences(userIDUUID, req)
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, gin.H{"data": preferences})
+}
+
+// GetMutes lấy danh sách user/câu hỏi đã tắt thông báo
+func (c *NotificationController) GetMutes(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    mutes, err := c.preferenceService.GetMutes(userIDUUID)
+    if err != nil {
+        ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, gin.H{"data": mutes})
+}
+
+// CreateMute tắt thông báo từ một user hoặc về một câu hỏi
+func (c *NotificationController) CreateMute(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    var req services.CreateMuteRequest
+    if err := ctx.ShouldBindJSON(&req); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    mute, err := c.preferenceService.Mute(userIDUUID, req)
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusCreated, mute)
+}
+
+// DeleteMute bật lại thông báo
+func (c *NotificationController) DeleteMute(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    muteID, err := uuid.Parse(ctx.Param("id"))
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid mute ID"})
+        return
+    }
+
+    if err := c.preferenceService.Unmute(userIDUUID, muteID); err != nil {
+        ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, gin.H{"message": "unmuted successfully"})
+}
+
+// GetDigestSettings lấy cài đặt email digest của user
+func (c *NotificationController) GetDigestSettings(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    settings, err := c.digestService.GetSettings(userIDUUID)
+    if err != nil {
+        ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, settings)
+}
+
+// UpdateDigestSettings đổi tần suất email digest (off, daily, weekly)
+func (c *NotificationController) UpdateDigestSettings(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    var req services.UpdateDigestSettingsRequest
+    if err := ctx.ShouldBindJSON(&req); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    settings, err := c.digestService.UpdateSettings(userIDUUID, req)
+    if err != nil {
+        ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, settings)
+}
+
+// UnsubscribeDigestPage hiện trang xác nhận khi user mở link trong email; GET không đổi cài đặt
+// vì trình quét link của mail server cũng mở link
+func (c *NotificationController) UnsubscribeDigestPage(ctx *gin.Context) {
+    token := ctx.Query("token")
+    if _, err := c.digestService.CheckUnsubscribeToken(token); err != nil {
+        c.renderUnsubscribePage(ctx, http.StatusBadRequest, services.UnsubscribePage{
+            Message: "Link hủy đăng ký không hợp lệ.",
+        })
+        return
+    }
+
+    c.renderUnsubscribePage(ctx, http.StatusOK, services.UnsubscribePage{
+        Message: "Bạn muốn ngừng nhận email tổng hợp thông báo từ VieTick?",
+        Token:   token,
+    })
+}
+
+// UnsubscribeDigest tắt email digest (không cần đăng nhập). Token nằm trong query khi mail client
+// hủy đăng ký một chạm (RFC 8058), hoặc trong form của trang xác nhận
+func (c *NotificationController) UnsubscribeDigest(ctx *gin.Context) {
+    token := ctx.Query("token")
+    if token == "" {
+        token = ctx.PostForm("token")
+    }
+
+    if err := c.digestService.Unsubscribe(token); err != nil {
+        status := http.StatusInternalServerError
+        message := "Không thể hủy đăng ký, vui lòng thử lại sau."
+        if errors.Is(err, services.ErrInvalidUnsubscribeToken) {
+            status = http.StatusBadRequest
+            message = "Link hủy đăng ký không hợp lệ."
+        }
+        c.renderUnsubscribePage(ctx, status, services.UnsubscribePage{Message: message})
+        return
+    }
+
+    c.renderUnsubscribePage(ctx, http.StatusOK, services.UnsubscribePage{
+        Message: "Bạn đã ngừng nhận email tổng hợp. Có thể bật lại trong phần cài đặt thông báo.",
+    })
+}
+
+func (c *NotificationController) renderUnsubscribePage(ctx *gin.Context, status int, page services.UnsubscribePage) {
+    body, err := c.digestService.RenderUnsubscribePage(page)
+    if err != nil {
+        ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    ctx.Data(status, "text/html; charset=utf-8", body)
+}
@@ -0,0 +1,239 @@
+package controllers
+
+import (
+    "errors"
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+    "vietick/internal/services"
+)
+
+type OAuthController struct {
+    oauthService *services.OAuthService
+}
+
+func NewOAuthController() *OAuthController {
+    return &OAuthController{
+        oauthService: services.NewOAuthService(),
+    }
+}
+
+// GetProviders liệt kê các provider đăng nhập đang bật
+func (c *OAuthController) GetProviders(ctx *gin.Context) {
+    ctx.JSON(http.StatusOK, gin.H{"providers": c.oauthService.Providers()})
+}
+
+// Authorize trả về URL đăng nhập tại provider và state client cần giữ lại để đối chiếu
+func (c *OAuthController) Authorize(ctx *gin.Context) {
+    authorization, err := c.oauthService.AuthorizationURL(ctx.Request.Context(), ctx.Param("provider"))
+    if err != nil {
+        ctx.JSON(oauthErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, authorization)
+}
+
+// Callback đổi code provider trả về lấy JWT; trả 202 kèm signup_token nếu cần tạo tài khoản
+func (c *OAuthController) Callback(ctx *gin.Context) {
+    var req services.OAuthCallbackRequest
+    if err := ctx.ShouldBindJSON(&req); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    response, err := c.oauthService.Login(ctx.Request.Context(), ctx.Param("provider"), req)
+    if err != nil {
+        ctx.JSON(oauthErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    if response.SignupRequired {
+        ctx.JSON(http.StatusAccepted, response)
+        return
+    }
+    ctx.JSON(http.StatusOK, response)
+}
+
+// Signup tạo tài khoản lần đầu đăng nhập bằng provider với username user chọn
+func (c *OAuthController) Signup(ctx *gin.Context) {
+    var req services.OAuthSignupRequest
+    if err := ctx.ShouldBindJSON(&req); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    response, err := c.oauthService.CompleteSignup(req)
+    if err != nil {
+        ctx.JSON(oauthErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusCreated, response)
+}
+
+// GetMyIdentities liệt kê các provider đã liên kết với tài khoản
+func (c *OAuthController) GetMyIdentities(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    identities, err := c.oauthService.GetIdentities(userIDUUID)
+    if err != nil {
+        ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, gin.H{"data": identities})
+}
+
+// AuthorizeLink trả về URL để liên kết thêm provider vào tài khoản đang đăng nhập
+func (c *OAuthController) AuthorizeLink(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    authorization, err := c.oauthService.LinkAuthorizationURL(ctx.Request.Context(), userIDUUID, ctx.Param("provider"))
+    if err != nil {
+        ctx.JSON(oauthErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, authorization)
+}
+
+// LinkIdentity hoàn tất liên kết provider bằng code và state provider trả về
+func (c *OAuthController) LinkIdentity(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    var req services.OAuthCallbackRequest
+    if err := ctx.ShouldBindJSON(&req); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    identity, err := c.oauthService.LinkIdentity(ctx.Request.Context(), userIDUUID, ctx.Param("provider"), req)
+    if err != nil {
+        ctx.JSON(oauthErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusCreated, identity)
+}
+
+// AuthorizeReauth trả về URL để user đăng nhập lại với provider đã liên kết, thay cho mật khẩu
+func (c *OAuthController) AuthorizeReauth(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    authorization, err := c.oauthService.ReauthAuthorizationURL(ctx.Request.Context(), userIDUUID, ctx.Param("provider"))
+    if err != nil {
+        ctx.JSON(oauthErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, authorization)
+}
+
+// Reauthenticate đổi code và state provider trả về lấy reauth token ngắn hạn
+func (c *OAuthController) Reauthenticate(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    var req services.OAuthCallbackRequest
+    if err := ctx.ShouldBindJSON(&req); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    response, err := c.oauthService.Reauthenticate(ctx.Request.Context(), userIDUUID, ctx.Param("provider"), req)
+    if err != nil {
+        ctx.JSON(oauthErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, response)
+}
+
+// UnlinkIdentity gỡ liên kết provider khỏi tài khoản
+func (c *OAuthController) UnlinkIdentity(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    if err := c.oauthService.UnlinkIdentity(userIDUUID, ctx.Param("provider")); err != nil {
+        ctx.JSON(oauthErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, gin.H{"message": "identity unlinked"})
+}
+
+func oauthErrorStatus(err error) int {
+    switch {
+    case errors.Is(err, services.ErrUnknownProvider), errors.Is(err, services.ErrUserNotFound):
+        return http.StatusNotFound
+    case errors.Is(err, services.ErrIdentityNotLinked):
+        return http.StatusForbidden
+    case errors.Is(err, services.ErrOAuthEmailTaken),
+        errors.Is(err, services.ErrIdentityAlreadyLinked),
+        errors.Is(err, services.ErrProviderAlreadyLinked),
+        errors.Is(err, services.ErrCannotUnlinkIdentity):
+        return http.StatusConflict
+    default:
+        return http.StatusBadRequest
+    }
+}
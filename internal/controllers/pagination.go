@@ -0,0 +1,36 @@
+package controllers
+
+import (
+    "net/http"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+    "vietick/internal/services"
+)
+
+// parsePagination đọc page, limit, cursor từ query; trả về false nếu đã ghi response lỗi
+func parsePagination(ctx *gin.Context, defaultLimit int) (services.Pagination, bool) {
+    page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+    limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", strconv.Itoa(defaultLimit)))
+
+    pagination, err := services.NewPagination(page, limit, ctx.Query("cursor"), defaultLimit)
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return pagination, false
+    }
+    return pagination, true
+}
+
+// paginatedResponse dựng response danh sách; total và page chỉ có ở chế độ page/limit
+func paginatedResponse(data interface{}, total int64, p services.Pagination, nextCursor string) gin.H {
+    response := gin.H{
+        "data":        data,
+        "limit":       p.Limit,
+        "next_cursor": nextCursor,
+    }
+    if !p.UsesCursor() {
+        response["total"] = total
+        response["page"] = p.Page
+    }
+    return response
+}
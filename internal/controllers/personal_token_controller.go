@@ -0,0 +1,113 @@
+package controllers
+
+import (
+    "errors"
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+    "vietick/internal/services"
+)
+
+type PersonalTokenController struct {
+    tokenService *services.PersonalTokenService
+}
+
+func NewPersonalTokenController() *PersonalTokenController {
+    return &PersonalTokenController{
+        tokenService: services.NewPersonalTokenService(),
+    }
+}
+
+// CreateToken tạo personal access token; token gốc chỉ trả về trong response này
+func (c *PersonalTokenController) CreateToken(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    var req services.CreatePersonalTokenRequest
+    if err := ctx.ShouldBindJSON(&req); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    token, err := c.tokenService.CreateToken(userIDUUID, req)
+    if err != nil {
+        ctx.JSON(personalTokenErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusCreated, token)
+}
+
+// GetMyTokens liệt kê personal access token của user
+func (c *PersonalTokenController) GetMyTokens(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    tokens, err := c.tokenService.GetTokens(userIDUUID)
+    if err != nil {
+        ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, gin.H{"data": tokens})
+}
+
+// RevokeToken thu hồi một personal access token
+func (c *PersonalTokenController) RevokeToken(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    tokenID, err := uuid.Parse(ctx.Param("id"))
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid token ID"})
+        return
+    }
+
+    if err := c.tokenService.RevokeToken(userIDUUID, tokenID); err != nil {
+        ctx.JSON(personalTokenErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, gin.H{"message": "token revoked"})
+}
+
+func personalTokenErrorStatus(err error) int {
+    switch {
+    case errors.Is(err, services.ErrTokenNotFound):
+        return http.StatusNotFound
+    case errors.Is(err, services.ErrInvalidTokenScope):
+        return http.StatusBadRequest
+    case errors.Is(err, services.ErrTooManyTokens):
+        return http.StatusConflict
+    default:
+        return http.StatusInternalServerError
+    }
+}
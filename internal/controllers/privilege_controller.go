@@ -0,0 +1,55 @@
+package controllers
+
+import (
+    "errors"
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+    "vietick/internal/services"
+)
+
+type PrivilegeController struct {
+    privilegeService *services.PrivilegeService
+}
+
+func NewPrivilegeController() *PrivilegeController {
+    return &PrivilegeController{
+        privilegeService: services.NewPrivilegeService(),
+    }
+}
+
+// GetPrivileges liệt kê các quyền và reputation cần có
+func (c *PrivilegeController) GetPrivileges(ctx *gin.Context) {
+    ctx.JSON(http.StatusOK, gin.H{"data": c.privilegeService.GetPrivileges()})
+}
+
+// GetMyPrivileges trả reputation của user hiện tại và các quyền đã/chưa có
+func (c *PrivilegeController) GetMyPrivileges(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    privileges, err := c.privilegeService.GetUserPrivileges(userID.(uuid.UUID))
+    if err != nil {
+        status := http.StatusInternalServerError
+        if errors.Is(err, services.ErrUserNotFound) {
+            status = http.StatusNotFound
+        }
+        ctx.JSON(status, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, privileges)
+}
+
+// privilegeErrorStatus trả 403 khi user chưa đủ reputation cho thao tác, ngược lại trả fallback
+func privilegeErrorStatus(err error, fallback int) int {
+    var privilegeErr *services.PrivilegeError
+    if errors.As(err, &privilegeErr) {
+        return http.StatusForbidden
+    }
+    return fallback
+}
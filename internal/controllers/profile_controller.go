@@ -0,0 +1,144 @@
+package controllers
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+    "vietick/internal/services"
+)
+
+type ProfileController struct {
+    profileService *services.ProfileService
+}
+
+func NewProfileController() *ProfileController {
+    return &ProfileController{
+        profileService: services.NewProfileService(),
+    }
+}
+
+// GetUserProfile lấy hồ sơ công khai của user theo ID
+func (c *ProfileController) GetUserProfile(ctx *gin.Context) {
+    userID, err := uuid.Parse(ctx.Param("id"))
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+        return
+    }
+
+    profile, err := c.profileService.GetPublicProfile(userID)
+    if err != nil {
+        ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, profile)
+}
+
+// GetUserProfileByUsername lấy hồ sơ công khai của user theo username
+func (c *ProfileController) GetUserProfileByUsername(ctx *gin.Context) {
+    profile, err := c.profileService.GetPublicProfileByUsername(ctx.Param("username"))
+    if err != nil {
+        ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, profile)
+}
+
+// GetUserQuestions lấy danh sách câu hỏi của user
+func (c *ProfileController) GetUserQuestions(ctx *gin.Context) {
+    userID, err := uuid.Parse(ctx.Param("id"))
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+        return
+    }
+
+    pagination, ok := parsePagination(ctx, services.DefaultPageLimit)
+    if !ok {
+        return
+    }
+
+    questions, total, nextCursor, err := c.profileService.GetUserQuestions(userID, pagination)
+    if err != nil {
+        ctx.JSON(profileErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, paginatedResponse(questions, total, pagination, nextCursor))
+}
+
+// GetUserAnswers lấy danh sách câu trả lời của user
+func (c *ProfileController) GetUserAnswers(ctx *gin.Context) {
+    userID, err := uuid.Parse(ctx.Param("id"))
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+        return
+    }
+
+    pagination, ok := parsePagination(ctx, services.DefaultPageLimit)
+    if !ok {
+        return
+    }
+
+    answers, total, nextCursor, err := c.profileService.GetUserAnswers(userID, pagination)
+    if err != nil {
+        ctx.JSON(profileErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, paginatedResponse(answers, total, pagination, nextCursor))
+}
+
+// GetUserTopTags lấy các tag user hoạt động nhiều nhất
+func (c *ProfileController) GetUserTopTags(ctx *gin.Context) {
+    userID, err := uuid.Parse(ctx.Param("id"))
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+        return
+    }
+
+    limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+
+    tags, err := c.profileService.GetUserTopTags(userID, limit)
+    if err != nil {
+        ctx.JSON(profileErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, gin.H{"data": tags})
+}
+
+// GetUserActivity lấy hoạt động gần đây của user
+func (c *ProfileController) GetUserActivity(ctx *gin.Context) {
+    userID, err := uuid.Parse(ctx.Param("id"))
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+        return
+    }
+
+    pagination, ok := parsePagination(ctx, services.DefaultPageLimit)
+    if !ok {
+        return
+    }
+
+    items, total, nextCursor, err := c.profileService.GetUserActivity(userID, pagination)
+    if err != nil {
+        ctx.JSON(profileErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, paginatedResponse(items, total, pagination, nextCursor))
+}
+
+func profileErrorStatus(err error) int {
+    if errors.Is(err, services.ErrUserNotFound) {
+        return http.StatusNotFound
+    }
+    if errors.Is(err, services.ErrActivityPageTooDeep) {
+        return http.StatusBadRequest
+    }
+    return http.StatusInternalServerError
+}
@@ -1,8 +1,8 @@
 package controllers
 
 import (
+    "errors"
     "net/http"
-    "strconv"
 
     "github.com/gin-gonic/gin"
     "github.com/google/uuid"
@@ -11,11 +11,15 @@ import (
 
 type QuestionController struct {
     questionService *services.QuestionService
+    viewerService   *services.ViewerService
+    viewService     *services.ViewService
 }
 
 func NewQuestionController() *QuestionController {
     return &QuestionController{
         questionService: services.NewQuestionService(),
+        viewerService:   services.NewViewerService(),
+        viewService:     services.NewViewService(),
     }
 }
 
@@ -41,7 +45,7 @@ func (c *QuestionController) CreateQuestion(ctx *gin.Context) {
 
     question, err := c.questionService.CreateQuestion(userIDUUID, req)
     if err != nil {
-        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        ctx.JSON(privilegeErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
         return
     }
 
@@ -49,21 +53,18 @@ func (c *QuestionController) CreateQuestion(ctx *gin.Context) {
 }
 
 func (c *QuestionController) GetQuestions(ctx *gin.Context) {
-    page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
-    limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+    pagination, ok := parsePagination(ctx, 10)
+    if !ok {
+        return
+    }
 
-    questions, total, err := c.questionService.GetQuestions(page, limit)
+    questions, total, nextCursor, err := c.questionService.GetQuestions(pagination)
     if err != nil {
         ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
     }
 
-    ctx.JSON(http.StatusOK, gin.H{
-        "data":  questions,
-        "total": total,
-        "page":  page,
-        "limit": limit,
-    })
+    ctx.JSON(http.StatusOK, paginatedResponse(questions, total, pagination, nextCursor))
 }
 
 func (c *QuestionController) GetQuestionByID(ctx *gin.Context) {
@@ -80,7 +81,45 @@ func (c *QuestionController) GetQuestionByID(ctx *gin.Context) {
         return
     }
 
-    ctx.JSON(http.StatusOK, question)
+    // Đếm lượt xem theo user nếu đã đăng nhập, theo IP nếu là khách; tác giả xem câu hỏi của mình không tính
+    userID, authenticated := viewerID(ctx)
+    if !authenticated {
+        c.viewService.RecordView(question.ID, "ip:"+ctx.ClientIP())
+    } else if userID != question.UserID {
+        c.viewService.RecordView(question.ID, "user:"+userID.String())
+    }
+
+    view, err := c.viewerService.QuestionView(question, userID)
+    if err != nil {
+        ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, view)
+}
+
+// GetTrendingQuestions xếp hạng câu hỏi trong 7 ngày gần đây theo lượt xem, câu trả lời và vote
+func (c *QuestionController) GetTrendingQuestions(ctx *gin.Context) {
+    pagination, ok := parsePagination(ctx, 20)
+    if !ok {
+        return
+    }
+    if pagination.UsesCursor() {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "trending questions are ranked by score, use page instead of cursor"})
+        return
+    }
+
+    questions, err := c.questionService.GetTrendingQuestions(pagination)
+    if err != nil {
+        ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, gin.H{
+        "data":  questions,
+        "page":  pagination.Page,
+        "limit": pagination.Limit,
+    })
 }
 
 func (c *QuestionController) UpdateQuestion(ctx *gin.Context) {
@@ -112,7 +151,7 @@ func (c *QuestionController) UpdateQuestion(ctx *gin.Context) {
 
     question, err := c.questionService.UpdateQuestion(questionID, userIDUUID, req)
     if err != nil {
-        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        ctx.JSON(privilegeErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
         return
     }
 
@@ -141,7 +180,11 @@ func (c *QuestionController) DeleteQuestion(ctx *gin.Context) {
     }
 
     if err := c.questionService.DeleteQuestion(questionID, userIDUUID); err != nil {
-        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        status := http.StatusBadRequest
+        if errors.Is(err, services.ErrBountyQuestionAnswered) {
+            status = http.StatusConflict
+        }
+        ctx.JSON(status, gin.H{"error": err.Error()})
         return
     }
 
@@ -156,20 +199,18 @@ func (c *QuestionController) SearchQuestions(ctx *gin.Context) {
         return
     }
 
-    page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
-    limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+    pagination, ok := parsePagination(ctx, 10)
+    if !ok {
+        return
+    }
 
-    questions, total, err := c.questionService.SearchQuestions(query, page, limit)
+    questions, total, nextCursor, err := c.questionService.SearchQuestions(query, pagination)
     if err != nil {
         ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
     }
 
-    ctx.JSON(http.StatusOK, gin.H{
-        "data":  questions,
-        "total": total,
-        "page":  page,
-        "limit": limit,
-        "query": query,
-    })
+    response := paginatedResponse(questions, total, pagination, nextCursor)
+    response["query"] = query
+    ctx.JSON(http.StatusOK, response)
 } 
\ No newline at end of file
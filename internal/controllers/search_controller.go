@@ -28,22 +28,20 @@ func (c *SearchController) GetQuestionsByTag(ctx *gin.Context) {
         return
     }
 
-    page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
-    limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+    pagination, ok := parsePagination(ctx, 10)
+    if !ok {
+        return
+    }
 
-    questions, total, err := c.questionService.GetQuestionsByTag(tagName, page, limit)
+    questions, total, nextCursor, err := c.questionService.GetQuestionsByTag(tagName, pagination)
     if err != nil {
         ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
     }
 
-    ctx.JSON(http.StatusOK, gin.H{
-        "data":  questions,
-        "total": total,
-        "page":  page,
-        "limit": limit,
-        "tag":   tagName,
-    })
+    response := paginatedResponse(questions, total, pagination, nextCursor)
+    response["tag"] = tagName
+    ctx.JSON(http.StatusOK, response)
 }
 
 // SearchQuestions tìm kiếm câu hỏi theo từ khóa
@@ -54,22 +52,20 @@ func (c *SearchController) SearchQuestions(ctx *gin.Context) {
         return
     }
 
-    page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
-    limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+    pagination, ok := parsePagination(ctx, 10)
+    if !ok {
+        return
+    }
 
-    questions, total, err := c.questionService.SearchQuestions(query, page, limit)
+    questions, total, nextCursor, err := c.questionService.SearchQuestions(query, pagination)
     if err != nil {
         ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
     }
 
-    ctx.JSON(http.StatusOK, gin.H{
-        "data":  questions,
-        "total": total,
-        "page":  page,
-        "limit": limit,
-        "query": query,
-    })
+    response := paginatedResponse(questions, total, pagination, nextCursor)
+    response["query"] = query
+    ctx.JSON(http.StatusOK, response)
 }
 
 // SearchTags tìm kiếm tags
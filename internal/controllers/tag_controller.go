@@ -21,15 +21,21 @@ func NewTagController() *TagController {
 
 // CreateTag tạo tag mới
 func (c *TagController) CreateTag(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
     var req services.CreateTagRequest
     if err := ctx.ShouldBindJSON(&req); err != nil {
         ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
         return
     }
 
-    tag, err := c.tagService.CreateTag(req)
+    tag, err := c.tagService.CreateTag(userID.(uuid.UUID), req)
     if err != nil {
-        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        ctx.JSON(privilegeErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
         return
     }
 
@@ -38,21 +44,24 @@ func (c *TagController) CreateTag(ctx *gin.Context) {
 
 // GetTags lấy danh sách tags
 func (c *TagController) GetTags(ctx *gin.Context) {
-    page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
-    limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+    pagination, ok := parsePagination(ctx, 20)
+    if !ok {
+        return
+    }
+    if pagination.UsesCursor() {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "tags are ranked by usage, use page instead of cursor"})
+        return
+    }
 
-    tags, total, err := c.tagService.GetTags(page, limit)
+    tags, total, err := c.tagService.GetTags(pagination)
     if err != nil {
         ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
     }
 
-    ctx.JSON(http.StatusOK, gin.H{
-        "data":  tags,
-        "total": total,
-        "page":  page,
-        "limit": limit,
-    })
+    response := paginatedResponse(tags, total, pagination, "")
+    delete(response, "next_cursor")
+    ctx.JSON(http.StatusOK, response)
 }
 
 // GetTagByID lấy tag theo ID
@@ -75,6 +84,12 @@ func (c *TagController) GetTagByID(ctx *gin.Context) {
 
 // UpdateTag cập nhật tag
 func (c *TagController) UpdateTag(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
     tagIDStr := ctx.Param("id")
     tagID, err := uuid.Parse(tagIDStr)
     if err != nil {
@@ -88,9 +103,9 @@ func (c *TagController) UpdateTag(ctx *gin.Context) {
         return
     }
 
-    tag, err := c.tagService.UpdateTag(tagID, req)
+    tag, err := c.tagService.UpdateTag(userID.(uuid.UUID), tagID, req)
     if err != nil {
-        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        ctx.JSON(privilegeErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
         return
     }
 
@@ -99,6 +114,12 @@ func (c *TagController) UpdateTag(ctx *gin.Context) {
 
 // DeleteTag xóa tag
 func (c *TagController) DeleteTag(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
     tagIDStr := ctx.Param("id")
     tagID, err := uuid.Parse(tagIDStr)
     if err != nil {
@@ -106,8 +127,8 @@ func (c *TagController) DeleteTag(ctx *gin.Context) {
         return
     }
 
-    if err := c.tagService.DeleteTag(tagID); err != nil {
-        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    if err := c.tagService.DeleteTag(userID.(uuid.UUID), tagID); err != nil {
+        ctx.JSON(privilegeErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
         return
     }
 
@@ -0,0 +1,224 @@
+package controllers
+
+import (
+    "errors"
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+    "vietick/internal/services"
+)
+
+type TwoFactorController struct {
+    twoFactorService *services.TwoFactorService
+}
+
+func NewTwoFactorController() *TwoFactorController {
+    return &TwoFactorController{
+        twoFactorService: services.NewTwoFactorService(),
+    }
+}
+
+// GetStatus trả về trạng thái 2FA và số mã khôi phục còn lại
+func (c *TwoFactorController) GetStatus(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    status, err := c.twoFactorService.GetStatus(userIDUUID)
+    if err != nil {
+        ctx.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, status)
+}
+
+// Setup tạo secret TOTP mới; 2FA chỉ có hiệu lực sau khi xác nhận bằng Enable
+func (c *TwoFactorController) Setup(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    setup, err := c.twoFactorService.BeginSetup(userIDUUID)
+    if err != nil {
+        ctx.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, setup)
+}
+
+// Enable xác nhận mã đầu tiên và trả về mã khôi phục
+func (c *TwoFactorController) Enable(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    var req services.TwoFactorCodeRequest
+    if err := ctx.ShouldBindJSON(&req); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    codes, err := c.twoFactorService.Enable(userIDUUID, req)
+    if err != nil {
+        ctx.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// Disable tắt 2FA (không áp dụng cho moderator/admin)
+func (c *TwoFactorController) Disable(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    var req services.DisableTwoFactorRequest
+    if err := ctx.ShouldBindJSON(&req); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    if err := c.twoFactorService.Disable(userIDUUID, req); err != nil {
+        ctx.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, gin.H{"message": "two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes thay toàn bộ mã khôi phục bằng bộ mới
+func (c *TwoFactorController) RegenerateRecoveryCodes(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    var req services.TwoFactorCodeRequest
+    if err := ctx.ShouldBindJSON(&req); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    codes, err := c.twoFactorService.RegenerateRecoveryCodes(userIDUUID, req)
+    if err != nil {
+        ctx.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// Verify là bước thứ hai của đăng nhập: challenge token + mã TOTP hoặc mã khôi phục
+func (c *TwoFactorController) Verify(ctx *gin.Context) {
+    var req services.TwoFactorChallengeRequest
+    if err := ctx.ShouldBindJSON(&req); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    response, err := c.twoFactorService.VerifyLogin(req)
+    if err != nil {
+        ctx.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, response)
+}
+
+// Enroll tạo secret cho tài khoản bắt buộc 2FA đang đăng nhập lần đầu
+func (c *TwoFactorController) Enroll(ctx *gin.Context) {
+    var req services.TwoFactorEnrollRequest
+    if err := ctx.ShouldBindJSON(&req); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    setup, err := c.twoFactorService.BeginEnrollment(req)
+    if err != nil {
+        ctx.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, setup)
+}
+
+// ConfirmEnrollment bật 2FA rồi trả về JWT cùng mã khôi phục
+func (c *TwoFactorController) ConfirmEnrollment(ctx *gin.Context) {
+    var req services.TwoFactorChallengeRequest
+    if err := ctx.ShouldBindJSON(&req); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    response, err := c.twoFactorService.CompleteEnrollment(req)
+    if err != nil {
+        ctx.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, response)
+}
+
+func twoFactorErrorStatus(err error) int {
+    switch {
+    case errors.Is(err, services.ErrUserNotFound):
+        return http.StatusNotFound
+    case errors.Is(err, services.ErrInvalidChallenge),
+        errors.Is(err, services.ErrInvalidTwoFactorCode),
+        errors.Is(err, services.ErrIncorrectPassword):
+        return http.StatusUnauthorized
+    case errors.Is(err, services.ErrTwoFactorRequired):
+        return http.StatusForbidden
+    case errors.Is(err, services.ErrTwoFactorAlreadyEnabled):
+        return http.StatusConflict
+    case errors.Is(err, services.ErrTwoFactorLocked):
+        return http.StatusTooManyRequests
+    case errors.Is(err, services.ErrTwoFactorNotEnabled), errors.Is(err, services.ErrTwoFactorNotSetUp):
+        return http.StatusBadRequest
+    default:
+        return http.StatusInternalServerError
+    }
+}
@@ -0,0 +1,152 @@
+package controllers
+
+import (
+    "errors"
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+    "vietick/internal/services"
+)
+
+type UploadController struct {
+    uploadService *services.UploadService
+}
+
+func NewUploadController() *UploadController {
+    return &UploadController{
+        uploadService: services.NewUploadService(),
+    }
+}
+
+// UploadAttachment tải lên file đính kèm (multipart field "file"), trả về upload để gắn vào câu hỏi/câu trả lời
+func (c *UploadController) UploadAttachment(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, services.MaxAttachmentSize+1<<20)
+    fileHeader, err := ctx.FormFile("file")
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+        return
+    }
+    if fileHeader.Size > services.MaxAttachmentSize {
+        ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": services.ErrFileTooLarge.Error()})
+        return
+    }
+
+    file, err := fileHeader.Open()
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    defer file.Close()
+
+    upload, err := c.uploadService.UploadAttachment(userIDUUID, fileHeader.Filename, file)
+    if err != nil {
+        ctx.JSON(uploadErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusCreated, upload)
+}
+
+// GetUpload lấy thông tin file đã tải lên
+func (c *UploadController) GetUpload(ctx *gin.Context) {
+    uploadID, err := uuid.Parse(ctx.Param("id"))
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid upload ID"})
+        return
+    }
+
+    upload, err := c.uploadService.GetUpload(uploadID)
+    if err != nil {
+        ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, upload)
+}
+
+// UploadAvatar tải lên ảnh avatar (multipart field "file")
+func (c *UploadController) UploadAvatar(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, services.MaxAvatarSize+1<<20)
+    fileHeader, err := ctx.FormFile("file")
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+        return
+    }
+    if fileHeader.Size > services.MaxAvatarSize {
+        ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": services.ErrFileTooLarge.Error()})
+        return
+    }
+
+    file, err := fileHeader.Open()
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    defer file.Close()
+
+    user, err := c.uploadService.UploadAvatar(userIDUUID, fileHeader.Filename, file)
+    if err != nil {
+        ctx.JSON(uploadErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, user)
+}
+
+// DeleteAvatar gỡ avatar của user
+func (c *UploadController) DeleteAvatar(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    if err := c.uploadService.RemoveAvatar(userIDUUID); err != nil {
+        ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, gin.H{"message": "avatar removed"})
+}
+
+func uploadErrorStatus(err error) int {
+    switch {
+    case errors.Is(err, services.ErrFileTooLarge):
+        return http.StatusRequestEntityTooLarge
+    case errors.Is(err, services.ErrUnsupportedFile):
+        return http.StatusUnsupportedMediaType
+    default:
+        return http.StatusBadRequest
+    }
+}
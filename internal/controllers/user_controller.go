@@ -1,6 +1,9 @@
 package controllers
 
 import (
+    "errors"
+    "fmt"
+    "log"
     "net/http"
 
     "github.com/gin-gonic/gin"
@@ -9,12 +12,16 @@ import (
 )
 
 type UserController struct {
-    userService *services.UserService
+    userService    *services.UserService
+    authService    *services.AuthService
+    accountService *services.AccountService
 }
 
 func NewUserController() *UserController {
     return &UserController{
-        userService: services.NewUserService(),
+        userService:    services.NewUserService(),
+        authService:    services.NewAuthService(),
+        accountService: services.NewAccountService(),
     }
 }
 
@@ -71,4 +78,277 @@ func (c *UserController) GetProfile(ctx *gin.Context) {
     }
 
     ctx.JSON(http.StatusOK, user)
-} 
\ No newline at end of file
+} 
+
+// VerifyEmail xác minh email bằng token trong link đã gửi
+func (c *UserController) VerifyEmail(ctx *gin.Context) {
+    var req services.VerifyEmailRequest
+    if err := ctx.ShouldBindJSON(&req); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    user, err := c.authService.VerifyEmail(req)
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, user)
+}
+
+// ResendVerification gửi lại link xác minh email cho user đang đăng nhập
+func (c *UserController) ResendVerification(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    if err := c.authService.SendVerificationEmail(userIDUUID); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, gin.H{"message": "verification email sent"})
+}
+
+// ForgotPassword gửi link đặt lại mật khẩu; luôn trả 200 dù email có tồn tại hay không
+func (c *UserController) ForgotPassword(ctx *gin.Context) {
+    var req services.ForgotPasswordRequest
+    if err := ctx.ShouldBindJSON(&req); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    if err := c.authService.ForgotPassword(req); err != nil {
+        ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, gin.H{"message": "if the email exists, a password reset link has been sent"})
+}
+
+// ResetPassword đặt mật khẩu mới bằng token đặt lại mật khẩu
+func (c *UserController) ResetPassword(ctx *gin.Context) {
+    var req services.ResetPasswordRequest
+    if err := ctx.ShouldBindJSON(&req); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    if err := c.authService.ResetPassword(req); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, gin.H{"message": "password reset successfully"})
+}
+
+// UpdateProfile cập nhật tên hiển thị, bio, địa điểm, website, avatar
+func (c *UserController) UpdateProfile(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    var req services.UpdateProfileRequest
+    if err := ctx.ShouldBindJSON(&req); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    user, err := c.userService.UpdateProfile(userIDUUID, req)
+    if err != nil {
+        ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, user)
+}
+
+// ChangePassword đổi mật khẩu, yêu cầu mật khẩu hiện tại
+func (c *UserController) ChangePassword(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    var req services.ChangePasswordRequest
+    if err := ctx.ShouldBindJSON(&req); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    if err := c.userService.ChangePassword(userIDUUID, req); err != nil {
+        ctx.JSON(accountErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, gin.H{"message": "password changed successfully"})
+}
+
+// ChangeEmail gửi link xác nhận tới email mới
+func (c *UserController) ChangeEmail(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    var req services.ChangeEmailRequest
+    if err := ctx.ShouldBindJSON(&req); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    if err := c.userService.ChangeEmail(userIDUUID, req); err != nil {
+        ctx.JSON(accountErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusAccepted, gin.H{"message": "confirmation link sent to the new email"})
+}
+
+// ChangeUsername đổi username (có thời gian chờ giữa hai lần đổi)
+func (c *UserController) ChangeUsername(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    var req services.ChangeUsernameRequest
+    if err := ctx.ShouldBindJSON(&req); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    user, err := c.userService.ChangeUsername(userIDUUID, req)
+    if err != nil {
+        ctx.JSON(accountErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, user)
+}
+
+// ExportData tải toàn bộ dữ liệu của user dưới dạng ZIP (mặc định) hoặc JSON (?format=json)
+func (c *UserController) ExportData(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    format := ctx.DefaultQuery("format", "zip")
+    if format != "zip" && format != "json" {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "format must be zip or json"})
+        return
+    }
+
+    export, err := c.accountService.ExportData(userIDUUID)
+    if err != nil {
+        if errors.Is(err, services.ErrUserNotFound) {
+            ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+            return
+        }
+        ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    fileName := fmt.Sprintf("vietick-export-%s.%s", export.ExportedAt.Format("20060102"), format)
+    ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+    ctx.Header("Cache-Control", "no-store")
+
+    if format == "json" {
+        ctx.IndentedJSON(http.StatusOK, export)
+        return
+    }
+
+    // Archive được stream thẳng ra response; lỗi giữa chừng chỉ có thể ghi log vì header đã gửi
+    ctx.Header("Content-Type", "application/zip")
+    ctx.Status(http.StatusOK)
+    if err := c.accountService.WriteExportZip(ctx.Writer, export); err != nil {
+        log.Printf("Error writing export archive for user %s: %v", userIDUUID, err)
+    }
+}
+
+// DeleteAccount xóa tài khoản của user đang đăng nhập, yêu cầu xác nhận mật khẩu
+func (c *UserController) DeleteAccount(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    var req services.DeleteAccountRequest
+    if err := ctx.ShouldBindJSON(&req); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    if err := c.accountService.DeleteAccount(userIDUUID, req); err != nil {
+        ctx.JSON(accountErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, gin.H{"message": "account deleted"})
+}
+
+func accountErrorStatus(err error) int {
+    switch {
+    case errors.Is(err, services.ErrIncorrectPassword), errors.Is(err, services.ErrCannotDeleteAccount),
+        errors.Is(err, services.ErrReauthRequired), errors.Is(err, services.ErrInvalidReauthToken),
+        errors.Is(err, services.ErrInvalidTwoFactorCode):
+        return http.StatusForbidden
+    case errors.Is(err, services.ErrEmailCooldown), errors.Is(err, services.ErrTwoFactorLocked):
+        return http.StatusTooManyRequests
+    default:
+        return http.StatusBadRequest
+    }
+}
@@ -0,0 +1,16 @@
+package controllers
+
+import (
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+)
+
+// viewerID trả user đang xem trên route đọc công khai (OptionalAuthMiddleware); false nếu là khách
+func viewerID(ctx *gin.Context) (uuid.UUID, bool) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        return uuid.Nil, false
+    }
+    userIDUUID, ok := userID.(uuid.UUID)
+    return userIDUUID, ok
+}
@@ -51,7 +51,7 @@ func (c *VoteController) VoteAnswer(ctx *gin.Context) {
     // Create vote
     vote, err := c.voteService.CreateVote(userID.(uuid.UUID), answerID, req)
     if err != nil {
-        ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        ctx.JSON(privilegeErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
         return
     }
 
@@ -80,8 +80,20 @@ func (c *VoteController) GetVotes(ctx *gin.Context) {
         return
     }
 
-    ctx.JSON(http.StatusOK, gin.H{
+    response := gin.H{
         "up_votes":   upVotes,
         "down_votes": downVotes,
-    })
+    }
+
+    // Vote của người xem chỉ có khi đã đăng nhập
+    if userID, ok := viewerID(ctx); ok {
+        myVote, err := c.voteService.GetUserVote(userID, answerID)
+        if err != nil {
+            ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+            return
+        }
+        response["my_vote"] = myVote
+    }
+
+    ctx.JSON(http.StatusOK, response)
 } 
\ No newline at end of file
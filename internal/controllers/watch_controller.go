@@ -0,0 +1,107 @@
+package controllers
+
+import (
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+    "vietick/internal/services"
+)
+
+type WatchController struct {
+    watchService *services.WatchService
+}
+
+func NewWatchController() *WatchController {
+    return &WatchController{
+        watchService: services.NewWatchService(),
+    }
+}
+
+// WatchQuestion theo dõi một câu hỏi
+func (c *WatchController) WatchQuestion(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    // Convert userID to uuid.UUID
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    questionID, err := uuid.Parse(ctx.Param("id"))
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid question ID"})
+        return
+    }
+
+    watch, err := c.watchService.WatchQuestion(userIDUUID, questionID)
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusCreated, watch)
+}
+
+// UnwatchQuestion bỏ theo dõi một câu hỏi
+func (c *WatchController) UnwatchQuestion(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    // Convert userID to uuid.UUID
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    questionID, err := uuid.Parse(ctx.Param("id"))
+    if err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid question ID"})
+        return
+    }
+
+    if err := c.watchService.UnwatchQuestion(userIDUUID, questionID); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, gin.H{"message": "question unwatched successfully"})
+}
+
+// GetMyWatchedQuestions lấy danh sách câu hỏi user hiện tại đang theo dõi
+func (c *WatchController) GetMyWatchedQuestions(ctx *gin.Context) {
+    userID, exists := ctx.Get("user_id")
+    if !exists {
+        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+        return
+    }
+
+    // Convert userID to uuid.UUID
+    userIDUUID, ok := userID.(uuid.UUID)
+    if !ok {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+        return
+    }
+
+    pagination, ok := parsePagination(ctx, 20)
+    if !ok {
+        return
+    }
+
+    questions, total, nextCursor, err := c.watchService.GetWatchedQuestions(userIDUUID, pagination)
+    if err != nil {
+        ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, paginatedResponse(questions, total, pagination, nextCursor))
+}
@@ -4,17 +4,19 @@ import (
     "fmt"
     "log"
     "net/http"
-    "os"
     "strings"
 
     "github.com/gin-gonic/gin"
+    "vietick/internal/services"
     "vietick/pkg/utils"
 )
 
+// AuthMiddleware chấp nhận JWT đăng nhập hoặc personal access token (vtk_...).
+// Với personal access token, scope của token được lưu vào context để TokenScopeMiddleware kiểm tra.
 func AuthMiddleware() gin.HandlerFunc {
+    tokenService := services.NewPersonalTokenService()
+
     return func(c *gin.Context) {
-        log.Printf("JWT_SECRET from env: %s", os.Getenv("JWT_SECRET"))
-        
         authHeader := c.GetHeader("Authorization")
         if authHeader == "" {
             log.Printf("Missing Authorization header")
@@ -22,18 +24,55 @@ func AuthMiddleware() gin.HandlerFunc {
             return
         }
 
-        tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-        log.Printf("Token received: %s", tokenString)
-        
-        claims, err := utils.ParseToken(tokenString)
+        if authenticate(c, tokenService, authHeader) {
+            c.Next()
+        }
+    }
+}
+
+// OptionalAuthMiddleware dùng cho route đọc công khai: không có header thì xử lý như khách (không có user_id),
+// có token hợp lệ thì set user_id giống AuthMiddleware để trả thêm thông tin riêng của người xem.
+// Token sai hoặc hết hạn vẫn bị từ chối để client biết cần đăng nhập lại.
+func OptionalAuthMiddleware() gin.HandlerFunc {
+    tokenService := services.NewPersonalTokenService()
+
+    return func(c *gin.Context) {
+        authHeader := c.GetHeader("Authorization")
+        if authHeader == "" {
+            c.Next()
+            return
+        }
+
+        if authenticate(c, tokenService, authHeader) {
+            c.Next()
+        }
+    }
+}
+
+// authenticate xác thực token trong header Authorization và set user_id; trả false (đã abort) nếu token không hợp lệ
+func authenticate(c *gin.Context, tokenService *services.PersonalTokenService, authHeader string) bool {
+    tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+
+    if strings.HasPrefix(tokenString, services.PersonalTokenPrefix) {
+        pat, err := tokenService.Authenticate(tokenString, c.ClientIP())
         if err != nil {
-            log.Printf("Token parsing error: %v", err)
+            log.Printf("Personal access token error: %v", err)
             c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": fmt.Sprintf("Invalid token: %v", err)})
-            return
+            return false
         }
 
-        log.Printf("Token parsed successfully, user_id: %s", claims.UserID)
-        c.Set("user_id", claims.UserID)
-        c.Next()
+        c.Set("user_id", pat.UserID)
+        c.Set("token_scopes", pat.ScopeList())
+        return true
+    }
+
+    claims, err := utils.ParseToken(tokenString)
+    if err != nil {
+        log.Printf("Token parsing error: %v", err)
+        c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": fmt.Sprintf("Invalid token: %v", err)})
+        return false
     }
-} 
\ No newline at end of file
+
+    c.Set("user_id", claims.UserID)
+    return true
+}
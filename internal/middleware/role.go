@@ -0,0 +1,36 @@
+package middleware
+
+import (
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+    "vietick/internal/models"
+    "vietick/internal/services"
+)
+
+// RoleMiddleware chỉ cho user có một trong các role cho trước đi tiếp; dùng sau AuthMiddleware cho các route quản trị
+func RoleMiddleware(roles ...models.UserRole) gin.HandlerFunc {
+    userService := services.NewUserService()
+
+    return func(c *gin.Context) {
+        userID, ok := c.Get("user_id")
+        userIDUUID, isUUID := userID.(uuid.UUID)
+        if !ok || !isUUID {
+            c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+            return
+        }
+
+        allowed, err := userService.HasRole(userIDUUID, roles...)
+        if err != nil {
+            c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+            return
+        }
+        if !allowed {
+            c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+            return
+        }
+
+        c.Next()
+    }
+}
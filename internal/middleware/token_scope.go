@@ -0,0 +1,53 @@
+package middleware
+
+import (
+    "fmt"
+    "net/http"
+    "strings"
+
+    "github.com/gin-gonic/gin"
+    "vietick/internal/models"
+)
+
+// TokenScopeMiddleware giới hạn những gì personal access token được làm; JWT đăng nhập không bị ảnh hưởng.
+// Route GET cần scope read, trừ các route trong sessionOnly. Route khác chỉ dùng được nếu có trong scopes
+// (khóa dạng "POST /questions", token cần một trong các scope liệt kê), còn lại chỉ dành cho JWT.
+func TokenScopeMiddleware(scopes map[string][]models.TokenScope, sessionOnly ...string) gin.HandlerFunc {
+    sessionRoutes := make(map[string]bool, len(sessionOnly))
+    for _, route := range sessionOnly {
+        sessionRoutes[route] = true
+    }
+
+    return func(c *gin.Context) {
+        value, ok := c.Get("token_scopes")
+        if !ok {
+            c.Next()
+            return
+        }
+        granted, _ := value.([]models.TokenScope)
+
+        route := c.Request.Method + " " + c.FullPath()
+        required, listed := scopes[route]
+        if !listed && (c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead) && !sessionRoutes[route] {
+            required, listed = []models.TokenScope{models.ScopeRead}, true
+        }
+        if !listed {
+            c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "this endpoint cannot be used with a personal access token"})
+            return
+        }
+
+        for _, scope := range required {
+            for _, have := range granted {
+                if have == scope {
+                    c.Next()
+                    return
+                }
+            }
+        }
+        names := make([]string, 0, len(required))
+        for _, scope := range required {
+            names = append(names, string(scope))
+        }
+        c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("personal access token is missing the required scope: %s", strings.Join(names, " or "))})
+    }
+}
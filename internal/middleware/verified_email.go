@@ -0,0 +1,35 @@
+package middleware
+
+import (
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+    "vietick/internal/services"
+)
+
+// VerifiedEmailMiddleware chặn tài khoản chưa xác minh email; dùng sau AuthMiddleware cho các route đăng nội dung
+func VerifiedEmailMiddleware() gin.HandlerFunc {
+    authService := services.NewAuthService()
+
+    return func(c *gin.Context) {
+        userID, ok := c.Get("user_id")
+        userIDUUID, isUUID := userID.(uuid.UUID)
+        if !ok || !isUUID {
+            c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+            return
+        }
+
+        verified, err := authService.IsEmailVerified(userIDUUID)
+        if err != nil {
+            c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+            return
+        }
+        if !verified {
+            c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "email not verified"})
+            return
+        }
+
+        c.Next()
+    }
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+    "time"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+)
+
+type BadgeTier string
+
+const (
+    BadgeTierBronze BadgeTier = "bronze"
+    BadgeTierSilver BadgeTier = "silver"
+    BadgeTierGold   BadgeTier = "gold"
+)
+
+// UserBadge là một huy hiệu user đã nhận. Định nghĩa huy hiệu nằm trong code (services.badgeRules),
+// bảng này chỉ lưu lần trao. Scope phân biệt huy hiệu theo tag (tag ID), rỗng với huy hiệu chung.
+type UserBadge struct {
+    ID        uuid.UUID  `gorm:"type:char(36);primaryKey;collate:utf8mb4_general_ci"`
+    UserID    uuid.UUID  `gorm:"type:char(36);not null;uniqueIndex:idx_user_badge;collate:utf8mb4_general_ci"`
+    BadgeKey  string     `gorm:"type:varchar(64);not null;uniqueIndex:idx_user_badge;index"`
+    Scope     string     `gorm:"type:varchar(64);not null;default:'';uniqueIndex:idx_user_badge"`
+    SubjectID *uuid.UUID `gorm:"type:char(36);collate:utf8mb4_general_ci"` // Câu hỏi/câu trả lời dẫn tới huy hiệu, nếu có
+    AwardedAt time.Time  `gorm:"not null;index"`
+
+    User User `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (b *UserBadge) BeforeCreate(tx *gorm.DB) error {
+    if b.ID == uuid.Nil {
+        b.ID = uuid.New()
+    }
+    return nil
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+    "time"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+)
+
+// BookmarkCollection là nhóm bookmark do user đặt tên; collection công khai ai cũng xem được
+type BookmarkCollection struct {
+    ID          uuid.UUID `gorm:"type:char(36);primaryKey;collate:utf8mb4_general_ci"`
+    UserID      uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_bookmark_collection_user_name;collate:utf8mb4_general_ci"`
+    Name        string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_bookmark_collection_user_name;collate:utf8mb4_general_ci"`
+    Description string    `gorm:"type:varchar(500);collate:utf8mb4_general_ci"`
+    IsPublic    bool      `gorm:"not null;default:false"`
+    CreatedAt   time.Time `gorm:"not null"`
+    UpdatedAt   time.Time `gorm:"not null"`
+
+    User User `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (c *BookmarkCollection) BeforeCreate(tx *gorm.DB) error {
+    if c.ID == uuid.Nil {
+        c.ID = uuid.New()
+    }
+    return nil
+}
+
+// Bookmark lưu câu hỏi để xem sau; mỗi câu hỏi chỉ bookmark một lần, nằm trong tối đa một collection
+type Bookmark struct {
+    ID           uuid.UUID  `gorm:"type:char(36);primaryKey;collate:utf8mb4_general_ci"`
+    UserID       uuid.UUID  `gorm:"type:char(36);not null;uniqueIndex:idx_bookmark_user_question;collate:utf8mb4_general_ci"`
+    QuestionID   uuid.UUID  `gorm:"type:char(36);not null;uniqueIndex:idx_bookmark_user_question;index;collate:utf8mb4_general_ci"`
+    CollectionID *uuid.UUID `gorm:"type:char(36);index;collate:utf8mb4_general_ci"` // nil: không thuộc collection nào
+    CreatedAt    time.Time  `gorm:"not null"`
+
+    User       User                `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+    Question   Question            `gorm:"foreignKey:QuestionID;references:ID;constraint:OnDelete:CASCADE"`
+    Collection *BookmarkCollection `gorm:"foreignKey:CollectionID;references:ID;constraint:OnDelete:SET NULL" json:"-"`
+}
+
+func (b *Bookmark) BeforeCreate(tx *gorm.DB) error {
+    if b.ID == uuid.Nil {
+        b.ID = uuid.New()
+    }
+    return nil
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+    "time"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+)
+
+type BountyStatus string
+
+const (
+    BountyStatusOpen     BountyStatus = "open"
+    BountyStatusAwarded  BountyStatus = "awarded"
+    BountyStatusRefunded BountyStatus = "refunded"
+)
+
+// Bounty là điểm thưởng user đặt cho câu hỏi. Điểm bị trừ (giữ lại) khi đặt bounty và
+// được trao cho một câu trả lời hoặc hoàn lại cho người đặt khi bounty đóng.
+// Không có khóa ngoại tới câu hỏi/user (giống PointTransaction.BountyID) để bounty vẫn còn làm
+// lịch sử đối soát điểm sau khi câu hỏi bị xóa.
+type Bounty struct {
+    ID              uuid.UUID    `gorm:"type:char(36);primaryKey;collate:utf8mb4_general_ci" json:"id"`
+    QuestionID      uuid.UUID    `gorm:"type:char(36);not null;index;collate:utf8mb4_general_ci" json:"question_id"`
+    OpenQuestionID  *uuid.UUID   `gorm:"type:char(36);uniqueIndex;collate:utf8mb4_general_ci" json:"-"`          // = QuestionID khi đang mở, để mỗi câu hỏi có tối đa một bounty mở
+    UserID          uuid.UUID    `gorm:"type:char(36);not null;index;collate:utf8mb4_general_ci" json:"user_id"` // Người đặt bounty
+    Amount          int64        `gorm:"not null" json:"amount"`
+    Status          BountyStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+    ExpiresAt       time.Time    `gorm:"not null;index" json:"expires_at"`
+    AwardedAnswerID *uuid.UUID   `gorm:"type:char(36);collate:utf8mb4_general_ci" json:"awarded_answer_id"`
+    AwardedTo       *uuid.UUID   `gorm:"type:char(36);index;collate:utf8mb4_general_ci" json:"awarded_to"`
+    AutoAwarded     bool         `gorm:"not null;default:false" json:"auto_awarded"` // Trao tự động khi hết hạn
+    ClosedAt        *time.Time   `json:"closed_at"`
+    CreatedAt       time.Time    `gorm:"not null" json:"created_at"`
+    UpdatedAt       time.Time    `gorm:"not null" json:"updated_at"`
+}
+
+func (b *Bounty) BeforeCreate(tx *gorm.DB) error {
+    if b.ID == uuid.Nil {
+        b.ID = uuid.New()
+    }
+    return nil
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+    "time"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+)
+
+type DigestFrequency string
+
+const (
+    DigestFrequencyOff    DigestFrequency = "off"
+    DigestFrequencyDaily  DigestFrequency = "daily"
+    DigestFrequencyWeekly DigestFrequency = "weekly"
+)
+
+// EmailDigestSetting lưu tần suất gửi email tổng hợp notification chưa đọc của user
+type EmailDigestSetting struct {
+    ID         uuid.UUID       `gorm:"type:char(36);primaryKey;collate:utf8mb4_general_ci"`
+    UserID     uuid.UUID       `gorm:"type:char(36);not null;uniqueIndex;collate:utf8mb4_general_ci"`
+    Frequency  DigestFrequency `gorm:"type:varchar(10);not null;default:'weekly'"`
+    LastSentAt *time.Time      // Mốc của lần digest gần nhất, notification sau mốc này sẽ vào digest tiếp theo
+    CreatedAt  time.Time       `gorm:"not null"`
+    UpdatedAt  time.Time       `gorm:"not null"`
+
+    User User `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+func (s *EmailDigestSetting) BeforeCreate(tx *gorm.DB) error {
+    if s.ID == uuid.Nil {
+        s.ID = uuid.New()
+    }
+    return nil
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+    "time"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+)
+
+type JobStatus string
+
+const (
+    JobStatusPending JobStatus = "pending"
+    JobStatusRunning JobStatus = "running"
+    JobStatusDone    JobStatus = "done"
+    JobStatusFailed  JobStatus = "failed"
+)
+
+// Job là công việc nền được lưu DB để không mất khi server restart
+type Job struct {
+    ID          uuid.UUID  `gorm:"type:char(36);primaryKey;collate:utf8mb4_general_ci"`
+    Type        string     `gorm:"type:varchar(50);not null;index"`
+    Payload     string     `gorm:"type:json;not null"`
+    Status      JobStatus  `gorm:"type:varchar(20);not null;index:idx_jobs_status_run_at"`
+    Attempts    int        `gorm:"not null;default:0"`
+    MaxAttempts int        `gorm:"not null;default:5"`
+    RunAt       time.Time  `gorm:"not null;index:idx_jobs_status_run_at"` // Thời điểm sớm nhất được chạy (dùng cho backoff)
+    LockedBy    *uuid.UUID `gorm:"type:char(36);index;collate:utf8mb4_general_ci"`
+    LockedAt    *time.Time
+    Checkpoint  string     `gorm:"type:varchar(255)"` // Tiến độ để chạy tiếp khi retry
+    LastError   string     `gorm:"type:text"`
+    CreatedAt   time.Time  `gorm:"not null"`
+    UpdatedAt   time.Time  `gorm:"not null"`
+}
+
+func (j *Job) BeforeCreate(tx *gorm.DB) error {
+    if j.ID == uuid.Nil {
+        j.ID = uuid.New()
+    }
+    return nil
+}
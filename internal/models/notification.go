@@ -17,18 +17,45 @@ const (
     NotificationTypeVerify     NotificationType = "verify"
     NotificationTypeQuestion   NotificationType = "question"
     NotificationTypeTag        NotificationType = "tag"
+    NotificationTypeBadge      NotificationType = "badge"
+    NotificationTypeBounty     NotificationType = "bounty"
 )
 
+// NotificationTypes liệt kê tất cả loại notification, dùng cho cài đặt của user
+var NotificationTypes = []NotificationType{
+    NotificationTypeFollow,
+    NotificationTypeUnfollow,
+    NotificationTypeAnswer,
+    NotificationTypeVote,
+    NotificationTypeVerify,
+    NotificationTypeQuestion,
+    NotificationTypeTag,
+    NotificationTypeBadge,
+    NotificationTypeBounty,
+}
+
+// IsValid kiểm tra loại notification có được hỗ trợ không
+func (t NotificationType) IsValid() bool {
+    for _, known := range NotificationTypes {
+        if t == known {
+            return true
+        }
+    }
+    return false
+}
+
 type Notification struct {
-    ID        uuid.UUID        `gorm:"type:char(36);primaryKey;collate:utf8mb4_general_ci"`
-    UserID    uuid.UUID        `gorm:"type:char(36);not null;index;collate:utf8mb4_general_ci"` // Người nhận notification
-    Type      NotificationType `gorm:"type:varchar(20);not null"`
-    Title     string           `gorm:"type:varchar(255);not null"`
-    Message   string           `gorm:"type:text;not null"`
-    Data      string           `gorm:"type:json"` // JSON data cho additional info
-    IsRead    bool             `gorm:"default:false"`
-    CreatedAt time.Time        `gorm:"not null"`
-    UpdatedAt time.Time        `gorm:"not null"`
+    ID         uuid.UUID        `gorm:"type:char(36);primaryKey;collate:utf8mb4_general_ci"`
+    UserID     uuid.UUID        `gorm:"type:char(36);not null;index;collate:utf8mb4_general_ci"` // Người nhận notification
+    Type       NotificationType `gorm:"type:varchar(20);not null"`
+    Title      string           `gorm:"type:varchar(255);not null"`
+    Message    string           `gorm:"type:text;not null"`
+    Data       string           `gorm:"type:json"`               // JSON data cho additional info
+    GroupKey   string           `gorm:"type:varchar(100);index"` // Khóa gộp các notification cùng sự kiện (vd: vote:<answer_id>)
+    IsRead     bool             `gorm:"default:false"`
+    DigestOnly bool             `gorm:"not null;default:false" json:"-"` // Chỉ dùng cho email digest (user tắt in-app), không hiện trong danh sách notification
+    CreatedAt  time.Time        `gorm:"not null"`
+    UpdatedAt  time.Time        `gorm:"not null"`
 
     User User `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
 }
@@ -0,0 +1,33 @@
+package models
+
+import (
+    "time"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+)
+
+type MuteTargetType string
+
+const (
+    MuteTargetUser     MuteTargetType = "user"
+    MuteTargetQuestion MuteTargetType = "question"
+)
+
+// NotificationMute tắt mọi notification liên quan đến một user hoặc một câu hỏi
+type NotificationMute struct {
+    ID         uuid.UUID      `gorm:"type:char(36);primaryKey;collate:utf8mb4_general_ci"`
+    UserID     uuid.UUID      `gorm:"type:char(36);not null;uniqueIndex:idx_notification_mute_target;collate:utf8mb4_general_ci"` // Người tắt thông báo
+    TargetType MuteTargetType `gorm:"type:varchar(20);not null;uniqueIndex:idx_notification_mute_target"`
+    TargetID   uuid.UUID      `gorm:"type:char(36);not null;uniqueIndex:idx_notification_mute_target;collate:utf8mb4_general_ci"`
+    CreatedAt  time.Time      `gorm:"not null"`
+
+    User User `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+func (m *NotificationMute) BeforeCreate(tx *gorm.DB) error {
+    if m.ID == uuid.Nil {
+        m.ID = uuid.New()
+    }
+    return nil
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+    "time"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+)
+
+// NotificationPreference lưu kênh nhận thông báo của user cho từng loại notification.
+// Không có bản ghi nghĩa là dùng cấu hình mặc định.
+type NotificationPreference struct {
+    ID          uuid.UUID        `gorm:"type:char(36);primaryKey;collate:utf8mb4_general_ci"`
+    UserID      uuid.UUID        `gorm:"type:char(36);not null;uniqueIndex:idx_notification_pref_user_type;collate:utf8mb4_general_ci"`
+    Type        NotificationType `gorm:"type:varchar(20);not null;uniqueIndex:idx_notification_pref_user_type"`
+    InApp       bool             `gorm:"not null;default:true"` // Lưu vào danh sách notification
+    SSE         bool             `gorm:"not null;default:true"` // Gửi real-time qua SSE
+    EmailDigest bool             `gorm:"not null;default:true"` // Đưa vào email digest
+    CreatedAt   time.Time        `gorm:"not null"`
+    UpdatedAt   time.Time        `gorm:"not null"`
+
+    User User `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+func (p *NotificationPreference) BeforeCreate(tx *gorm.DB) error {
+    if p.ID == uuid.Nil {
+        p.ID = uuid.New()
+    }
+    return nil
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+    "strings"
+    "time"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+)
+
+type TokenScope string
+
+const (
+    ScopeRead           TokenScope = "read"
+    ScopeWriteQuestions TokenScope = "write:questions"
+    ScopeWriteAnswers   TokenScope = "write:answers"
+    ScopeVote           TokenScope = "vote"
+)
+
+// TokenScopes là các scope user được chọn khi tạo personal access token
+var TokenScopes = []TokenScope{ScopeRead, ScopeWriteQuestions, ScopeWriteAnswers, ScopeVote}
+
+// PersonalAccessToken là token dài hạn cho bot/tích hợp; chỉ lưu SHA-256 của token, không lưu token gốc.
+// ExpiresAt nil nghĩa là token không hết hạn cho tới khi bị thu hồi.
+type PersonalAccessToken struct {
+    ID         uuid.UUID `gorm:"type:char(36);primaryKey;collate:utf8mb4_general_ci"`
+    UserID     uuid.UUID `gorm:"type:char(36);not null;index;collate:utf8mb4_general_ci"`
+    Name       string    `gorm:"type:varchar(100);not null"`
+    TokenHash  string    `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+    Prefix     string    `gorm:"type:varchar(16);not null"`  // Vài ký tự đầu của token để user nhận ra
+    Scopes     string    `gorm:"type:varchar(255);not null"` // Các scope cách nhau bằng dấu cách
+    ExpiresAt  *time.Time
+    LastUsedAt *time.Time
+    LastUsedIP string    `gorm:"type:varchar(45)"`
+    CreatedAt  time.Time `gorm:"not null"`
+
+    User User `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (t *PersonalAccessToken) BeforeCreate(tx *gorm.DB) error {
+    if t.ID == uuid.Nil {
+        t.ID = uuid.New()
+    }
+    return nil
+}
+
+// ScopeList tách Scopes thành danh sách
+func (t *PersonalAccessToken) ScopeList() []TokenScope {
+    fields := strings.Fields(t.Scopes)
+    scopes := make([]TokenScope, 0, len(fields))
+    for _, field := range fields {
+        scopes = append(scopes, TokenScope(field))
+    }
+    return scopes
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+    "time"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+)
+
+type PointTransactionReason string
+
+const (
+    PointReasonBountyEscrow PointTransactionReason = "bounty_escrow"
+    PointReasonBountyAward  PointTransactionReason = "bounty_award"
+    PointReasonBountyRefund PointTransactionReason = "bounty_refund"
+    PointReasonAnswerVote   PointTransactionReason = "answer_vote"     // Câu trả lời được upvote/downvote (âm khi bị downvote hoặc bỏ upvote)
+    PointReasonAnswerVerify PointTransactionReason = "answer_verified" // Câu trả lời được xác minh (âm khi bị bỏ xác minh)
+    PointReasonBackfill     PointTransactionReason = "reputation_backfill"
+)
+
+// PointTransaction là một lần thay đổi điểm của user, dùng để đối soát. Không có khóa ngoại tới
+// bounty/câu trả lời để lịch sử vẫn còn khi câu hỏi bị xóa.
+type PointTransaction struct {
+    ID        uuid.UUID              `gorm:"type:char(36);primaryKey;collate:utf8mb4_general_ci" json:"id"`
+    UserID    uuid.UUID              `gorm:"type:char(36);not null;index;collate:utf8mb4_general_ci" json:"-"`
+    Amount    int64                  `gorm:"not null" json:"amount"`  // Âm khi trừ điểm
+    Balance   int64                  `gorm:"not null" json:"balance"` // Điểm sau giao dịch
+    Reason    PointTransactionReason `gorm:"type:varchar(32);not null" json:"reason"`
+    BountyID  *uuid.UUID             `gorm:"type:char(36);index;collate:utf8mb4_general_ci" json:"bounty_id"`
+    AnswerID  *uuid.UUID             `gorm:"type:char(36);collate:utf8mb4_general_ci" json:"answer_id"`
+    CreatedAt time.Time              `gorm:"not null;index" json:"created_at"`
+
+    User User `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (t *PointTransaction) BeforeCreate(tx *gorm.DB) error {
+    if t.ID == uuid.Nil {
+        t.ID = uuid.New()
+    }
+    return nil
+}
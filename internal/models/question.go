@@ -8,16 +8,21 @@ import (
 )
 
 type Question struct {
-    ID        uuid.UUID `gorm:"type:char(36);primaryKey;collate:utf8mb4_general_ci"`
-    Title     string    `gorm:"type:varchar(255);not null;collate:utf8mb4_general_ci"`
-    Content   string    `gorm:"type:text;not null;collate:utf8mb4_general_ci"`
-    UserID    uuid.UUID `gorm:"type:char(36);not null;collate:utf8mb4_general_ci"`
-    CreatedAt time.Time `gorm:"not null"`
-    UpdatedAt time.Time `gorm:"not null"`
+    ID            uuid.UUID `gorm:"type:char(36);primaryKey;collate:utf8mb4_general_ci"`
+    Title         string    `gorm:"type:varchar(255);not null;collate:utf8mb4_general_ci"`
+    Content       string    `gorm:"type:text;not null;collate:utf8mb4_general_ci"`                  // Markdown gốc
+    ContentHTML   string    `gorm:"type:mediumtext;collate:utf8mb4_general_ci" json:"content_html"` // HTML đã render và sanitize
+    ContentText   string    `gorm:"type:text;collate:utf8mb4_general_ci" json:"-"`                  // Văn bản thuần cho tìm kiếm, preview
+    UserID        uuid.UUID `gorm:"type:char(36);not null;collate:utf8mb4_general_ci"`
+    BookmarkCount int64     `gorm:"not null;default:0" json:"bookmark_count"` // Cập nhật khi bookmark/bỏ bookmark
+    ViewCount     int64     `gorm:"not null;default:0" json:"view_count"`     // Ghi theo lô bởi services.ViewService
+    CreatedAt     time.Time `gorm:"not null"`
+    UpdatedAt     time.Time `gorm:"not null"`
 
-    User    User     `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
-    Answers []Answer `gorm:"foreignKey:QuestionID;references:ID;constraint:OnDelete:CASCADE"`
-    Tags    []Tag    `gorm:"many2many:question_tags;"`
+    User        User     `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+    Answers     []Answer `gorm:"foreignKey:QuestionID;references:ID;constraint:OnDelete:CASCADE"`
+    Tags        []Tag    `gorm:"many2many:question_tags;"`
+    Attachments []Upload `gorm:"many2many:question_attachments;"`
 }
 
 func (q *Question) BeforeCreate(tx *gorm.DB) error {
@@ -25,4 +30,4 @@ func (q *Question) BeforeCreate(tx *gorm.DB) error {
         q.ID = uuid.New()
     }
     return nil
-} 
\ No newline at end of file
+}
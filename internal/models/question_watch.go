@@ -0,0 +1,25 @@
+package models
+
+import (
+    "time"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+)
+
+type QuestionWatch struct {
+    ID         uuid.UUID `gorm:"type:char(36);primaryKey;collate:utf8mb4_general_ci"`
+    UserID     uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_question_watch_user_question;collate:utf8mb4_general_ci"` // Người theo dõi câu hỏi
+    QuestionID uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_question_watch_user_question;index;collate:utf8mb4_general_ci"`
+    CreatedAt  time.Time `gorm:"not null"`
+
+    User     User     `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+    Question Question `gorm:"foreignKey:QuestionID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+func (w *QuestionWatch) BeforeCreate(tx *gorm.DB) error {
+    if w.ID == uuid.Nil {
+        w.ID = uuid.New()
+    }
+    return nil
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+    "time"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+)
+
+// SigningKey là khóa bất đối xứng ký JWT đăng nhập; ID dùng làm kid trong header token và JWKS.
+// Khóa được công bố từ lúc tạo, bắt đầu dùng để ký từ ActivatesAt và bị xóa sau ExpiresAt.
+type SigningKey struct {
+    ID          uuid.UUID  `gorm:"type:char(36);primaryKey;collate:utf8mb4_general_ci"`
+    Algorithm   string     `gorm:"type:varchar(10);not null"`   // RS256 | EdDSA
+    PrivateKey  string     `gorm:"type:text;not null" json:"-"` // PKCS#8 PEM đã mã hóa AES-GCM
+    PublicKey   string     `gorm:"type:text;not null"`          // PKIX PEM
+    ActivatesAt time.Time  `gorm:"not null;uniqueIndex"`
+    ExpiresAt   *time.Time `gorm:"index"` // nil: khóa mới nhất, chưa có khóa thay thế
+    CreatedAt   time.Time  `gorm:"not null"`
+}
+
+func (k *SigningKey) BeforeCreate(tx *gorm.DB) error {
+    if k.ID == uuid.Nil {
+        k.ID = uuid.New()
+    }
+    return nil
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+    "time"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+)
+
+type TagFollow struct {
+    ID        uuid.UUID `gorm:"type:char(36);primaryKey;collate:utf8mb4_general_ci"`
+    UserID    uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_tag_follow_user_tag;collate:utf8mb4_general_ci"` // Người follow tag
+    TagID     uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_tag_follow_user_tag;index;collate:utf8mb4_general_ci"`
+    CreatedAt time.Time `gorm:"not null"`
+
+    User User `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+    Tag  Tag  `gorm:"foreignKey:TagID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+func (f *TagFollow) BeforeCreate(tx *gorm.DB) error {
+    if f.ID == uuid.Nil {
+        f.ID = uuid.New()
+    }
+    return nil
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+    "time"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+)
+
+// TwoFactor lưu cấu hình xác thực hai lớp bằng TOTP của user.
+// EnabledAt nil nghĩa là user đã tạo secret nhưng chưa xác nhận bằng mã đầu tiên.
+type TwoFactor struct {
+    ID             uuid.UUID `gorm:"type:char(36);primaryKey;collate:utf8mb4_general_ci"`
+    UserID         uuid.UUID `gorm:"type:char(36);not null;uniqueIndex;collate:utf8mb4_general_ci"`
+    Secret         string    `gorm:"type:varchar(255);not null" json:"-"` // Secret TOTP đã mã hóa AES-GCM
+    EnabledAt      *time.Time
+    LastUsedStep   int64      `gorm:"not null;default:0" json:"-"` // Bước thời gian của mã dùng gần nhất, chặn dùng lại mã
+    FailedAttempts int        `gorm:"not null;default:0" json:"-"`
+    LockedUntil    *time.Time `json:"-"` // Tạm khóa nhập mã sau nhiều lần sai
+    CreatedAt      time.Time  `gorm:"not null"`
+    UpdatedAt      time.Time  `gorm:"not null"`
+
+    User User `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (t *TwoFactor) BeforeCreate(tx *gorm.DB) error {
+    if t.ID == uuid.Nil {
+        t.ID = uuid.New()
+    }
+    return nil
+}
+
+// RecoveryCode là mã khôi phục dùng một lần khi mất thiết bị TOTP; chỉ lưu SHA-256 của mã
+type RecoveryCode struct {
+    ID        uuid.UUID `gorm:"type:char(36);primaryKey;collate:utf8mb4_general_ci"`
+    UserID    uuid.UUID `gorm:"type:char(36);not null;index;collate:utf8mb4_general_ci"`
+    CodeHash  string    `gorm:"type:char(64);not null;uniqueIndex"`
+    UsedAt    *time.Time
+    CreatedAt time.Time `gorm:"not null"`
+
+    User User `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (c *RecoveryCode) BeforeCreate(tx *gorm.DB) error {
+    if c.ID == uuid.Nil {
+        c.ID = uuid.New()
+    }
+    return nil
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+    "time"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+)
+
+type UploadKind string
+
+const (
+    UploadKindAvatar     UploadKind = "avatar"
+    UploadKindAttachment UploadKind = "attachment"
+)
+
+// Upload là file người dùng tải lên. File được đặt tên theo SHA-256 nội dung
+// nên cùng một file tải lên nhiều lần chỉ lưu một bản trong storage.
+type Upload struct {
+    ID           uuid.UUID  `gorm:"type:char(36);primaryKey;collate:utf8mb4_general_ci"`
+    UserID       uuid.UUID  `gorm:"type:char(36);not null;index;collate:utf8mb4_general_ci"` // Người tải lên
+    Kind         UploadKind `gorm:"type:varchar(20);not null"`
+    FileName     string     `gorm:"type:varchar(255);collate:utf8mb4_general_ci"` // Tên file gốc, chỉ để hiển thị
+    ContentType  string     `gorm:"type:varchar(100);not null"`                   // MIME phát hiện từ nội dung, không tin header của client
+    Size         int64      `gorm:"not null"`
+    Checksum     string     `gorm:"type:char(64);not null;index"` // SHA-256 (hex) của nội dung
+    StorageKey   string     `gorm:"type:varchar(255);not null"`
+    URL          string     `gorm:"type:varchar(500);not null"`
+    Width        int        // Chỉ có với ảnh
+    Height       int
+    ThumbnailKey string     `gorm:"type:varchar(255)"`
+    ThumbnailURL string     `gorm:"type:varchar(500)"`
+    CreatedAt    time.Time  `gorm:"not null"`
+
+    User User `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+func (u *Upload) BeforeCreate(tx *gorm.DB) error {
+    if u.ID == uuid.Nil {
+        u.ID = uuid.New()
+    }
+    return nil
+}
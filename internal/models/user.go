@@ -7,14 +7,36 @@ import (
     "gorm.io/gorm"
 )
 
+type UserRole string
+
+const (
+    RoleUser      UserRole = "user"
+    RoleModerator UserRole = "moderator"
+    RoleAdmin     UserRole = "admin"
+)
+
+// RequiresTwoFactor cho biết tài khoản có bắt buộc bật xác thực hai lớp không
+func (r UserRole) RequiresTwoFactor() bool {
+    return r == RoleModerator || r == RoleAdmin
+}
+
 type User struct {
-    ID        uuid.UUID `gorm:"type:char(36);primaryKey;collate:utf8mb4_general_ci"`
-    Email     string    `gorm:"type:varchar(255);unique;not null;collate:utf8mb4_general_ci"`
-    Username  string    `gorm:"type:varchar(50);unique;not null;collate:utf8mb4_general_ci"`
-    Password  string    `gorm:"type:varchar(255);not null;collate:utf8mb4_general_ci"`
-    Point     int64     `gorm:"type:bigint;default:0"`
-    CreatedAt time.Time `gorm:"not null"`
-    UpdatedAt time.Time `gorm:"not null"`
+    ID                uuid.UUID  `gorm:"type:char(36);primaryKey;collate:utf8mb4_general_ci"`
+    Email             string     `gorm:"type:varchar(255);unique;not null;collate:utf8mb4_general_ci"`
+    Username          string     `gorm:"type:varchar(50);unique;not null;collate:utf8mb4_general_ci"`
+    Password          string     `gorm:"type:varchar(255);not null;collate:utf8mb4_general_ci" json:"-"` // Không bao giờ trả hash mật khẩu ra API
+    Point             int64      `gorm:"type:bigint;default:0"`
+    Role              UserRole   `gorm:"type:varchar(20);not null;default:'user'"`
+    DisplayName       string     `gorm:"type:varchar(50);collate:utf8mb4_general_ci"`
+    Bio               string     `gorm:"type:varchar(500);collate:utf8mb4_general_ci"`
+    Location          string     `gorm:"type:varchar(100);collate:utf8mb4_general_ci"`
+    Website           string     `gorm:"type:varchar(255)"`
+    AvatarURL         string     `gorm:"type:varchar(500)"`
+    AvatarUploadID    *uuid.UUID `gorm:"type:char(36);collate:utf8mb4_general_ci"` // Upload của avatar nếu tải lên qua /users/me/avatar
+    EmailVerifiedAt   *time.Time // nil khi chưa xác minh email
+    UsernameChangedAt *time.Time // Lần đổi username gần nhất, dùng cho thời gian chờ
+    CreatedAt         time.Time  `gorm:"not null"`
+    UpdatedAt         time.Time  `gorm:"not null"`
 
     Questions []Question `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
     Answers   []Answer   `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
@@ -29,5 +51,8 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
     if u.ID == uuid.Nil {
         u.ID = uuid.New()
     }
+    if u.Role == "" {
+        u.Role = RoleUser
+    }
     return nil
 } 
\ No newline at end of file
@@ -0,0 +1,29 @@
+package models
+
+import (
+    "time"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+)
+
+// UserIdentity liên kết tài khoản với một danh tính bên ngoài (Google, GitHub, OIDC).
+// Mỗi user có tối đa một danh tính cho mỗi provider.
+type UserIdentity struct {
+    ID        uuid.UUID `gorm:"type:char(36);primaryKey;collate:utf8mb4_general_ci"`
+    UserID    uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_user_identity_user_provider;collate:utf8mb4_general_ci"`
+    Provider  string    `gorm:"type:varchar(30);not null;uniqueIndex:idx_user_identity_user_provider;uniqueIndex:idx_user_identity_provider_subject"`
+    Subject   string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_user_identity_provider_subject"` // ID của user phía provider
+    Email     string    `gorm:"type:varchar(255);collate:utf8mb4_general_ci"`                             // Email provider trả về lúc liên kết
+    CreatedAt time.Time `gorm:"not null"`
+    UpdatedAt time.Time `gorm:"not null"`
+
+    User User `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (i *UserIdentity) BeforeCreate(tx *gorm.DB) error {
+    if i.ID == uuid.Nil {
+        i.ID = uuid.New()
+    }
+    return nil
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+    "time"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+)
+
+type UserTokenPurpose string
+
+const (
+    UserTokenEmailVerification UserTokenPurpose = "email_verification"
+    UserTokenPasswordReset     UserTokenPurpose = "password_reset"
+    UserTokenEmailChange       UserTokenPurpose = "email_change"
+)
+
+// UserToken là token dùng một lần gửi qua email; chỉ lưu SHA-256 của token, không lưu token gốc
+type UserToken struct {
+    ID        uuid.UUID        `gorm:"type:char(36);primaryKey;collate:utf8mb4_general_ci"`
+    UserID    uuid.UUID        `gorm:"type:char(36);not null;index;collate:utf8mb4_general_ci"`
+    Purpose   UserTokenPurpose `gorm:"type:varchar(30);not null"`
+    TokenHash string           `gorm:"type:char(64);not null;uniqueIndex"`
+    Email     string           `gorm:"type:varchar(255);collate:utf8mb4_general_ci"` // Email mới, chỉ dùng cho email_change
+    ExpiresAt time.Time        `gorm:"not null"`
+    UsedAt    *time.Time
+    CreatedAt time.Time        `gorm:"not null"`
+
+    User User `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+func (t *UserToken) BeforeCreate(tx *gorm.DB) error {
+    if t.ID == uuid.Nil {
+        t.ID = uuid.New()
+    }
+    return nil
+}
@@ -16,8 +16,8 @@ const (
 
 type Vote struct {
     ID        uuid.UUID `gorm:"type:char(36);primaryKey"`
-    UserID    uuid.UUID `gorm:"type:char(36);index;not null"`
-    AnswerID  uuid.UUID `gorm:"type:char(36);index;not null"`
+    UserID    uuid.UUID `gorm:"type:char(36);uniqueIndex:idx_vote_user_answer;not null"` // Mỗi user một vote cho mỗi câu trả lời
+    AnswerID  uuid.UUID `gorm:"type:char(36);uniqueIndex:idx_vote_user_answer;index;not null"`
     Type      VoteType  `gorm:"type:varchar(10);not null"`
     CreatedAt time.Time `gorm:"not null"`
     UpdatedAt time.Time `gorm:"not null"`
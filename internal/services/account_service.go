@@ -0,0 +1,574 @@
+package services
+
+import (
+    "archive/zip"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "log"
+    "path"
+    "time"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+    "vietick/config"
+    "vietick/internal/models"
+    "vietick/pkg/storage"
+)
+
+// DeletedUserID là tài khoản giữ chỗ nhận lại câu hỏi, câu trả lời, file đính kèm của các user đã xóa tài khoản,
+// để thread của người khác không bị mất theo
+var DeletedUserID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+const (
+    // Dài hơn giới hạn 20 ký tự khi đăng ký/đổi username nên không user thật nào trùng được
+    deletedUsername    = "__deleted_user_placeholder__"
+    deletedDisplayName = "Người dùng đã xóa"
+    deletedEmail       = "deleted-user@vietick.invalid"
+)
+
+var ErrCannotDeleteAccount = errors.New("this account cannot be deleted")
+
+type AccountService struct {
+    storage storage.Storage
+}
+
+// DeleteAccountRequest cần password; tài khoản không có mật khẩu gửi reauth_token hoặc code (2FA) thay thế
+type DeleteAccountRequest struct {
+    Password    string `json:"password"`
+    Code        string `json:"code"`
+    ReauthToken string `json:"reauth_token"`
+}
+
+// AccountExport là toàn bộ dữ liệu của user, trả về qua GET /users/me/export
+type AccountExport struct {
+    ExportedAt    time.Time              `json:"exported_at"`
+    Profile       ExportedProfile        `json:"profile"`
+    Questions     []ExportedQuestion     `json:"questions"`
+    Answers       []ExportedAnswer       `json:"answers"`
+    Votes         []ExportedVote         `json:"votes"`
+    Followers     []ExportedFollow       `json:"followers"`
+    Following     []ExportedFollow       `json:"following"`
+    FollowedTags  []ExportedTagFollow    `json:"followed_tags"`
+    Watches       []ExportedWatch        `json:"watched_questions"`
+    Bookmarks     []ExportedBookmark     `json:"bookmarks"`
+    Collections   []ExportedCollection   `json:"bookmark_collections"`
+    Badges        []ExportedBadge        `json:"badges"`
+    Bounties      []models.Bounty        `json:"bounties"` // Bounty user đã đặt
+    PointHistory  []ExportedPointChange  `json:"point_transactions"`
+    Notifications []ExportedNotification `json:"notifications"`
+    Uploads       []ExportedUpload       `json:"uploads"`
+    Identities    []ExportedIdentity     `json:"linked_accounts"`
+}
+
+type ExportedProfile struct {
+    ID              uuid.UUID  `json:"id"`
+    Email           string     `json:"email"`
+    Username        string     `json:"username"`
+    DisplayName     string     `json:"display_name"`
+    Bio             string     `json:"bio"`
+    Location        string     `json:"location"`
+    Website         string     `json:"website"`
+    AvatarURL       string     `json:"avatar_url"`
+    Reputation      int64      `json:"reputation"`
+    EmailVerifiedAt *time.Time `json:"email_verified_at"`
+    CreatedAt       time.Time  `json:"created_at"`
+    UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+type ExportedQuestion struct {
+    ID            uuid.UUID   `json:"id"`
+    Title         string      `json:"title"`
+    Content       string      `json:"content"`
+    Tags          []string    `json:"tags"`
+    AttachmentIDs []uuid.UUID `json:"attachment_ids"`
+    CreatedAt     time.Time   `json:"created_at"`
+    UpdatedAt     time.Time   `json:"updated_at"`
+}
+
+type ExportedAnswer struct {
+    ID            uuid.UUID   `json:"id"`
+    QuestionID    uuid.UUID   `json:"question_id"`
+    Content       string      `json:"content"`
+    IsVerified    bool        `json:"is_verified"`
+    AttachmentIDs []uuid.UUID `json:"attachment_ids"`
+    CreatedAt     time.Time   `json:"created_at"`
+    UpdatedAt     time.Time   `json:"updated_at"`
+}
+
+type ExportedVote struct {
+    AnswerID  uuid.UUID       `json:"answer_id"`
+    Type      models.VoteType `json:"type"`
+    CreatedAt time.Time       `json:"created_at"`
+}
+
+type ExportedFollow struct {
+    UserID    uuid.UUID `json:"user_id"`
+    Username  string    `json:"username"`
+    CreatedAt time.Time `json:"created_at"`
+}
+
+type ExportedTagFollow struct {
+    TagID     uuid.UUID `json:"tag_id"`
+    Name      string    `json:"name"`
+    CreatedAt time.Time `json:"created_at"`
+}
+
+type ExportedWatch struct {
+    QuestionID uuid.UUID `json:"question_id"`
+    CreatedAt  time.Time `json:"created_at"`
+}
+
+type ExportedBookmark struct {
+    QuestionID   uuid.UUID  `json:"question_id"`
+    CollectionID *uuid.UUID `json:"collection_id"`
+    CreatedAt    time.Time  `json:"created_at"`
+}
+
+type ExportedCollection struct {
+    ID          uuid.UUID `json:"id"`
+    Name        string    `json:"name"`
+    Description string    `json:"description"`
+    IsPublic    bool      `json:"is_public"`
+    CreatedAt   time.Time `json:"created_at"`
+}
+
+type ExportedBadge struct {
+    BadgeKey  string     `json:"badge"`
+    Scope     string     `json:"scope,omitempty"`
+    SubjectID *uuid.UUID `json:"subject_id"`
+    AwardedAt time.Time  `json:"awarded_at"`
+}
+
+type ExportedPointChange struct {
+    Amount    int64                         `json:"amount"`
+    Balance   int64                         `json:"balance"`
+    Reason    models.PointTransactionReason `json:"reason"`
+    BountyID  *uuid.UUID                    `json:"bounty_id"`
+    AnswerID  *uuid.UUID                    `json:"answer_id"`
+    CreatedAt time.Time                     `json:"created_at"`
+}
+
+type ExportedNotification struct {
+    Type      models.NotificationType `json:"type"`
+    Title     string                  `json:"title"`
+    Message   string                  `json:"message"`
+    Data      json.RawMessage         `json:"data,omitempty"`
+    IsRead    bool                    `json:"is_read"`
+    CreatedAt time.Time               `json:"created_at"`
+}
+
+type ExportedIdentity struct {
+    Provider  string    `json:"provider"`
+    Subject   string    `json:"subject"`
+    Email     string    `json:"email"`
+    CreatedAt time.Time `json:"created_at"`
+}
+
+type ExportedUpload struct {
+    ID          uuid.UUID         `json:"id"`
+    Kind        models.UploadKind `json:"kind"`
+    FileName    string            `json:"file_name"`
+    ContentType string            `json:"content_type"`
+    Size        int64             `json:"size"`
+    URL         string            `json:"url"`
+    ArchivePath string            `json:"archive_path,omitempty"` // Đường dẫn file trong archive ZIP
+    CreatedAt   time.Time         `json:"created_at"`
+
+    storageKey string
+}
+
+func NewAccountService() *AccountService {
+    return &AccountService{
+        storage: DefaultStorage(),
+    }
+}
+
+// ExportData gom toàn bộ dữ liệu của user: hồ sơ, câu hỏi, câu trả lời, vote, follow, notification, upload
+func (s *AccountService) ExportData(userID uuid.UUID) (*AccountExport, error) {
+    var user models.User
+    if err := config.DB.First(&user, "id = ?", userID).Error; err != nil {
+        return nil, ErrUserNotFound
+    }
+
+    export := &AccountExport{
+        ExportedAt: time.Now(),
+        Profile: ExportedProfile{
+            ID:              user.ID,
+            Email:           user.Email,
+            Username:        user.Username,
+            DisplayName:     user.DisplayName,
+            Bio:             user.Bio,
+            Location:        user.Location,
+            Website:         user.Website,
+            AvatarURL:       user.AvatarURL,
+            Reputation:      user.Point,
+            EmailVerifiedAt: user.EmailVerifiedAt,
+            CreatedAt:       user.CreatedAt,
+            UpdatedAt:       user.UpdatedAt,
+        },
+    }
+
+    var questions []models.Question
+    if err := config.DB.Preload("Tags").Preload("Attachments").Where("user_id = ?", userID).
+        Order("created_at").Find(&questions).Error; err != nil {
+        return nil, err
+    }
+    export.Questions = make([]ExportedQuestion, 0, len(questions))
+    for _, q := range questions {
+        tags := make([]string, 0, len(q.Tags))
+        for _, tag := range q.Tags {
+            tags = append(tags, tag.Name)
+        }
+        export.Questions = append(export.Questions, ExportedQuestion{
+            ID:            q.ID,
+            Title:         q.Title,
+            Content:       q.Content,
+            Tags:          tags,
+            AttachmentIDs: uploadIDs(q.Attachments),
+            CreatedAt:     q.CreatedAt,
+            UpdatedAt:     q.UpdatedAt,
+        })
+    }
+
+    var answers []models.Answer
+    if err := config.DB.Preload("Attachments").Where("user_id = ?", userID).
+        Order("created_at").Find(&answers).Error; err != nil {
+        return nil, err
+    }
+    export.Answers = make([]ExportedAnswer, 0, len(answers))
+    for _, a := range answers {
+        export.Answers = append(export.Answers, ExportedAnswer{
+            ID:            a.ID,
+            QuestionID:    a.QuestionID,
+            Content:       a.Content,
+            IsVerified:    a.IsVerified,
+            AttachmentIDs: uploadIDs(a.Attachments),
+            CreatedAt:     a.CreatedAt,
+            UpdatedAt:     a.UpdatedAt,
+        })
+    }
+
+    export.Votes = []ExportedVote{}
+    if err := config.DB.Model(&models.Vote{}).Select("answer_id", "type", "created_at").
+        Where("user_id = ?", userID).Order("created_at").Scan(&export.Votes).Error; err != nil {
+        return nil, err
+    }
+
+    export.Followers = []ExportedFollow{}
+    if err := config.DB.Table("follows").
+        Select("users.id AS user_id, users.username, follows.created_at").
+        Joins("JOIN users ON users.id = follows.follower_id").
+        Where("follows.following_id = ?", userID).
+        Order("follows.created_at").Scan(&export.Followers).Error; err != nil {
+        return nil, err
+    }
+
+    export.Following = []ExportedFollow{}
+    if err := config.DB.Table("follows").
+        Select("users.id AS user_id, users.username, follows.created_at").
+        Joins("JOIN users ON users.id = follows.following_id").
+        Where("follows.follower_id = ?", userID).
+        Order("follows.created_at").Scan(&export.Following).Error; err != nil {
+        return nil, err
+    }
+
+    export.FollowedTags = []ExportedTagFollow{}
+    if err := config.DB.Table("tag_follows").
+        Select("tags.id AS tag_id, tags.name, tag_follows.created_at").
+        Joins("JOIN tags ON tags.id = tag_follows.tag_id").
+        Where("tag_follows.user_id = ?", userID).
+        Order("tag_follows.created_at").Scan(&export.FollowedTags).Error; err != nil {
+        return nil, err
+    }
+
+    export.Watches = []ExportedWatch{}
+    if err := config.DB.Model(&models.QuestionWatch{}).Select("question_id", "created_at").
+        Where("user_id = ?", userID).Order("created_at").Scan(&export.Watches).Error; err != nil {
+        return nil, err
+    }
+
+    export.Bookmarks = []ExportedBookmark{}
+    if err := config.DB.Model(&models.Bookmark{}).Select("question_id", "collection_id", "created_at").
+        Where("user_id = ?", userID).Order("created_at").Scan(&export.Bookmarks).Error; err != nil {
+        return nil, err
+    }
+
+    export.Collections = []ExportedCollection{}
+    if err := config.DB.Model(&models.BookmarkCollection{}).Select("id", "name", "description", "is_public", "created_at").
+        Where("user_id = ?", userID).Order("created_at").Scan(&export.Collections).Error; err != nil {
+        return nil, err
+    }
+
+    export.Badges = []ExportedBadge{}
+    if err := config.DB.Model(&models.UserBadge{}).Select("badge_key", "scope", "subject_id", "awarded_at").
+        Where("user_id = ?", userID).Order("awarded_at").Scan(&export.Badges).Error; err != nil {
+        return nil, err
+    }
+
+    export.Bounties = []models.Bounty{}
+    if err := config.DB.Where("user_id = ?", userID).Order("created_at").Find(&export.Bounties).Error; err != nil {
+        return nil, err
+    }
+
+    export.PointHistory = []ExportedPointChange{}
+    if err := config.DB.Model(&models.PointTransaction{}).Select("amount", "balance", "reason", "bounty_id", "answer_id", "created_at").
+        Where("user_id = ?", userID).Order("created_at").Scan(&export.PointHistory).Error; err != nil {
+        return nil, err
+    }
+
+    var notifications []models.Notification
+    if err := config.DB.Where("user_id = ?", userID).Order("created_at").Find(&notifications).Error; err != nil {
+        return nil, err
+    }
+    export.Notifications = make([]ExportedNotification, 0, len(notifications))
+    for _, n := range notifications {
+        notice := ExportedNotification{
+            Type:      n.Type,
+            Title:     n.Title,
+            Message:   n.Message,
+            IsRead:    n.IsRead,
+            CreatedAt: n.CreatedAt,
+        }
+        if json.Valid([]byte(n.Data)) {
+            notice.Data = json.RawMessage(n.Data)
+        }
+        export.Notifications = append(export.Notifications, notice)
+    }
+
+    var uploads []models.Upload
+    if err := config.DB.Where("user_id = ?", userID).Order("created_at").Find(&uploads).Error; err != nil {
+        return nil, err
+    }
+    export.Uploads = make([]ExportedUpload, 0, len(uploads))
+    for _, u := range uploads {
+        export.Uploads = append(export.Uploads, ExportedUpload{
+            ID:          u.ID,
+            Kind:        u.Kind,
+            FileName:    u.FileName,
+            ContentType: u.ContentType,
+            Size:        u.Size,
+            URL:         u.URL,
+            CreatedAt:   u.CreatedAt,
+            storageKey:  u.StorageKey,
+        })
+    }
+
+    export.Identities = []ExportedIdentity{}
+    if err := config.DB.Model(&models.UserIdentity{}).Select("provider", "subject", "email", "created_at").
+        Where("user_id = ?", userID).Order("created_at").Scan(&export.Identities).Error; err != nil {
+        return nil, err
+    }
+
+    return export, nil
+}
+
+// WriteExportZip ghi archive ZIP gồm mỗi loại dữ liệu một file JSON và các file user đã tải lên
+func (s *AccountService) WriteExportZip(w io.Writer, export *AccountExport) error {
+    zw := zip.NewWriter(w)
+
+    // File tải lên được đưa vào thư mục files/ trước để uploads.json ghi được đường dẫn trong archive
+    uploads := make([]ExportedUpload, len(export.Uploads))
+    copy(uploads, export.Uploads)
+    for i := range uploads {
+        archivePath := "files/" + uploads[i].ID.String() + "_" + path.Base(uploads[i].FileName)
+        if err := s.copyFileToZip(zw, archivePath, uploads[i].storageKey); err != nil {
+            if errors.Is(err, storage.ErrNotFound) {
+                log.Printf("Export: upload %s missing from storage", uploads[i].ID)
+                continue
+            }
+            return err
+        }
+        uploads[i].ArchivePath = archivePath
+    }
+
+    files := []struct {
+        name string
+        data interface{}
+    }{
+        {"profile.json", export.Profile},
+        {"questions.json", export.Questions},
+        {"answers.json", export.Answers},
+        {"votes.json", export.Votes},
+        {"followers.json", export.Followers},
+        {"following.json", export.Following},
+        {"followed_tags.json", export.FollowedTags},
+        {"watched_questions.json", export.Watches},
+        {"bookmarks.json", export.Bookmarks},
+        {"bookmark_collections.json", export.Collections},
+        {"badges.json", export.Badges},
+        {"bounties.json", export.Bounties},
+        {"point_transactions.json", export.PointHistory},
+        {"notifications.json", export.Notifications},
+        {"uploads.json", uploads},
+        {"linked_accounts.json", export.Identities},
+    }
+    for _, file := range files {
+        if err := writeZipJSON(zw, file.name, export.ExportedAt, file.data); err != nil {
+            return err
+        }
+    }
+
+    return zw.Close()
+}
+
+func (s *AccountService) copyFileToZip(zw *zip.Writer, name, storageKey string) error {
+    r, err := s.storage.Open(storageKey)
+    if err != nil {
+        return err
+    }
+    defer r.Close()
+
+    fw, err := zw.Create(name)
+    if err != nil {
+        return err
+    }
+    _, err = io.Copy(fw, r)
+    return err
+}
+
+func writeZipJSON(zw *zip.Writer, name string, modified time.Time, data interface{}) error {
+    fw, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+    if err != nil {
+        return err
+    }
+    encoder := json.NewEncoder(fw)
+    encoder.SetIndent("", "  ")
+    return encoder.Encode(data)
+}
+
+// DeleteAccount xóa tài khoản sau khi xác nhận mật khẩu (hoặc reauth token/mã 2FA nếu không có mật khẩu).
+// Câu hỏi, câu trả lời và file đính kèm được chuyển sang tài khoản giữ chỗ DeletedUserID thay vì
+// xóa theo (cascade), các dữ liệu cá nhân còn lại (vote, follow, bookmark, notification, cài đặt, token, avatar) bị xóa.
+// Vote bị xóa chứ không chuyển vì idx_vote_user_answer chỉ cho tài khoản giữ chỗ một vote trên mỗi câu trả lời.
+func (s *AccountService) DeleteAccount(userID uuid.UUID, req DeleteAccountRequest) error {
+    if userID == DeletedUserID {
+        return ErrCannotDeleteAccount
+    }
+    if _, err := NewUserService().confirmIdentity(userID, req.Password, req.Code, req.ReauthToken); err != nil {
+        return err
+    }
+
+    var avatars []models.Upload
+    err := config.DB.Transaction(func(tx *gorm.DB) error {
+        if err := ensureDeletedUser(tx); err != nil {
+            return err
+        }
+
+        // Nội dung công khai: giữ lại, chuyển tác giả sang tài khoản giữ chỗ
+        reassign := []struct {
+            model  interface{}
+            column string
+        }{
+            {&models.Question{}, "user_id"},
+            {&models.Answer{}, "user_id"},
+            {&models.Answer{}, "verified_by"},
+            {&models.Bounty{}, "user_id"},
+            {&models.Bounty{}, "awarded_to"},
+        }
+        for _, r := range reassign {
+            if err := tx.Model(r.model).Where(r.column+" = ?", userID).
+                UpdateColumn(r.column, DeletedUserID).Error; err != nil {
+                return err
+            }
+        }
+        if err := tx.Model(&models.Upload{}).Where("user_id = ? AND kind = ?", userID, models.UploadKindAttachment).
+            UpdateColumn("user_id", DeletedUserID).Error; err != nil {
+            return err
+        }
+
+        // Dữ liệu cá nhân: xóa hẳn
+        if err := tx.Model(&models.Question{}).
+            Where("id IN (SELECT question_id FROM bookmarks WHERE user_id = ?) AND bookmark_count > 0", userID).
+            UpdateColumn("bookmark_count", gorm.Expr("bookmark_count - 1")).Error; err != nil {
+            return err
+        }
+        if err := tx.Where("user_id = ? AND kind = ?", userID, models.UploadKindAvatar).Find(&avatars).Error; err != nil {
+            return err
+        }
+        deletes := []struct {
+            model     interface{}
+            condition string
+            args      []interface{}
+        }{
+            {&models.Upload{}, "user_id = ?", []interface{}{userID}},
+            {&models.Vote{}, "user_id = ?", []interface{}{userID}},
+            {&models.Follow{}, "follower_id = ? OR following_id = ?", []interface{}{userID, userID}},
+            {&models.TagFollow{}, "user_id = ?", []interface{}{userID}},
+            {&models.QuestionWatch{}, "user_id = ?", []interface{}{userID}},
+            {&models.Bookmark{}, "user_id = ?", []interface{}{userID}},
+            {&models.BookmarkCollection{}, "user_id = ?", []interface{}{userID}},
+            {&models.UserBadge{}, "user_id = ?", []interface{}{userID}},
+            {&models.PointTransaction{}, "user_id = ?", []interface{}{userID}},
+            {&models.Notification{}, "user_id = ?", []interface{}{userID}},
+            {&models.NotificationPreference{}, "user_id = ?", []interface{}{userID}},
+            {&models.NotificationMute{}, "user_id = ? OR (target_type = ? AND target_id = ?)", []interface{}{userID, models.MuteTargetUser, userID}},
+            {&models.EmailDigestSetting{}, "user_id = ?", []interface{}{userID}},
+            {&models.UserToken{}, "user_id = ?", []interface{}{userID}},
+            {&models.UserIdentity{}, "user_id = ?", []interface{}{userID}},
+            {&models.RecoveryCode{}, "user_id = ?", []interface{}{userID}},
+            {&models.TwoFactor{}, "user_id = ?", []interface{}{userID}},
+            {&models.PersonalAccessToken{}, "user_id = ?", []interface{}{userID}},
+        }
+        for _, d := range deletes {
+            if err := tx.Where(d.condition, d.args...).Delete(d.model).Error; err != nil {
+                return err
+            }
+        }
+
+        return tx.Delete(&models.User{}, "id = ?", userID).Error
+    })
+    if err != nil {
+        return err
+    }
+
+    s.removeOrphanedFiles(avatars)
+    log.Printf("Account %s deleted, content reassigned to placeholder user", userID)
+    return nil
+}
+
+// removeOrphanedFiles xóa file avatar khỏi storage nếu không còn upload nào khác dùng chung nội dung
+func (s *AccountService) removeOrphanedFiles(uploads []models.Upload) {
+    for _, upload := range uploads {
+        for _, key := range []string{upload.StorageKey, upload.ThumbnailKey} {
+            if key == "" {
+                continue
+            }
+            var count int64
+            if err := config.DB.Model(&models.Upload{}).
+                Where("storage_key = ? OR thumbnail_key = ?", key, key).
+                Count(&count).Error; err != nil || count > 0 {
+                continue
+            }
+            if err := s.storage.Delete(key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+                log.Printf("Error deleting file %s: %v", key, err)
+            }
+        }
+    }
+}
+
+// ensureDeletedUser tạo tài khoản giữ chỗ nếu chưa có; mật khẩu rỗng nên không thể đăng nhập
+func ensureDeletedUser(tx *gorm.DB) error {
+    now := time.Now()
+    placeholder := models.User{
+        ID:          DeletedUserID,
+        Email:       deletedEmail,
+        Username:    deletedUsername,
+        DisplayName: deletedDisplayName,
+        CreatedAt:   now,
+        UpdatedAt:   now,
+    }
+    if err := tx.Where("id = ?", DeletedUserID).FirstOrCreate(&placeholder).Error; err != nil {
+        return fmt.Errorf("create placeholder user: %w", err)
+    }
+    return nil
+}
+
+func uploadIDs(uploads []models.Upload) []uuid.UUID {
+    ids := make([]uuid.UUID, 0, len(uploads))
+    for _, upload := range uploads {
+        ids = append(ids, upload.ID)
+    }
+    return ids
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+    "testing"
+    "time"
+
+    "golang.org/x/crypto/bcrypt"
+    "vietick/config"
+    "vietick/internal/models"
+)
+
+const testPassword = "correct horse battery"
+
+// createTestUserWithPassword tạo user có mật khẩu thật để qua được bước xác nhận danh tính
+func createTestUserWithPassword(t *testing.T, email string) models.User {
+    t.Helper()
+    user := createTestUser(t, email, true)
+    hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+    if err != nil {
+        t.Fatalf("hash password: %v", err)
+    }
+    if err := config.DB.Model(&user).UpdateColumn("password", string(hash)).Error; err != nil {
+        t.Fatalf("set password: %v", err)
+    }
+    return user
+}
+
+func TestDeleteAccountsThatVotedOnSameAnswer(t *testing.T) {
+    useTestDB(t)
+    t.Setenv("UPLOAD_DIR", t.TempDir())
+
+    author := createTestUser(t, "author@example.com", true)
+    now := time.Now()
+    question := models.Question{Title: "Câu hỏi", Content: "Nội dung", UserID: author.ID, CreatedAt: now, UpdatedAt: now}
+    if err := config.DB.Create(&question).Error; err != nil {
+        t.Fatalf("create question: %v", err)
+    }
+    answer := models.Answer{Content: "Trả lời", QuestionID: question.ID, UserID: author.ID, CreatedAt: now, UpdatedAt: now}
+    if err := config.DB.Create(&answer).Error; err != nil {
+        t.Fatalf("create answer: %v", err)
+    }
+
+    svc := NewAccountService()
+    for _, email := range []string{"first@example.com", "second@example.com"} {
+        voter := createTestUserWithPassword(t, email)
+        vote := models.Vote{UserID: voter.ID, AnswerID: answer.ID, Type: models.UpVote, CreatedAt: now, UpdatedAt: now}
+        if err := config.DB.Create(&vote).Error; err != nil {
+            t.Fatalf("create vote: %v", err)
+        }
+        if err := svc.DeleteAccount(voter.ID, DeleteAccountRequest{Password: testPassword}); err != nil {
+            t.Fatalf("delete %s: %v", email, err)
+        }
+
+        var users int64
+        config.DB.Model(&models.User{}).Where("id = ?", voter.ID).Count(&users)
+        if users != 0 {
+            t.Fatalf("expected %s to be deleted", email)
+        }
+    }
+
+    var votes int64
+    if err := config.DB.Model(&models.Vote{}).Where("answer_id = ?", answer.ID).Count(&votes).Error; err != nil {
+        t.Fatalf("count votes: %v", err)
+    }
+    if votes != 0 {
+        t.Fatalf("expected deleted accounts' votes to be removed, got %d", votes)
+    }
+    var answers int64
+    config.DB.Model(&models.Answer{}).Where("id = ? AND user_id = ?", answer.ID, author.ID).Count(&answers)
+    if answers != 1 {
+        t.Fatal("expected the voted answer to be kept")
+    }
+}
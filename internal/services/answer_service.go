@@ -11,12 +11,15 @@ import (
 	// "vietick/internal/services"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type AnswerService struct{}
 
 type CreateAnswerRequest struct {
-	Content string `json:"content" binding:"required,min=10"`
+	Content       string   `json:"content" binding:"required,min=10"`
+	AttachmentIDs []string `json:"attachment_ids"` // ID các file đã tải lên qua POST /uploads
 }
 
 func NewAnswerService() *AnswerService {
@@ -24,76 +27,103 @@ func NewAnswerService() *AnswerService {
 }
 
 func (s *AnswerService) CreateAnswer(userID, questionID uuid.UUID, req CreateAnswerRequest) (*models.Answer, error) {
-	log.Printf("Creating answer for user ID: %s, question ID: %s", userID, questionID)
-
 	// Check if question exists
 	var question models.Question
 	if err := config.DB.First(&question, "id = ?", questionID).Error; err != nil {
-		log.Printf("Question not found: %v", err)
 		return nil, errors.New("question not found")
 	}
-	log.Printf("Question found: %s", question.ID)
 
 	// Check if user exists
 	var user models.User
 	if err := config.DB.First(&user, "id = ?", userID).Error; err != nil {
-		log.Printf("User not found: %v", err)
-		// Log all users in database for debugging
-		var users []models.User
-		if err := config.DB.Find(&users).Error; err != nil {
-			log.Printf("Error fetching users: %v", err)
-		} else {
-			log.Printf("Available users in database:")
-			for _, u := range users {
-				log.Printf("User ID: %s, Email: %s, Username: %s", u.ID, u.Email, u.Username)
-			}
-		}
 		return nil, errors.New("user not found")
 	}
-	log.Printf("User found: %s", user.ID)
 
 	now := time.Now()
+	rendered := renderContent(req.Content)
 	answer := models.Answer{
-		Content:    req.Content,
-		UserID:     userID,
-		QuestionID: questionID,
-		CreatedAt:  now,
-		UpdatedAt:  now,
+		Content:     req.Content,
+		ContentHTML: rendered.HTML,
+		ContentText: rendered.Text,
+		UserID:      userID,
+		QuestionID:  questionID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
 	}
 
-	if err := config.DB.Create(&answer).Error; err != nil {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// Khóa chia sẻ trên câu hỏi: hủy bounty/xóa câu hỏi (countAnswersLocked) chờ câu trả lời này ghi xong
+		if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).Select("id").
+			First(&models.Question{}, "id = ?", questionID).Error; err != nil {
+			return err
+		}
+		attachments, err := resolveAttachments(tx, userID, req.AttachmentIDs)
+		if err != nil {
+			return err
+		}
+		answer.Attachments = attachments
+		return tx.Create(&answer).Error
+	})
+	if err != nil {
 		log.Printf("Error creating answer: %v", err)
 		return nil, err
 	}
 
-	log.Printf("Answer created successfully with ID: %s", answer.ID)
+	// Thông báo cho tác giả câu hỏi và người theo dõi câu hỏi (không gồm người trả lời)
+	notificationService := NewNotificationService()
+	notificationData := map[string]interface{}{
+		"question_id": questionID,
+		"answer_id":   answer.ID,
+		"author_id":   userID,
+		"author_name": user.Username,
+		"preview":     contentPreview(answer.ContentText),
+	}
+	notificationService.SendNotificationToUsers(
+		[]uuid.UUID{question.UserID},
+		[]uuid.UUID{userID},
+		models.NotificationTypeAnswer,
+		"Câu hỏi của bạn có câu trả lời mới",
+		user.Username+" đã trả lời câu hỏi của bạn: "+question.Title,
+		notificationData,
+	)
+	notificationService.SendNotificationToWatchers(
+		questionID,
+		[]uuid.UUID{userID, question.UserID},
+		models.NotificationTypeAnswer,
+		"Câu hỏi bạn theo dõi có câu trả lời mới",
+		user.Username+" đã trả lời câu hỏi: "+question.Title,
+		notificationData,
+	)
+
+	// Người trả lời tự động theo dõi câu hỏi
+	if err := ensureWatch(userID, questionID); err != nil {
+		log.Printf("Failed to auto-watch question %s: %v", questionID, err)
+	}
+
+	PublishBadgeEvent(BadgeEventAnswerCreated, userID, answer.ID)
 
 	return &answer, nil
 }
 
-func (s *AnswerService) GetAnswers(questionID uuid.UUID, page, limit int) ([]models.Answer, int64, error) {
+func (s *AnswerService) GetAnswers(questionID uuid.UUID, p Pagination) ([]models.Answer, int64, string, error) {
 	var answers []models.Answer
-	var total int64
 
 	// Get total count
-	if err := config.DB.Model(&models.Answer{}).
-		Where("question_id = ?", questionID).
-		Count(&total).Error; err != nil {
-		return nil, 0, err
+	total, err := p.Count(config.DB.Model(&models.Answer{}).Where("question_id = ?", questionID))
+	if err != nil {
+		return nil, 0, "", err
 	}
 
 	// Get answers with pagination
-	offset := (page - 1) * limit
-	if err := config.DB.Preload("User").
-		Where("question_id = ?", questionID).
-		Order("created_at DESC").
-		Offset(offset).
-		Limit(limit).
+	if err := p.Apply(config.DB.Preload("User", publicUserColumns).Preload("Attachments").Where("question_id = ?", questionID), "created_at", "id").
 		Find(&answers).Error; err != nil {
-		return nil, 0, err
+		return nil, 0, "", err
 	}
 
-	return answers, total, nil
+	answers, nextCursor := trimPage(answers, p.Limit, func(a models.Answer) (time.Time, uuid.UUID) {
+		return a.CreatedAt, a.ID
+	})
+	return answers, total, nextCursor, nil
 }
 
 func (s *AnswerService) VerifyAnswer(answerID, verifierID uuid.UUID) error {
@@ -102,6 +132,17 @@ func (s *AnswerService) VerifyAnswer(answerID, verifierID uuid.UUID) error {
 		return errors.New("answer not found")
 	}
 
+	// Tác giả câu hỏi luôn được xác minh câu trả lời cho câu hỏi của mình; người khác cần đủ reputation
+	var question models.Question
+	if err := config.DB.Select("id", "user_id").First(&question, "id = ?", answer.QuestionID).Error; err != nil {
+		return err
+	}
+	if question.UserID != verifierID {
+		if err := requirePrivilege(verifierID, PrivilegeVerifyAnswer); err != nil {
+			return err
+		}
+	}
+
 	// Nếu câu trả lời đã được xác minh, bỏ xác minh
 	if answer.IsVerified {
 		answer.IsVerified = false
@@ -112,9 +153,70 @@ func (s *AnswerService) VerifyAnswer(answerID, verifierID uuid.UUID) error {
 		answer.VerifiedBy = &verifierID
 	}
 
-	if err := config.DB.Save(&answer).Error; err != nil {
+	changed, err := setAnswerVerified(answer, answer.IsVerified, answer.VerifiedBy)
+	if err != nil {
 		return err
 	}
 
+	if changed && answer.IsVerified {
+		notifyAnswerVerified(answer, verifierID, false)
+		PublishBadgeEvent(BadgeEventAnswerVerified, answer.UserID, answer.ID)
+	}
+
 	return nil
 }
+
+// notifyAnswerVerified thông báo cho tác giả câu trả lời, tác giả câu hỏi, người theo dõi và người đã bookmark câu hỏi
+// khi câu trả lời được xác minh (thủ công hoặc tự động), không gồm người thực hiện hành động
+func notifyAnswerVerified(answer models.Answer, actorID uuid.UUID, automatic bool) {
+	var question models.Question
+	if err := config.DB.First(&question, "id = ?", answer.QuestionID).Error; err != nil {
+		log.Printf("Error loading question for verify notification: %v", err)
+		return
+	}
+
+	notificationService := NewNotificationService()
+	data := map[string]interface{}{
+		"question_id": answer.QuestionID,
+		"answer_id":   answer.ID,
+		"verifier_id": actorID,
+		"automatic":   automatic,
+	}
+
+	authorMessage := "Câu trả lời của bạn cho câu hỏi \"" + question.Title + "\" đã được xác minh."
+	if automatic {
+		authorMessage = "Câu trả lời của bạn cho câu hỏi \"" + question.Title + "\" đã được xác minh tự động nhờ đủ số upvote."
+	}
+	notificationService.SendNotificationToUsers(
+		[]uuid.UUID{answer.UserID},
+		[]uuid.UUID{actorID},
+		models.NotificationTypeVerify,
+		"Câu trả lời của bạn đã được xác minh",
+		authorMessage,
+		data,
+	)
+	notificationService.SendNotificationToUsers(
+		[]uuid.UUID{question.UserID},
+		[]uuid.UUID{actorID, answer.UserID},
+		models.NotificationTypeVerify,
+		"Câu hỏi của bạn có câu trả lời được xác minh",
+		"Một câu trả lời cho câu hỏi \""+question.Title+"\" vừa được xác minh.",
+		data,
+	)
+	notificationService.SendNotificationToWatchers(
+		answer.QuestionID,
+		[]uuid.UUID{actorID, answer.UserID, question.UserID},
+		models.NotificationTypeVerify,
+		"Câu hỏi bạn theo dõi có câu trả lời được xác minh",
+		"Một câu trả lời cho câu hỏi \""+question.Title+"\" vừa được xác minh.",
+		data,
+	)
+	notificationService.SendNotificationToBookmarkers(
+		answer.QuestionID,
+		[]uuid.UUID{actorID, answer.UserID, question.UserID},
+		models.NotificationTypeVerify,
+		"Câu hỏi bạn đã lưu có câu trả lời được xác minh",
+		"Một câu trả lời cho câu hỏi \""+question.Title+"\" bạn đã lưu vừa được xác minh.",
+		data,
+	)
+}
@@ -0,0 +1,428 @@
+package services
+
+import (
+    "bytes"
+    "crypto/aes"
+    "crypto/cipher"
+    "crypto/hmac"
+    "crypto/rand"
+    "crypto/sha256"
+    "embed"
+    "encoding/base64"
+    "encoding/hex"
+    "errors"
+    htmltemplate "html/template"
+    "log"
+    "net/url"
+    "os"
+    texttemplate "text/template"
+    "time"
+
+    "github.com/google/uuid"
+    "golang.org/x/crypto/bcrypt"
+    "gorm.io/gorm"
+    "vietick/config"
+    "vietick/internal/models"
+    "vietick/pkg/mailer"
+)
+
+const (
+    emailVerificationTTL = 24 * time.Hour
+    passwordResetTTL     = time.Hour
+    userTokenCooldown    = time.Minute // Không gửi lại email cùng loại trong khoảng này
+)
+
+var (
+    ErrInvalidUserToken     = errors.New("invalid or expired token")
+    ErrEmailAlreadyVerified = errors.New("email already verified")
+    ErrEmailCooldown        = errors.New("please wait a minute before requesting another email")
+    ErrTokenSecretMissing   = errors.New("EMAIL_TOKEN_SECRET or JWT_SECRET must be set")
+)
+
+//go:embed templates/action.html templates/action.txt
+var actionTemplateFS embed.FS
+
+var (
+    actionHTMLTemplate = htmltemplate.Must(htmltemplate.ParseFS(actionTemplateFS, "templates/action.html"))
+    actionTextTemplate = texttemplate.Must(texttemplate.ParseFS(actionTemplateFS, "templates/action.txt"))
+)
+
+type AuthService struct {
+    mailer mailer.Mailer
+}
+
+type VerifyEmailRequest struct {
+    Token string `json:"token" binding:"required"`
+}
+
+type ForgotPasswordRequest struct {
+    Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+    Token       string `json:"token" binding:"required"`
+    NewPassword string `json:"new_password" binding:"required,min=8"`
+}
+
+type actionEmailView struct {
+    Subject     string
+    Username    string
+    Intro       string
+    ActionURL   string
+    ActionLabel string
+    Note        string
+}
+
+func NewAuthService() *AuthService {
+    return &AuthService{
+        mailer: mailer.NewFromEnv(),
+    }
+}
+
+// SendVerificationEmail tạo token xác minh mới (vô hiệu token cũ) và gửi link xác minh cho user
+func (s *AuthService) SendVerificationEmail(userID uuid.UUID) error {
+    var user models.User
+    if err := config.DB.First(&user, "id = ?", userID).Error; err != nil {
+        return errors.New("user not found")
+    }
+    if user.EmailVerifiedAt != nil {
+        return ErrEmailAlreadyVerified
+    }
+
+    token, err := issueUserToken(user.ID, models.UserTokenEmailVerification, "", emailVerificationTTL)
+    if err != nil || token == "" {
+        return err
+    }
+
+    s.sendActionEmail(user, actionEmailView{
+        Subject:     "[VieTick] Xác minh địa chỉ email",
+        Intro:       "Cảm ơn bạn đã đăng ký VieTick. Vui lòng xác minh địa chỉ email để có thể đặt câu hỏi và trả lời.",
+        ActionURL:   appBaseURL() + "/verify-email?token=" + url.QueryEscape(token),
+        ActionLabel: "Xác minh email",
+        Note:        "Link có hiệu lực trong 24 giờ. Nếu bạn không đăng ký tài khoản, hãy bỏ qua email này.",
+    })
+    return nil
+}
+
+// SendEmailChangeVerification gửi link xác nhận tới email mới; email chỉ được đổi khi user mở link đó
+func (s *AuthService) SendEmailChangeVerification(user models.User, newEmail string) error {
+    token, err := issueUserToken(user.ID, models.UserTokenEmailChange, newEmail, emailVerificationTTL)
+    if err != nil {
+        return err
+    }
+    if token == "" {
+        return ErrEmailCooldown
+    }
+
+    recipient := user
+    recipient.Email = newEmail
+    s.sendActionEmail(recipient, actionEmailView{
+        Subject:     "[VieTick] Xác nhận đổi địa chỉ email",
+        Intro:       "Bạn vừa yêu cầu dùng địa chỉ email này cho tài khoản VieTick. Vui lòng xác nhận để hoàn tất.",
+        ActionURL:   appBaseURL() + "/verify-email?token=" + url.QueryEscape(token),
+        ActionLabel: "Xác nhận email mới",
+        Note:        "Link có hiệu lực trong 24 giờ. Nếu bạn không yêu cầu, hãy bỏ qua email này.",
+    })
+    return nil
+}
+
+// VerifyEmail xác minh email bằng token trong link đã gửi (xác minh khi đăng ký hoặc xác nhận email mới)
+func (s *AuthService) VerifyEmail(req VerifyEmailRequest) (*models.User, error) {
+    var user models.User
+    err := config.DB.Transaction(func(tx *gorm.DB) error {
+        token, err := consumeUserToken(tx, req.Token, models.UserTokenEmailVerification, models.UserTokenEmailChange)
+        if err != nil {
+            return err
+        }
+
+        now := time.Now()
+        if token.Purpose == models.UserTokenEmailChange {
+            var count int64
+            if err := tx.Model(&models.User{}).
+                Where("email = ? AND id <> ?", token.Email, token.UserID).
+                Count(&count).Error; err != nil {
+                return err
+            }
+            if count > 0 {
+                return errors.New("email already exists")
+            }
+            if err := tx.Model(&models.User{}).Where("id = ?", token.UserID).
+                Updates(map[string]interface{}{"email": token.Email, "email_verified_at": now, "updated_at": now}).Error; err != nil {
+                return err
+            }
+        } else if err := tx.Model(&models.User{}).
+            Where("id = ? AND email_verified_at IS NULL", token.UserID).
+            Updates(map[string]interface{}{"email_verified_at": now, "updated_at": now}).Error; err != nil {
+            return err
+        }
+        return tx.First(&user, "id = ?", token.UserID).Error
+    })
+    if err != nil {
+        return nil, err
+    }
+    return &user, nil
+}
+
+// ForgotPassword gửi link đặt lại mật khẩu. Không báo lỗi khi email không tồn tại
+// để không lộ thông tin tài khoản nào đã đăng ký.
+func (s *AuthService) ForgotPassword(req ForgotPasswordRequest) error {
+    var user models.User
+    if err := config.DB.Where("email = ?", req.Email).First(&user).Error; err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            return nil
+        }
+        return err
+    }
+
+    token, err := issueUserToken(user.ID, models.UserTokenPasswordReset, "", passwordResetTTL)
+    if err != nil || token == "" {
+        return err
+    }
+
+    s.sendActionEmail(user, actionEmailView{
+        Subject:     "[VieTick] Đặt lại mật khẩu",
+        Intro:       "Chúng tôi nhận được yêu cầu đặt lại mật khẩu cho tài khoản của bạn.",
+        ActionURL:   appBaseURL() + "/reset-password?token=" + url.QueryEscape(token),
+        ActionLabel: "Đặt lại mật khẩu",
+        Note:        "Link có hiệu lực trong 1 giờ và chỉ dùng được một lần. Nếu bạn không yêu cầu, hãy bỏ qua email này, mật khẩu của bạn không thay đổi.",
+    })
+    return nil
+}
+
+// ResetPassword đặt mật khẩu mới bằng token đặt lại mật khẩu.
+// Đặt lại thành công cũng xác minh email (user đã chứng minh sở hữu hộp thư).
+func (s *AuthService) ResetPassword(req ResetPasswordRequest) error {
+    hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+    if err != nil {
+        return err
+    }
+
+    return config.DB.Transaction(func(tx *gorm.DB) error {
+        token, err := consumeUserToken(tx, req.Token, models.UserTokenPasswordReset)
+        if err != nil {
+            return err
+        }
+
+        now := time.Now()
+        if err := tx.Model(&models.User{}).Where("id = ?", token.UserID).
+            Updates(map[string]interface{}{"password": string(hashedPassword), "updated_at": now}).Error; err != nil {
+            return err
+        }
+        if err := tx.Model(&models.User{}).
+            Where("id = ? AND email_verified_at IS NULL", token.UserID).
+            Update("email_verified_at", now).Error; err != nil {
+            return err
+        }
+
+        // Vô hiệu các link đặt lại mật khẩu khác còn hạn
+        return tx.Model(&models.UserToken{}).
+            Where("user_id = ? AND purpose = ? AND used_at IS NULL", token.UserID, models.UserTokenPasswordReset).
+            Update("used_at", now).Error
+    })
+}
+
+// IsEmailVerified kiểm tra user đã xác minh email chưa
+func (s *AuthService) IsEmailVerified(userID uuid.UUID) (bool, error) {
+    var count int64
+    if err := config.DB.Model(&models.User{}).
+        Where("id = ? AND email_verified_at IS NOT NULL", userID).
+        Count(&count).Error; err != nil {
+        return false, err
+    }
+    return count > 0, nil
+}
+
+// NeedsEmailVerificationBackfill cho biết bảng users có từ trước khi có cột email_verified_at,
+// tức các tài khoản hiện có chưa từng được yêu cầu xác minh email. Phải gọi trước AutoMigrate.
+func NeedsEmailVerificationBackfill() bool {
+    migrator := config.DB.Migrator()
+    return migrator.HasTable(&models.User{}) && !migrator.HasColumn(&models.User{}, "EmailVerifiedAt")
+}
+
+// BackfillEmailVerification coi các tài khoản tạo trước khi có xác minh email là đã xác minh
+// (email_verified_at = created_at), để họ không bị chặn đăng bài sau khi nâng cấp. Gọi sau AutoMigrate.
+func BackfillEmailVerification() error {
+    result := config.DB.Model(&models.User{}).Where("email_verified_at IS NULL").
+        UpdateColumn("email_verified_at", gorm.Expr("created_at"))
+    if result.Error != nil {
+        return result.Error
+    }
+    log.Printf("Marked %d existing accounts as email-verified", result.RowsAffected)
+    return nil
+}
+
+// sendActionEmail gửi email ở goroutine riêng để thời gian phản hồi không phụ thuộc mail server
+// (và không lộ qua timing việc email có tồn tại hay không)
+func (s *AuthService) sendActionEmail(user models.User, view actionEmailView) {
+    view.Username = user.Username
+
+    var htmlBody, textBody bytes.Buffer
+    if err := actionHTMLTemplate.Execute(&htmlBody, view); err != nil {
+        log.Printf("Error rendering email %q: %v", view.Subject, err)
+        return
+    }
+    if err := actionTextTemplate.Execute(&textBody, view); err != nil {
+        log.Printf("Error rendering email %q: %v", view.Subject, err)
+        return
+    }
+
+    msg := mailer.Message{
+        To:       user.Email,
+        Subject:  view.Subject,
+        TextBody: textBody.String(),
+        HTMLBody: htmlBody.String(),
+    }
+    go func() {
+        if err := s.mailer.Send(msg); err != nil {
+            log.Printf("Error sending email %q to user %s: %v", msg.Subject, user.ID, err)
+        }
+    }()
+}
+
+// issueUserToken tạo token ngẫu nhiên, lưu hash và vô hiệu các token cùng loại trước đó.
+// Trả về chuỗi rỗng nếu vừa gửi token cùng loại trong userTokenCooldown.
+func issueUserToken(userID uuid.UUID, purpose models.UserTokenPurpose, email string, ttl time.Duration) (string, error) {
+    now := time.Now()
+
+    var recent int64
+    if err := config.DB.Model(&models.UserToken{}).
+        Where("user_id = ? AND purpose = ? AND created_at > ?", userID, purpose, now.Add(-userTokenCooldown)).
+        Count(&recent).Error; err != nil {
+        return "", err
+    }
+    if recent > 0 {
+        return "", nil
+    }
+
+    raw := make([]byte, 32)
+    if _, err := rand.Read(raw); err != nil {
+        return "", err
+    }
+    token := base64.RawURLEncoding.EncodeToString(raw)
+
+    err := config.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Model(&models.UserToken{}).
+            Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+            Update("used_at", now).Error; err != nil {
+            return err
+        }
+        return tx.Create(&models.UserToken{
+            UserID:    userID,
+            Purpose:   purpose,
+            TokenHash: hashUserToken(token),
+            Email:     email,
+            ExpiresAt: now.Add(ttl),
+            CreatedAt: now,
+        }).Error
+    })
+    if err != nil {
+        return "", err
+    }
+    return token, nil
+}
+
+// consumeUserToken đánh dấu token đã dùng bằng một câu UPDATE có điều kiện,
+// nên hai request dùng cùng token đồng thời chỉ một request thành công
+func consumeUserToken(tx *gorm.DB, token string, purposes ...models.UserTokenPurpose) (*models.UserToken, error) {
+    hash := hashUserToken(token)
+    now := time.Now()
+
+    result := tx.Model(&models.UserToken{}).
+        Where("token_hash = ? AND purpose IN ? AND used_at IS NULL AND expires_at > ?", hash, purposes, now).
+        Update("used_at", now)
+    if result.Error != nil {
+        return nil, result.Error
+    }
+    if result.RowsAffected == 0 {
+        return nil, ErrInvalidUserToken
+    }
+
+    var userToken models.UserToken
+    if err := tx.Where("token_hash = ?", hash).First(&userToken).Error; err != nil {
+        return nil, err
+    }
+    return &userToken, nil
+}
+
+func hashUserToken(token string) string {
+    sum := sha256.Sum256([]byte(token))
+    return hex.EncodeToString(sum[:])
+}
+
+// tokenSecret là secret chung cho token trong email, challenge 2FA, state OAuth và khóa mã hóa dẫn xuất:
+// EMAIL_TOKEN_SECRET, nếu không có thì JWT_SECRET. Với RS256/EdDSA, JWT_SECRET không bắt buộc nên
+// có thể cả hai đều trống; khi đó trả lỗi thay vì dẫn xuất khóa từ chuỗi rỗng mà ai cũng tính được.
+func tokenSecret() ([]byte, error) {
+    secret := os.Getenv("EMAIL_TOKEN_SECRET")
+    if secret == "" {
+        secret = os.Getenv("JWT_SECRET")
+    }
+    if secret == "" {
+        return nil, ErrTokenSecretMissing
+    }
+    return []byte(secret), nil
+}
+
+// ValidateTokenSecret kiểm tra lúc khởi động rằng đã cấu hình secret chung
+func ValidateTokenSecret() error {
+    _, err := tokenSecret()
+    return err
+}
+
+// derivedKey tạo khóa HMAC riêng cho từng mục đích từ EMAIL_TOKEN_SECRET (hoặc JWT_SECRET)
+func derivedKey(label string) ([]byte, error) {
+    secret, err := tokenSecret()
+    if err != nil {
+        return nil, err
+    }
+    mac := hmac.New(sha256.New, secret)
+    mac.Write([]byte(label))
+    return mac.Sum(nil), nil
+}
+
+// encryptionKey dùng khóa cấu hình ở biến môi trường envVar nếu có, nếu không thì khóa dẫn xuất theo label
+func encryptionKey(envVar, label string) ([]byte, error) {
+    if configured := os.Getenv(envVar); configured != "" {
+        sum := sha256.Sum256([]byte(configured))
+        return sum[:], nil
+    }
+    return derivedKey(label)
+}
+
+// encryptSecret mã hóa dữ liệu nhạy cảm lưu trong DB bằng AES-256-GCM, lộ DB thôi không đủ để dùng
+func encryptSecret(key []byte, plain string) (string, error) {
+    gcm, err := newGCM(key)
+    if err != nil {
+        return "", err
+    }
+    nonce := make([]byte, gcm.NonceSize())
+    if _, err := rand.Read(nonce); err != nil {
+        return "", err
+    }
+    sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+    return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func decryptSecret(key []byte, encrypted string) (string, error) {
+    gcm, err := newGCM(key)
+    if err != nil {
+        return "", err
+    }
+    sealed, err := base64.StdEncoding.DecodeString(encrypted)
+    if err != nil || len(sealed) < gcm.NonceSize() {
+        return "", errors.New("invalid encrypted secret")
+    }
+    plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+    if err != nil {
+        return "", errors.New("invalid encrypted secret")
+    }
+    return string(plain), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+    block, err := aes.NewCipher(key)
+    if err != nil {
+        return nil, err
+    }
+    return cipher.NewGCM(block)
+}
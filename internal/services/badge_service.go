@@ -0,0 +1,397 @@
+package services
+
+import (
+    "encoding/json"
+    "errors"
+    "log"
+    "time"
+
+    "github.com/go-sql-driver/mysql"
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+    "vietick/config"
+    "vietick/internal/models"
+)
+
+const (
+    badgeEvaluateJob         = "badge.evaluate"
+    badgeEvaluateMaxAttempts = 5
+)
+
+// BadgeEventType là sự kiện domain có thể dẫn tới huy hiệu
+type BadgeEventType string
+
+const (
+    BadgeEventQuestionCreated BadgeEventType = "question.created"
+    BadgeEventAnswerCreated   BadgeEventType = "answer.created"
+    BadgeEventAnswerVerified  BadgeEventType = "answer.verified"
+    BadgeEventAnswerUpvoted   BadgeEventType = "answer.upvoted"
+    BadgeEventUserFollowed    BadgeEventType = "user.followed"
+)
+
+// BadgeEvent: UserID là người có thể nhận huy hiệu, SubjectID là câu hỏi/câu trả lời liên quan (uuid.Nil nếu không có)
+type BadgeEvent struct {
+    Type      BadgeEventType `json:"type"`
+    UserID    uuid.UUID      `json:"user_id"`
+    SubjectID uuid.UUID      `json:"subject_id"`
+}
+
+// Badge là định nghĩa một huy hiệu
+type Badge struct {
+    Key         string           `json:"key"`
+    Name        string           `json:"name"`
+    Description string           `json:"description"`
+    Tier        models.BadgeTier `json:"tier"`
+    PerTag      bool             `json:"per_tag"` // Nhận riêng cho từng tag
+}
+
+// badgeRule gắn huy hiệu với các sự kiện cần xét và điều kiện nhận.
+// Qualify trả về các scope user đủ điều kiện ("" với huy hiệu chung, tag ID với huy hiệu theo tag).
+type badgeRule struct {
+    Badge
+    On      []BadgeEventType
+    Qualify func(event BadgeEvent) ([]string, error)
+}
+
+// badgeRules là danh sách huy hiệu; thêm huy hiệu mới chỉ cần thêm rule vào đây
+var badgeRules = []badgeRule{
+    {
+        Badge: Badge{Key: "first_question", Name: "Người đặt câu hỏi", Description: "Đăng câu hỏi đầu tiên", Tier: models.BadgeTierBronze},
+        On:    []BadgeEventType{BadgeEventQuestionCreated},
+        Qualify: countAtLeast(1, func(event BadgeEvent) *gorm.DB {
+            return config.DB.Model(&models.Question{}).Where("user_id = ?", event.UserID)
+        }),
+    },
+    {
+        Badge: Badge{Key: "first_verified_answer", Name: "Câu trả lời đáng tin", Description: "Có câu trả lời đầu tiên được xác minh", Tier: models.BadgeTierBronze},
+        On:    []BadgeEventType{BadgeEventAnswerVerified},
+        Qualify: countAtLeast(1, func(event BadgeEvent) *gorm.DB {
+            return config.DB.Model(&models.Answer{}).Where("user_id = ? AND is_verified = ?", event.UserID, true)
+        }),
+    },
+    {
+        Badge: Badge{Key: "good_answer", Name: "Câu trả lời hay", Description: "Một câu trả lời nhận 10 upvote từ người khác", Tier: models.BadgeTierSilver},
+        On:    []BadgeEventType{BadgeEventAnswerUpvoted},
+        Qualify: countAtLeast(10, func(event BadgeEvent) *gorm.DB {
+            return config.DB.Model(&models.Vote{}).
+                Where("answer_id = ? AND type = ? AND user_id <> ?", event.SubjectID, models.UpVote, event.UserID)
+        }),
+    },
+    {
+        Badge:   Badge{Key: "tag_expert", Name: "Chuyên gia", Description: "Có 5 câu trả lời được xác minh trong cùng một tag", Tier: models.BadgeTierGold, PerTag: true},
+        On:      []BadgeEventType{BadgeEventAnswerVerified},
+        Qualify: verifiedAnswersInTag(5),
+    },
+    {
+        Badge:   Badge{Key: "streak_7", Name: "Chăm chỉ", Description: "Đặt câu hỏi hoặc trả lời 7 ngày liên tiếp", Tier: models.BadgeTierBronze},
+        On:      []BadgeEventType{BadgeEventQuestionCreated, BadgeEventAnswerCreated},
+        Qualify: activityStreak(7),
+    },
+    {
+        Badge:   Badge{Key: "streak_30", Name: "Bền bỉ", Description: "Đặt câu hỏi hoặc trả lời 30 ngày liên tiếp", Tier: models.BadgeTierGold},
+        On:      []BadgeEventType{BadgeEventQuestionCreated, BadgeEventAnswerCreated},
+        Qualify: activityStreak(30),
+    },
+    {
+        Badge: Badge{Key: "popular", Name: "Được quan tâm", Description: "Có 10 người follow", Tier: models.BadgeTierSilver},
+        On:    []BadgeEventType{BadgeEventUserFollowed},
+        Qualify: countAtLeast(10, func(event BadgeEvent) *gorm.DB {
+            return config.DB.Model(&models.Follow{}).Where("following_id = ?", event.UserID)
+        }),
+    },
+}
+
+type BadgeService struct{}
+
+// BadgeSummary là định nghĩa huy hiệu kèm số lần đã được trao
+type BadgeSummary struct {
+    Badge
+    AwardedCount int64 `json:"awarded_count"`
+}
+
+type BadgeTagRef struct {
+    ID   uuid.UUID `json:"id"`
+    Name string    `json:"name"`
+}
+
+type UserBadgeResponse struct {
+    Badge     Badge        `json:"badge"`
+    Tag       *BadgeTagRef `json:"tag,omitempty"`
+    SubjectID *uuid.UUID   `json:"subject_id"`
+    AwardedAt time.Time    `json:"awarded_at"`
+}
+
+func init() {
+    DefaultJobQueue().Register(badgeEvaluateJob, NewBadgeService().handleEvaluateJob)
+}
+
+func NewBadgeService() *BadgeService {
+    return &BadgeService{}
+}
+
+// PublishBadgeEvent đưa sự kiện vào hàng đợi để xét huy hiệu ở nền, không làm chậm request; lỗi chỉ ghi log
+func PublishBadgeEvent(eventType BadgeEventType, userID, subjectID uuid.UUID) {
+    event := BadgeEvent{Type: eventType, UserID: userID, SubjectID: subjectID}
+    if _, err := DefaultJobQueue().Enqueue(badgeEvaluateJob, event, badgeEvaluateMaxAttempts); err != nil {
+        log.Printf("Error enqueueing badge event %s for user %s: %v", eventType, userID, err)
+    }
+}
+
+func (s *BadgeService) handleEvaluateJob(job *models.Job) error {
+    var event BadgeEvent
+    if err := json.Unmarshal([]byte(job.Payload), &event); err != nil {
+        return err
+    }
+    return s.Evaluate(event)
+}
+
+// Evaluate xét các rule gắn với sự kiện và trao huy hiệu user vừa đạt. Trao lại huy hiệu đã có
+// không có tác dụng nên job retry an toàn.
+func (s *BadgeService) Evaluate(event BadgeEvent) error {
+    for _, rule := range badgeRules {
+        if !rule.handles(event.Type) {
+            continue
+        }
+        if !rule.PerTag {
+            awarded, err := hasBadge(event.UserID, rule.Key)
+            if err != nil {
+                return err
+            }
+            if awarded {
+                continue
+            }
+        }
+
+        scopes, err := rule.Qualify(event)
+        if err != nil {
+            return err
+        }
+        for _, scope := range scopes {
+            if err := s.award(rule.Badge, event, scope); err != nil {
+                return err
+            }
+        }
+    }
+    return nil
+}
+
+// GetBadges liệt kê mọi huy hiệu kèm số lần đã trao
+func (s *BadgeService) GetBadges() ([]BadgeSummary, error) {
+    var counts []struct {
+        BadgeKey string
+        Count    int64
+    }
+    if err := config.DB.Model(&models.UserBadge{}).
+        Select("badge_key, COUNT(*) AS count").
+        Group("badge_key").
+        Scan(&counts).Error; err != nil {
+        return nil, err
+    }
+    byKey := make(map[string]int64, len(counts))
+    for _, count := range counts {
+        byKey[count.BadgeKey] = count.Count
+    }
+
+    summaries := make([]BadgeSummary, 0, len(badgeRules))
+    for _, rule := range badgeRules {
+        summaries = append(summaries, BadgeSummary{Badge: rule.Badge, AwardedCount: byKey[rule.Key]})
+    }
+    return summaries, nil
+}
+
+// GetUserBadges liệt kê huy hiệu của user, mới nhận trước
+func (s *BadgeService) GetUserBadges(userID uuid.UUID) ([]UserBadgeResponse, error) {
+    if err := ensureUserExists(userID); err != nil {
+        return nil, err
+    }
+
+    var awards []models.UserBadge
+    if err := config.DB.Where("user_id = ?", userID).Order("awarded_at DESC").Find(&awards).Error; err != nil {
+        return nil, err
+    }
+
+    var tagIDs []string
+    for _, award := range awards {
+        if award.Scope != "" {
+            tagIDs = append(tagIDs, award.Scope)
+        }
+    }
+    tagNames := make(map[string]string, len(tagIDs))
+    if len(tagIDs) > 0 {
+        var tags []models.Tag
+        if err := config.DB.Select("id", "name").Where("id IN ?", tagIDs).Find(&tags).Error; err != nil {
+            return nil, err
+        }
+        for _, tag := range tags {
+            tagNames[tag.ID.String()] = tag.Name
+        }
+    }
+
+    responses := make([]UserBadgeResponse, 0, len(awards))
+    for _, award := range awards {
+        badge, ok := findBadge(award.BadgeKey)
+        if !ok {
+            continue // Huy hiệu đã bị gỡ khỏi badgeRules
+        }
+        response := UserBadgeResponse{Badge: badge, SubjectID: award.SubjectID, AwardedAt: award.AwardedAt}
+        if award.Scope != "" {
+            if tagID, err := uuid.Parse(award.Scope); err == nil {
+                response.Tag = &BadgeTagRef{ID: tagID, Name: tagNames[award.Scope]}
+            }
+        }
+        responses = append(responses, response)
+    }
+    return responses, nil
+}
+
+// award lưu huy hiệu và gửi notification; bỏ qua nếu user đã có huy hiệu này
+func (s *BadgeService) award(badge Badge, event BadgeEvent, scope string) error {
+    userBadge := models.UserBadge{
+        UserID:    event.UserID,
+        BadgeKey:  badge.Key,
+        Scope:     scope,
+        AwardedAt: time.Now(),
+    }
+    if event.SubjectID != uuid.Nil {
+        subjectID := event.SubjectID
+        userBadge.SubjectID = &subjectID
+    }
+    if err := config.DB.Create(&userBadge).Error; err != nil {
+        var mysqlErr *mysql.MySQLError
+        if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+            return nil
+        }
+        return err
+    }
+
+    name := badge.Name
+    data := map[string]interface{}{
+        "badge": badge.Key,
+        "tier":  badge.Tier,
+    }
+    if scope != "" {
+        var tag models.Tag
+        if err := config.DB.Select("id", "name").First(&tag, "id = ?", scope).Error; err == nil {
+            name = badge.Name + " (" + tag.Name + ")"
+            data["tag_id"] = tag.ID
+            data["tag_name"] = tag.Name
+        }
+    }
+
+    log.Printf("Badge %s%s awarded to user %s", badge.Key, scopeSuffix(scope), event.UserID)
+    if err := NewNotificationService().SendNotificationToUser(
+        event.UserID,
+        models.NotificationTypeBadge,
+        "Bạn vừa nhận huy hiệu mới",
+        "Chúc mừng! Bạn nhận được huy hiệu \""+name+"\": "+badge.Description+".",
+        data,
+    ); err != nil {
+        log.Printf("Error sending badge notification: %v", err)
+    }
+    return nil
+}
+
+func (r badgeRule) handles(eventType BadgeEventType) bool {
+    for _, on := range r.On {
+        if on == eventType {
+            return true
+        }
+    }
+    return false
+}
+
+func findBadge(key string) (Badge, bool) {
+    for _, rule := range badgeRules {
+        if rule.Key == key {
+            return rule.Badge, true
+        }
+    }
+    return Badge{}, false
+}
+
+func hasBadge(userID uuid.UUID, key string) (bool, error) {
+    var count int64
+    if err := config.DB.Model(&models.UserBadge{}).
+        Where("user_id = ? AND badge_key = ?", userID, key).
+        Count(&count).Error; err != nil {
+        return false, err
+    }
+    return count > 0, nil
+}
+
+func scopeSuffix(scope string) string {
+    if scope == "" {
+        return ""
+    }
+    return "[" + scope + "]"
+}
+
+// countAtLeast: đạt khi query đếm được ít nhất n bản ghi
+func countAtLeast(n int64, query func(event BadgeEvent) *gorm.DB) func(BadgeEvent) ([]string, error) {
+    return func(event BadgeEvent) ([]string, error) {
+        var count int64
+        if err := query(event).Count(&count).Error; err != nil {
+            return nil, err
+        }
+        if count < n {
+            return nil, nil
+        }
+        return []string{""}, nil
+    }
+}
+
+// verifiedAnswersInTag: đạt cho từng tag của câu hỏi mà user có ít nhất n câu trả lời được xác minh.
+// SubjectID của sự kiện là câu trả lời vừa được xác minh.
+func verifiedAnswersInTag(n int64) func(BadgeEvent) ([]string, error) {
+    return func(event BadgeEvent) ([]string, error) {
+        var tagIDs []string
+        err := config.DB.Table("answers").
+            Joins("JOIN question_tags ON question_tags.question_id = answers.question_id").
+            Where("answers.user_id = ? AND answers.is_verified = ?", event.UserID, true).
+            Where("question_tags.tag_id IN (SELECT qt.tag_id FROM question_tags qt JOIN answers a ON a.question_id = qt.question_id WHERE a.id = ?)", event.SubjectID).
+            Group("question_tags.tag_id").
+            Having("COUNT(*) >= ?", n).
+            Pluck("question_tags.tag_id", &tagIDs).Error
+        return tagIDs, err
+    }
+}
+
+// activityStreak: đạt khi user đặt câu hỏi hoặc trả lời trong days ngày liên tiếp, tính tới ngày hoạt động gần nhất
+func activityStreak(days int) func(BadgeEvent) ([]string, error) {
+    return func(event BadgeEvent) ([]string, error) {
+        since := time.Now().AddDate(0, 0, -days)
+        var activeDays []string
+        if err := config.DB.Raw(
+            "SELECT DATE_FORMAT(created_at, '%Y-%m-%d') AS day FROM questions WHERE user_id = ? AND created_at >= ? "+
+                "UNION SELECT DATE_FORMAT(created_at, '%Y-%m-%d') AS day FROM answers WHERE user_id = ? AND created_at >= ? "+
+                "ORDER BY day DESC",
+            event.UserID, since, event.UserID, since,
+        ).Scan(&activeDays).Error; err != nil {
+            return nil, err
+        }
+        if len(activeDays) < days {
+            return nil, nil
+        }
+
+        streak := 1
+        previous, err := time.Parse("2006-01-02", activeDays[0])
+        if err != nil {
+            return nil, err
+        }
+        for _, value := range activeDays[1:] {
+            day, err := time.Parse("2006-01-02", value)
+            if err != nil {
+                return nil, err
+            }
+            if !day.Equal(previous.AddDate(0, 0, -1)) {
+                break
+            }
+            streak++
+            previous = day
+        }
+        if streak < days {
+            return nil, nil
+        }
+        return []string{""}, nil
+    }
+}
@@ -0,0 +1,373 @@
+package services
+
+import (
+    "errors"
+    "strings"
+    "time"
+
+    "github.com/go-sql-driver/mysql"
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+    "vietick/config"
+    "vietick/internal/models"
+)
+
+const maxCollectionsPerUser = 100
+
+var (
+    ErrQuestionNotFound    = errors.New("question not found")
+    ErrBookmarkNotFound    = errors.New("bookmark not found")
+    ErrAlreadyBookmarked   = errors.New("question already bookmarked")
+    ErrCollectionNotFound  = errors.New("collection not found")
+    ErrCollectionNameTaken = errors.New("collection name already exists")
+    ErrTooManyCollections  = errors.New("too many collections, delete unused ones first")
+)
+
+type BookmarkService struct{}
+
+type BookmarkRequest struct {
+    CollectionID *uuid.UUID `json:"collection_id"` // Bỏ trống: không thuộc collection nào
+}
+
+type CreateCollectionRequest struct {
+    Name        string `json:"name" binding:"required,max=100"`
+    Description string `json:"description" binding:"max=500"`
+    IsPublic    bool   `json:"is_public"`
+}
+
+type UpdateCollectionRequest struct {
+    Name        *string `json:"name" binding:"omitempty,min=1,max=100"`
+    Description *string `json:"description" binding:"omitempty,max=500"`
+    IsPublic    *bool   `json:"is_public"`
+}
+
+type BookmarkResponse struct {
+    ID           uuid.UUID       `json:"id"`
+    CollectionID *uuid.UUID      `json:"collection_id"`
+    CreatedAt    time.Time       `json:"created_at"`
+    Question     models.Question `json:"question"`
+}
+
+type CollectionResponse struct {
+    ID            uuid.UUID `json:"id"`
+    UserID        uuid.UUID `json:"user_id"`
+    Name          string    `json:"name"`
+    Description   string    `json:"description"`
+    IsPublic      bool      `json:"is_public"`
+    BookmarkCount int64     `json:"bookmark_count"`
+    CreatedAt     time.Time `json:"created_at"`
+    UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func NewBookmarkService() *BookmarkService {
+    return &BookmarkService{}
+}
+
+// BookmarkQuestion lưu câu hỏi vào bookmark, hoặc chuyển bookmark sẵn có sang collection khác.
+// Trả về created = true nếu là bookmark mới.
+func (s *BookmarkService) BookmarkQuestion(userID, questionID uuid.UUID, req BookmarkRequest) (*models.Bookmark, bool, error) {
+    if err := config.DB.Select("id").First(&models.Question{}, "id = ?", questionID).Error; err != nil {
+        return nil, false, ErrQuestionNotFound
+    }
+    if req.CollectionID != nil {
+        if _, err := s.findOwnCollection(userID, *req.CollectionID); err != nil {
+            return nil, false, err
+        }
+    }
+
+    var bookmark models.Bookmark
+    created := false
+    err := config.DB.Transaction(func(tx *gorm.DB) error {
+        err := tx.Where("user_id = ? AND question_id = ?", userID, questionID).First(&bookmark).Error
+        if err == nil {
+            bookmark.CollectionID = req.CollectionID
+            return tx.Model(&bookmark).UpdateColumn("collection_id", req.CollectionID).Error
+        }
+        if !errors.Is(err, gorm.ErrRecordNotFound) {
+            return err
+        }
+
+        bookmark = models.Bookmark{
+            UserID:       userID,
+            QuestionID:   questionID,
+            CollectionID: req.CollectionID,
+            CreatedAt:    time.Now(),
+        }
+        if err := tx.Create(&bookmark).Error; err != nil {
+            return err
+        }
+        created = true
+        return tx.Model(&models.Question{}).Where("id = ?", questionID).
+            UpdateColumn("bookmark_count", gorm.Expr("bookmark_count + 1")).Error
+    })
+    if err != nil {
+        var mysqlErr *mysql.MySQLError
+        if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+            return nil, false, ErrAlreadyBookmarked
+        }
+        return nil, false, err
+    }
+
+    return &bookmark, created, nil
+}
+
+// RemoveBookmark bỏ bookmark câu hỏi
+func (s *BookmarkService) RemoveBookmark(userID, questionID uuid.UUID) error {
+    return config.DB.Transaction(func(tx *gorm.DB) error {
+        result := tx.Where("user_id = ? AND question_id = ?", userID, questionID).Delete(&models.Bookmark{})
+        if result.Error != nil {
+            return result.Error
+        }
+        if result.RowsAffected == 0 {
+            return ErrBookmarkNotFound
+        }
+        return tx.Model(&models.Question{}).Where("id = ? AND bookmark_count > 0", questionID).
+            UpdateColumn("bookmark_count", gorm.Expr("bookmark_count - 1")).Error
+    })
+}
+
+// GetBookmarks lấy bookmark của user, mới nhất trước; collectionID khác nil thì chỉ lấy trong collection đó
+func (s *BookmarkService) GetBookmarks(userID uuid.UUID, collectionID *uuid.UUID, p Pagination) ([]BookmarkResponse, int64, string, error) {
+    if collectionID != nil {
+        if _, err := s.findOwnCollection(userID, *collectionID); err != nil {
+            return nil, 0, "", err
+        }
+    }
+
+    filter := func(db *gorm.DB) *gorm.DB {
+        db = db.Where("user_id = ?", userID)
+        if collectionID != nil {
+            db = db.Where("collection_id = ?", *collectionID)
+        }
+        return db
+    }
+    return listBookmarks(filter, p)
+}
+
+// CreateCollection tạo collection mới
+func (s *BookmarkService) CreateCollection(userID uuid.UUID, req CreateCollectionRequest) (*CollectionResponse, error) {
+    var count int64
+    if err := config.DB.Model(&models.BookmarkCollection{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+        return nil, err
+    }
+    if count >= maxCollectionsPerUser {
+        return nil, ErrTooManyCollections
+    }
+
+    now := time.Now()
+    collection := models.BookmarkCollection{
+        UserID:      userID,
+        Name:        strings.TrimSpace(req.Name),
+        Description: strings.TrimSpace(req.Description),
+        IsPublic:    req.IsPublic,
+        CreatedAt:   now,
+        UpdatedAt:   now,
+    }
+    if err := config.DB.Create(&collection).Error; err != nil {
+        return nil, collectionWriteError(err)
+    }
+
+    response := toCollectionResponse(collection, 0)
+    return &response, nil
+}
+
+// GetCollections liệt kê collection của ownerID; người khác chỉ thấy collection công khai
+func (s *BookmarkService) GetCollections(ownerID, viewerID uuid.UUID) ([]CollectionResponse, error) {
+    if err := ensureUserExists(ownerID); err != nil {
+        return nil, err
+    }
+
+    query := config.DB.Where("user_id = ?", ownerID)
+    if ownerID != viewerID {
+        query = query.Where("is_public = ?", true)
+    }
+    var collections []models.BookmarkCollection
+    if err := query.Order("created_at DESC").Find(&collections).Error; err != nil {
+        return nil, err
+    }
+
+    counts, err := collectionBookmarkCounts(collections)
+    if err != nil {
+        return nil, err
+    }
+
+    responses := make([]CollectionResponse, 0, len(collections))
+    for _, collection := range collections {
+        responses = append(responses, toCollectionResponse(collection, counts[collection.ID]))
+    }
+    return responses, nil
+}
+
+// GetCollection lấy một collection; collection riêng tư chỉ chủ sở hữu xem được (viewerID uuid.Nil là khách)
+func (s *BookmarkService) GetCollection(collectionID, viewerID uuid.UUID) (*CollectionResponse, error) {
+    collection, err := findVisibleCollection(collectionID, viewerID)
+    if err != nil {
+        return nil, err
+    }
+
+    counts, err := collectionBookmarkCounts([]models.BookmarkCollection{*collection})
+    if err != nil {
+        return nil, err
+    }
+
+    response := toCollectionResponse(*collection, counts[collection.ID])
+    return &response, nil
+}
+
+// GetCollectionBookmarks lấy các câu hỏi trong collection, cùng quy tắc hiển thị với GetCollection
+func (s *BookmarkService) GetCollectionBookmarks(collectionID, viewerID uuid.UUID, p Pagination) ([]BookmarkResponse, int64, string, error) {
+    if _, err := findVisibleCollection(collectionID, viewerID); err != nil {
+        return nil, 0, "", err
+    }
+
+    return listBookmarks(func(db *gorm.DB) *gorm.DB {
+        return db.Where("collection_id = ?", collectionID)
+    }, p)
+}
+
+// UpdateCollection đổi tên, mô tả hoặc chế độ công khai của collection
+func (s *BookmarkService) UpdateCollection(userID, collectionID uuid.UUID, req UpdateCollectionRequest) (*CollectionResponse, error) {
+    collection, err := s.findOwnCollection(userID, collectionID)
+    if err != nil {
+        return nil, err
+    }
+
+    updates := map[string]interface{}{"updated_at": time.Now()}
+    if req.Name != nil {
+        collection.Name = strings.TrimSpace(*req.Name)
+        updates["name"] = collection.Name
+    }
+    if req.Description != nil {
+        collection.Description = strings.TrimSpace(*req.Description)
+        updates["description"] = collection.Description
+    }
+    if req.IsPublic != nil {
+        collection.IsPublic = *req.IsPublic
+        updates["is_public"] = collection.IsPublic
+    }
+    if err := config.DB.Model(collection).Updates(updates).Error; err != nil {
+        return nil, collectionWriteError(err)
+    }
+
+    return s.GetCollection(collection.ID, userID)
+}
+
+// DeleteCollection xóa collection; các bookmark bên trong được giữ lại, không thuộc collection nào
+func (s *BookmarkService) DeleteCollection(userID, collectionID uuid.UUID) error {
+    collection, err := s.findOwnCollection(userID, collectionID)
+    if err != nil {
+        return err
+    }
+
+    return config.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Model(&models.Bookmark{}).Where("collection_id = ?", collection.ID).
+            UpdateColumn("collection_id", nil).Error; err != nil {
+            return err
+        }
+        return tx.Delete(collection).Error
+    })
+}
+
+func (s *BookmarkService) findOwnCollection(userID, collectionID uuid.UUID) (*models.BookmarkCollection, error) {
+    var collection models.BookmarkCollection
+    if err := config.DB.Where("id = ? AND user_id = ?", collectionID, userID).First(&collection).Error; err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            return nil, ErrCollectionNotFound
+        }
+        return nil, err
+    }
+    return &collection, nil
+}
+
+// findVisibleCollection trả ErrCollectionNotFound cả khi collection riêng tư, để không lộ sự tồn tại của nó
+func findVisibleCollection(collectionID, viewerID uuid.UUID) (*models.BookmarkCollection, error) {
+    var collection models.BookmarkCollection
+    if err := config.DB.First(&collection, "id = ?", collectionID).Error; err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            return nil, ErrCollectionNotFound
+        }
+        return nil, err
+    }
+    if !collection.IsPublic && collection.UserID != viewerID {
+        return nil, ErrCollectionNotFound
+    }
+    return &collection, nil
+}
+
+func listBookmarks(filter func(db *gorm.DB) *gorm.DB, p Pagination) ([]BookmarkResponse, int64, string, error) {
+    total, err := p.Count(config.DB.Model(&models.Bookmark{}).Scopes(filter))
+    if err != nil {
+        return nil, 0, "", err
+    }
+
+    var bookmarks []models.Bookmark
+    if err := p.Apply(config.DB.Preload("Question.User", publicUserColumns).Preload("Question.Tags").Scopes(filter), "created_at", "id").
+        Find(&bookmarks).Error; err != nil {
+        return nil, 0, "", err
+    }
+
+    bookmarks, nextCursor := trimPage(bookmarks, p.Limit, func(b models.Bookmark) (time.Time, uuid.UUID) {
+        return b.CreatedAt, b.ID
+    })
+
+    responses := make([]BookmarkResponse, 0, len(bookmarks))
+    for _, bookmark := range bookmarks {
+        responses = append(responses, BookmarkResponse{
+            ID:           bookmark.ID,
+            CollectionID: bookmark.CollectionID,
+            CreatedAt:    bookmark.CreatedAt,
+            Question:     bookmark.Question,
+        })
+    }
+    return responses, total, nextCursor, nil
+}
+
+// collectionBookmarkCounts đếm bookmark của nhiều collection bằng một query
+func collectionBookmarkCounts(collections []models.BookmarkCollection) (map[uuid.UUID]int64, error) {
+    counts := make(map[uuid.UUID]int64, len(collections))
+    if len(collections) == 0 {
+        return counts, nil
+    }
+
+    ids := make([]uuid.UUID, 0, len(collections))
+    for _, collection := range collections {
+        ids = append(ids, collection.ID)
+    }
+
+    var rows []struct {
+        CollectionID uuid.UUID
+        Count        int64
+    }
+    if err := config.DB.Model(&models.Bookmark{}).
+        Select("collection_id, COUNT(*) AS count").
+        Where("collection_id IN ?", ids).
+        Group("collection_id").
+        Scan(&rows).Error; err != nil {
+        return nil, err
+    }
+    for _, row := range rows {
+        counts[row.CollectionID] = row.Count
+    }
+    return counts, nil
+}
+
+func collectionWriteError(err error) error {
+    var mysqlErr *mysql.MySQLError
+    if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+        return ErrCollectionNameTaken
+    }
+    return err
+}
+
+func toCollectionResponse(collection models.BookmarkCollection, bookmarkCount int64) CollectionResponse {
+    return CollectionResponse{
+        ID:            collection.ID,
+        UserID:        collection.UserID,
+        Name:          collection.Name,
+        Description:   collection.Description,
+        IsPublic:      collection.IsPublic,
+        BookmarkCount: bookmarkCount,
+        CreatedAt:     collection.CreatedAt,
+        UpdatedAt:     collection.UpdatedAt,
+    }
+}
@@ -0,0 +1,460 @@
+package services
+
+import (
+    "errors"
+    "log"
+    "strconv"
+    "time"
+
+    "github.com/go-sql-driver/mysql"
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+    "vietick/config"
+    "vietick/internal/models"
+)
+
+const (
+    bountyMinAmount      = 50
+    bountyMaxAmount      = 500
+    bountyDuration       = 7 * 24 * time.Hour
+    bountyExpiryInterval = time.Minute
+)
+
+var (
+    ErrInvalidBountyAmount    = errors.New("bounty amount must be between 50 and 500")
+    ErrInsufficientReputation = errors.New("not enough reputation to fund this bounty")
+    ErrBountyAlreadyOpen      = errors.New("question already has an open bounty")
+    ErrBountyNotFound         = errors.New("question has no open bounty")
+    ErrNotBountySponsor       = errors.New("only the user who started the bounty can do this")
+    ErrBountyHasAnswers       = errors.New("bounty cannot be cancelled once the question has answers")
+    ErrBountyQuestionAnswered = errors.New("question with an open bounty cannot be deleted once it has answers")
+    ErrBountyAnswerNotFound   = errors.New("answer not found on this question")
+    ErrCannotAwardOwnAnswer   = errors.New("cannot award a bounty to your own answer")
+)
+
+// BountyService quản lý bounty trên câu hỏi. Mọi thay đổi điểm (giữ điểm, trao, hoàn) chạy trong
+// cùng transaction với việc đổi trạng thái bounty và được ghi vào point_transactions.
+type BountyService struct {
+    stop chan struct{}
+    done chan struct{}
+}
+
+type StartBountyRequest struct {
+    Amount int64 `json:"amount" binding:"required"`
+}
+
+type AwardBountyRequest struct {
+    AnswerID uuid.UUID `json:"answer_id"`
+}
+
+// FeaturedQuestion là câu hỏi đang có bounty mở
+type FeaturedQuestion struct {
+    models.Question
+    Bounty models.Bounty `json:"bounty"`
+}
+
+func NewBountyService() *BountyService {
+    return &BountyService{}
+}
+
+// StartBounty đặt bounty cho câu hỏi và trừ amount điểm của người đặt cho tới khi bounty đóng
+func (s *BountyService) StartBounty(questionID, userID uuid.UUID, amount int64) (*models.Bounty, error) {
+    if amount < bountyMinAmount || amount > bountyMaxAmount {
+        return nil, ErrInvalidBountyAmount
+    }
+
+    var question models.Question
+    if err := config.DB.Select("id", "title", "user_id").First(&question, "id = ?", questionID).Error; err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            return nil, ErrQuestionNotFound
+        }
+        return nil, err
+    }
+
+    bounty := models.Bounty{
+        QuestionID:     questionID,
+        OpenQuestionID: &questionID,
+        UserID:         userID,
+        Amount:         amount,
+        Status:         models.BountyStatusOpen,
+        ExpiresAt:      time.Now().Add(bountyDuration),
+    }
+    err := config.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Create(&bounty).Error; err != nil {
+            var mysqlErr *mysql.MySQLError
+            if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+                return ErrBountyAlreadyOpen
+            }
+            return err
+        }
+        return changePoints(tx, models.PointTransaction{UserID: userID, Amount: -amount, Reason: models.PointReasonBountyEscrow, BountyID: &bounty.ID})
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    NewNotificationService().SendNotificationToUsers(
+        []uuid.UUID{question.UserID},
+        []uuid.UUID{userID, DeletedUserID},
+        models.NotificationTypeBounty,
+        "Câu hỏi của bạn được đặt bounty",
+        "Câu hỏi \""+question.Title+"\" vừa được đặt bounty "+strconv.FormatInt(amount, 10)+" điểm.",
+        map[string]interface{}{"question_id": questionID, "bounty_id": bounty.ID, "amount": amount},
+    )
+    return &bounty, nil
+}
+
+// GetOpenBounty trả về bounty đang mở của câu hỏi, nil nếu không có
+func (s *BountyService) GetOpenBounty(questionID uuid.UUID) (*models.Bounty, error) {
+    var bounties []models.Bounty
+    if err := config.DB.Where("open_question_id = ?", questionID).Limit(1).Find(&bounties).Error; err != nil {
+        return nil, err
+    }
+    if len(bounties) == 0 {
+        return nil, nil
+    }
+    return &bounties[0], nil
+}
+
+// CancelBounty hủy bounty và hoàn điểm cho người đặt; chỉ được hủy khi câu hỏi chưa có câu trả lời
+func (s *BountyService) CancelBounty(questionID, userID uuid.UUID) (*models.Bounty, error) {
+    bounty, err := s.sponsoredBounty(questionID, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := config.DB.Transaction(func(tx *gorm.DB) error {
+        answers, err := countAnswersLocked(tx, questionID)
+        if err != nil {
+            return err
+        }
+        if answers > 0 {
+            return ErrBountyHasAnswers
+        }
+        return closeBounty(tx, bounty, nil, false)
+    }); err != nil {
+        return nil, err
+    }
+    return bounty, nil
+}
+
+// AwardBounty trao bounty cho một câu trả lời của câu hỏi do người đặt bounty chọn
+func (s *BountyService) AwardBounty(questionID, userID, answerID uuid.UUID) (*models.Bounty, error) {
+    bounty, err := s.sponsoredBounty(questionID, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    var answer models.Answer
+    if err := config.DB.Select("id", "question_id", "user_id").
+        First(&answer, "id = ? AND question_id = ?", answerID, questionID).Error; err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            return nil, ErrBountyAnswerNotFound
+        }
+        return nil, err
+    }
+    if answer.UserID == userID {
+        return nil, ErrCannotAwardOwnAnswer
+    }
+
+    if err := config.DB.Transaction(func(tx *gorm.DB) error {
+        return closeBounty(tx, bounty, &answer, false)
+    }); err != nil {
+        return nil, err
+    }
+
+    notifyBountyClosed(bounty)
+    return bounty, nil
+}
+
+// GetFeaturedQuestions liệt kê câu hỏi đang có bounty mở, bounty lớn nhất rồi sắp hết hạn nhất trước
+func (s *BountyService) GetFeaturedQuestions(p Pagination) ([]FeaturedQuestion, int64, error) {
+    var total int64
+    if err := config.DB.Model(&models.Bounty{}).Where("status = ?", models.BountyStatusOpen).Count(&total).Error; err != nil {
+        return nil, 0, err
+    }
+
+    var bounties []models.Bounty
+    if err := config.DB.Where("status = ?", models.BountyStatusOpen).
+        Order("amount DESC").Order("expires_at ASC").
+        Offset(p.Offset()).Limit(p.Limit).
+        Find(&bounties).Error; err != nil {
+        return nil, 0, err
+    }
+    if len(bounties) == 0 {
+        return []FeaturedQuestion{}, total, nil
+    }
+
+    ids := make([]uuid.UUID, 0, len(bounties))
+    for _, bounty := range bounties {
+        ids = append(ids, bounty.QuestionID)
+    }
+    var questions []models.Question
+    if err := config.DB.Preload("User", publicUserColumns).Preload("Tags").Where("id IN ?", ids).Find(&questions).Error; err != nil {
+        return nil, 0, err
+    }
+    byID := make(map[uuid.UUID]models.Question, len(questions))
+    for _, question := range questions {
+        byID[question.ID] = question
+    }
+
+    featured := make([]FeaturedQuestion, 0, len(bounties))
+    for _, bounty := range bounties {
+        question, ok := byID[bounty.QuestionID]
+        if !ok {
+            continue // Bị xóa giữa hai query
+        }
+        featured = append(featured, FeaturedQuestion{Question: question, Bounty: bounty})
+    }
+    return featured, total, nil
+}
+
+// GetPointTransactions liệt kê lịch sử thay đổi điểm của user, mới nhất trước
+func (s *BountyService) GetPointTransactions(userID uuid.UUID, p Pagination) ([]models.PointTransaction, int64, string, error) {
+    total, err := p.Count(config.DB.Model(&models.PointTransaction{}).Where("user_id = ?", userID))
+    if err != nil {
+        return nil, 0, "", err
+    }
+
+    var transactions []models.PointTransaction
+    if err := p.Apply(config.DB.Where("user_id = ?", userID), "created_at", "id").
+        Find(&transactions).Error; err != nil {
+        return nil, 0, "", err
+    }
+
+    transactions, nextCursor := trimPage(transactions, p.Limit, func(t models.PointTransaction) (time.Time, uuid.UUID) {
+        return t.CreatedAt, t.ID
+    })
+    return transactions, total, nextCursor, nil
+}
+
+// StartExpiryScheduler chạy định kỳ để đóng các bounty đã hết hạn
+func (s *BountyService) StartExpiryScheduler() {
+    if s.stop != nil {
+        return
+    }
+    s.stop = make(chan struct{})
+    s.done = make(chan struct{})
+
+    go func() {
+        defer close(s.done)
+        ticker := time.NewTicker(bountyExpiryInterval)
+        defer ticker.Stop()
+
+        for {
+            if err := s.ExpireBounties(time.Now()); err != nil {
+                log.Printf("Error expiring bounties: %v", err)
+            }
+            select {
+            case <-s.stop:
+                return
+            case <-ticker.C:
+            }
+        }
+    }()
+
+    log.Println("Bounty expiry scheduler started")
+}
+
+// StopExpiryScheduler dừng scheduler
+func (s *BountyService) StopExpiryScheduler() {
+    if s.stop == nil {
+        return
+    }
+    close(s.stop)
+    <-s.done
+    s.stop = nil
+}
+
+// ExpireBounties đóng các bounty hết hạn: trao cho câu trả lời có điểm vote cao nhất (lớn hơn 0),
+// không có câu trả lời nào đủ điều kiện thì hoàn điểm cho người đặt. Mỗi bounty một transaction,
+// bounty đóng lỗi được giữ nguyên để lần chạy sau thử lại.
+func (s *BountyService) ExpireBounties(now time.Time) error {
+    var bounties []models.Bounty
+    if err := config.DB.Where("status = ? AND expires_at <= ?", models.BountyStatusOpen, now).
+        Order("expires_at").Find(&bounties).Error; err != nil {
+        return err
+    }
+
+    for i := range bounties {
+        bounty := &bounties[i]
+        err := config.DB.Transaction(func(tx *gorm.DB) error {
+            answer, err := topVotedAnswer(tx, bounty)
+            if err != nil {
+                return err
+            }
+            return closeBounty(tx, bounty, answer, answer != nil)
+        })
+        if errors.Is(err, ErrBountyNotFound) {
+            continue // Đã được trao hoặc hủy bởi request khác
+        }
+        if err != nil {
+            log.Printf("Error closing expired bounty %s: %v", bounty.ID, err)
+            continue // Thử lại ở lần chạy sau
+        }
+        notifyBountyClosed(bounty)
+    }
+    return nil
+}
+
+// refundOpenBounty hoàn điểm bounty đang mở của câu hỏi, dùng trong transaction xóa câu hỏi
+func refundOpenBounty(tx *gorm.DB, questionID uuid.UUID) error {
+    var bounties []models.Bounty
+    if err := tx.Where("open_question_id = ?", questionID).Find(&bounties).Error; err != nil {
+        return err
+    }
+    for i := range bounties {
+        if err := closeBounty(tx, &bounties[i], nil, false); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// countAnswersLocked khóa câu hỏi (FOR UPDATE) rồi đếm câu trả lời. CreateAnswer giữ khóa chia sẻ trên
+// câu hỏi khi thêm câu trả lời, nên số đếm không bỏ sót câu trả lời đang được ghi đồng thời và không
+// câu trả lời nào được thêm cho tới khi transaction này kết thúc.
+func countAnswersLocked(tx *gorm.DB, questionID uuid.UUID) (int64, error) {
+    var question models.Question
+    if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+        First(&question, "id = ?", questionID).Error; err != nil {
+        return 0, err
+    }
+
+    var answers int64
+    if err := tx.Model(&models.Answer{}).Where("question_id = ?", questionID).Count(&answers).Error; err != nil {
+        return 0, err
+    }
+    return answers, nil
+}
+
+// sponsoredBounty lấy bounty đang mở của câu hỏi và kiểm tra userID là người đặt
+func (s *BountyService) sponsoredBounty(questionID, userID uuid.UUID) (*models.Bounty, error) {
+    bounty, err := s.GetOpenBounty(questionID)
+    if err != nil {
+        return nil, err
+    }
+    if bounty == nil {
+        return nil, ErrBountyNotFound
+    }
+    if bounty.UserID != userID {
+        return nil, ErrNotBountySponsor
+    }
+    return bounty, nil
+}
+
+// closeBounty đóng bounty đang mở: trao cho answer nếu có, ngược lại hoàn điểm cho người đặt.
+// Cập nhật có điều kiện status = open nên bounty chỉ được đóng một lần dù nhiều request chạy cùng lúc.
+func closeBounty(tx *gorm.DB, bounty *models.Bounty, answer *models.Answer, automatic bool) error {
+    now := time.Now()
+    updates := map[string]interface{}{
+        "status":           models.BountyStatusRefunded,
+        "open_question_id": nil,
+        "auto_awarded":     automatic,
+        "closed_at":        now,
+    }
+    if answer != nil {
+        updates["status"] = models.BountyStatusAwarded
+        updates["awarded_answer_id"] = answer.ID
+        updates["awarded_to"] = answer.UserID
+    }
+
+    result := tx.Model(&models.Bounty{}).
+        Where("id = ? AND status = ?", bounty.ID, models.BountyStatusOpen).
+        Updates(updates)
+    if result.Error != nil {
+        return result.Error
+    }
+    if result.RowsAffected == 0 {
+        return ErrBountyNotFound
+    }
+
+    bounty.Status = models.BountyStatusRefunded
+    bounty.OpenQuestionID = nil
+    bounty.AutoAwarded = automatic
+    bounty.ClosedAt = &now
+    if answer == nil {
+        return changePoints(tx, models.PointTransaction{UserID: bounty.UserID, Amount: bounty.Amount, Reason: models.PointReasonBountyRefund, BountyID: &bounty.ID})
+    }
+    bounty.Status = models.BountyStatusAwarded
+    bounty.AwardedAnswerID = &answer.ID
+    bounty.AwardedTo = &answer.UserID
+    return changePoints(tx, models.PointTransaction{UserID: answer.UserID, Amount: bounty.Amount, Reason: models.PointReasonBountyAward, BountyID: &bounty.ID, AnswerID: &answer.ID})
+}
+
+// topVotedAnswer chọn câu trả lời có upvote trừ downvote cao nhất (phải lớn hơn 0), cũ hơn trước nếu bằng điểm.
+// Câu trả lời của người đặt bounty và của tài khoản giữ chỗ không được xét.
+func topVotedAnswer(tx *gorm.DB, bounty *models.Bounty) (*models.Answer, error) {
+    var rows []struct {
+        ID     uuid.UUID
+        UserID uuid.UUID
+        Score  int64
+    }
+    if err := tx.Table("answers").
+        Select("answers.id, answers.user_id, SUM(CASE votes.type WHEN ? THEN 1 WHEN ? THEN -1 ELSE 0 END) AS score",
+            models.UpVote, models.DownVote).
+        Joins("JOIN votes ON votes.answer_id = answers.id").
+        Where("answers.question_id = ? AND answers.user_id NOT IN ?", bounty.QuestionID, []uuid.UUID{bounty.UserID, DeletedUserID}).
+        Group("answers.id").
+        Having("score > 0").
+        Order("score DESC").Order("answers.created_at ASC").
+        Limit(1).
+        Scan(&rows).Error; err != nil {
+        return nil, err
+    }
+    if len(rows) == 0 {
+        return nil, nil
+    }
+    return &models.Answer{ID: rows[0].ID, QuestionID: bounty.QuestionID, UserID: rows[0].UserID}, nil
+}
+
+// notifyBountyClosed báo cho người nhận bounty và người đặt bounty khi bounty được trao hoặc hoàn điểm khi hết hạn
+func notifyBountyClosed(bounty *models.Bounty) {
+    var question models.Question
+    if err := config.DB.Select("id", "title").First(&question, "id = ?", bounty.QuestionID).Error; err != nil {
+        log.Printf("Error loading question for bounty notification: %v", err)
+        return
+    }
+
+    notificationService := NewNotificationService()
+    amount := strconv.FormatInt(bounty.Amount, 10)
+    data := map[string]interface{}{
+        "question_id": bounty.QuestionID,
+        "bounty_id":   bounty.ID,
+        "amount":      bounty.Amount,
+        "automatic":   bounty.AutoAwarded,
+    }
+
+    if bounty.AwardedTo == nil {
+        notificationService.SendNotificationToUsers(
+            []uuid.UUID{bounty.UserID},
+            []uuid.UUID{DeletedUserID},
+            models.NotificationTypeBounty,
+            "Bounty đã hết hạn",
+            "Bounty cho câu hỏi \""+question.Title+"\" hết hạn mà không có câu trả lời đủ điều kiện, "+amount+" điểm đã được hoàn lại.",
+            data,
+        )
+        return
+    }
+
+    data["answer_id"] = bounty.AwardedAnswerID
+    notificationService.SendNotificationToUsers(
+        []uuid.UUID{*bounty.AwardedTo},
+        []uuid.UUID{DeletedUserID},
+        models.NotificationTypeBounty,
+        "Bạn nhận được bounty",
+        "Câu trả lời của bạn cho câu hỏi \""+question.Title+"\" được trao bounty "+amount+" điểm.",
+        data,
+    )
+    if bounty.AutoAwarded {
+        notificationService.SendNotificationToUsers(
+            []uuid.UUID{bounty.UserID},
+            []uuid.UUID{DeletedUserID, *bounty.AwardedTo},
+            models.NotificationTypeBounty,
+            "Bounty đã được trao tự động",
+            "Bounty cho câu hỏi \""+question.Title+"\" hết hạn và được trao cho câu trả lời có nhiều vote nhất.",
+            data,
+        )
+    }
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+    "html"
+    "log"
+
+    "vietick/config"
+    "vietick/internal/models"
+    "vietick/pkg/markdown"
+)
+
+const (
+    contentPreviewLength = 140
+    contentBackfillBatch = 200
+)
+
+// renderContent render Markdown của câu hỏi/câu trả lời. Không trả lỗi: nếu render thất bại
+// thì hiển thị nguyên văn đã escape, để việc đăng bài không phụ thuộc vào renderer.
+func renderContent(source string) markdown.Rendered {
+    rendered, err := markdown.Render(source)
+    if err != nil {
+        log.Printf("Error rendering markdown: %v", err)
+        rendered = markdown.Rendered{HTML: "<p>" + html.EscapeString(source) + "</p>", Text: source}
+    }
+    if rendered.HTML == "" {
+        // Nội dung chỉ có HTML thô bị loại bỏ hết; vẫn đánh dấu là đã render
+        rendered.HTML = "<p></p>"
+    }
+    return rendered
+}
+
+// contentPreview trả về đoạn preview ngắn từ văn bản thuần, dùng trong notification
+func contentPreview(plain string) string {
+    return markdown.Preview(plain, contentPreviewLength)
+}
+
+// BackfillRenderedContent render Markdown cho câu hỏi/câu trả lời tạo trước khi có content_html.
+// Chạy nền lúc khởi động, mỗi lần xử lý một batch đến khi hết.
+func BackfillRenderedContent() {
+    total := backfillRenderedContent(&models.Question{}) + backfillRenderedContent(&models.Answer{})
+    if total > 0 {
+        log.Printf("Rendered Markdown for %d existing questions and answers", total)
+    }
+}
+
+func backfillRenderedContent(model interface{}) int {
+    total := 0
+    for {
+        var rows []struct {
+            ID      string
+            Content string
+        }
+        if err := config.DB.Model(model).Select("id", "content").
+            Where("content_html IS NULL OR content_html = ''").
+            Limit(contentBackfillBatch).
+            Scan(&rows).Error; err != nil {
+            log.Printf("Error loading content for backfill: %v", err)
+            return total
+        }
+
+        for _, row := range rows {
+            rendered := renderContent(row.Content)
+            if err := config.DB.Model(model).Where("id = ?", row.ID).
+                UpdateColumns(map[string]interface{}{"content_html": rendered.HTML, "content_text": rendered.Text}).Error; err != nil {
+                log.Printf("Error saving rendered content for %s: %v", row.ID, err)
+                return total
+            }
+        }
+
+        total += len(rows)
+        if len(rows) < contentBackfillBatch {
+            return total
+        }
+    }
+}
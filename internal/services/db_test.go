@@ -0,0 +1,84 @@
+package services
+
+import (
+    "database/sql"
+    "strings"
+    "sync"
+    "testing"
+
+    "github.com/mattn/go-sqlite3"
+    "gorm.io/driver/sqlite"
+    "gorm.io/gorm"
+    "gorm.io/gorm/logger"
+    "vietick/config"
+    "vietick/internal/models"
+)
+
+// testModels là các bảng được tạo trong database test, cùng danh sách với AutoMigrate trong cmd/api
+var testModels = []interface{}{
+    &models.User{},
+    &models.Tag{},
+    &models.Question{},
+    &models.Answer{},
+    &models.Vote{},
+    &models.Follow{},
+    &models.TagFollow{},
+    &models.QuestionWatch{},
+    &models.Notification{},
+    &models.NotificationPreference{},
+    &models.NotificationMute{},
+    &models.Job{},
+    &models.EmailDigestSetting{},
+    &models.UserToken{},
+    &models.Upload{},
+    &models.UserIdentity{},
+    &models.TwoFactor{},
+    &models.RecoveryCode{},
+    &models.SigningKey{},
+    &models.PersonalAccessToken{},
+    &models.BookmarkCollection{},
+    &models.Bookmark{},
+    &models.UserBadge{},
+    &models.Bounty{},
+    &models.PointTransaction{},
+}
+
+var registerTestSQLite sync.Once
+
+// useTestDB thay config.DB bằng SQLite trong bộ nhớ (đã migrate testModels) cho một test. Model khai báo
+// collation MySQL, nên driver đăng ký thêm collation cùng tên để AutoMigrate dùng được model thật.
+func useTestDB(t *testing.T) {
+    t.Helper()
+    registerTestSQLite.Do(func() {
+        sql.Register("sqlite3_vietick_test", &sqlite3.SQLiteDriver{
+            ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+                return conn.RegisterCollation("utf8mb4_general_ci", func(a, b string) int {
+                    return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+                })
+            },
+        })
+    })
+
+    db, err := gorm.Open(&sqlite.Dialector{DriverName: "sqlite3_vietick_test", DSN: ":memory:"}, &gorm.Config{
+        Logger:                                   logger.Default.LogMode(logger.Silent),
+        DisableForeignKeyConstraintWhenMigrating: true,
+    })
+    if err != nil {
+        t.Fatalf("open test database: %v", err)
+    }
+    sqlDB, err := db.DB()
+    if err != nil {
+        t.Fatalf("open test database: %v", err)
+    }
+    sqlDB.SetMaxOpenConns(1) // Mỗi kết nối :memory: là một database riêng
+    if err := db.AutoMigrate(testModels...); err != nil {
+        t.Fatalf("migrate test database: %v", err)
+    }
+
+    previous := config.DB
+    config.DB = db
+    t.Cleanup(func() {
+        config.DB = previous
+        sqlDB.Close()
+    })
+}
@@ -0,0 +1,434 @@
+package services
+
+import (
+    "bytes"
+    "crypto/hmac"
+    "crypto/sha256"
+    "embed"
+    "encoding/base64"
+    "encoding/json"
+    "errors"
+    "fmt"
+    htmltemplate "html/template"
+    "log"
+    "net/url"
+    "os"
+    "strings"
+    texttemplate "text/template"
+    "time"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+    "vietick/config"
+    "vietick/internal/models"
+    "vietick/pkg/mailer"
+)
+
+const (
+    emailDigestJob          = "email.digest"
+    emailDigestMaxAttempts  = 5
+    emailDigestMaxItems     = 20
+    digestSchedulerInterval = time.Hour
+    digestSchedulerBatch    = 200
+)
+
+// DefaultDigestFrequency áp dụng cho user chưa từng chỉnh cài đặt digest
+const DefaultDigestFrequency = models.DigestFrequencyWeekly
+
+//go:embed templates/digest.html templates/digest.txt templates/unsubscribe.html
+var digestTemplateFS embed.FS
+
+var (
+    digestHTMLTemplate      = htmltemplate.Must(htmltemplate.ParseFS(digestTemplateFS, "templates/digest.html"))
+    digestTextTemplate      = texttemplate.Must(texttemplate.ParseFS(digestTemplateFS, "templates/digest.txt"))
+    unsubscribePageTemplate = htmltemplate.Must(htmltemplate.ParseFS(digestTemplateFS, "templates/unsubscribe.html"))
+)
+
+var ErrInvalidUnsubscribeToken = errors.New("invalid unsubscribe token")
+
+type DigestService struct {
+    mailer      mailer.Mailer
+    preferences *NotificationPreferenceService
+    stop        chan struct{}
+    done        chan struct{}
+}
+
+type DigestSettingsResponse struct {
+    Frequency  models.DigestFrequency `json:"frequency"`
+    LastSentAt *time.Time             `json:"last_sent_at"`
+}
+
+// UnsubscribePage là trang HTML mở từ link hủy đăng ký; có Token thì hiện nút xác nhận
+type UnsubscribePage struct {
+    Message string
+    Token   string
+    AppURL  string
+}
+
+type UpdateDigestSettingsRequest struct {
+    Frequency models.DigestFrequency `json:"frequency" binding:"required,oneof=off daily weekly"`
+}
+
+type emailDigestPayload struct {
+    UserID uuid.UUID `json:"user_id"`
+    Since  time.Time `json:"since"`
+    Until  time.Time `json:"until"`
+}
+
+type digestItem struct {
+    Title     string
+    Message   string
+    CreatedAt time.Time
+}
+
+type digestView struct {
+    Subject        string
+    Username       string
+    Total          int
+    Remaining      int
+    PeriodLabel    string
+    Items          []digestItem
+    AppURL         string
+    UnsubscribeURL string
+}
+
+func init() {
+    DefaultJobQueue().Register(emailDigestJob, func(job *models.Job) error {
+        return NewDigestService().handleDigestJob(job)
+    })
+}
+
+func NewDigestService() *DigestService {
+    return &DigestService{
+        mailer:      mailer.NewFromEnv(),
+        preferences: NewNotificationPreferenceService(),
+    }
+}
+
+// GetSettings lấy cài đặt email digest của user (mặc định weekly nếu chưa có)
+func (s *DigestService) GetSettings(userID uuid.UUID) (*DigestSettingsResponse, error) {
+    var setting models.EmailDigestSetting
+    err := config.DB.Where("user_id = ?", userID).First(&setting).Error
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        return &DigestSettingsResponse{Frequency: DefaultDigestFrequency}, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &DigestSettingsResponse{Frequency: setting.Frequency, LastSentAt: setting.LastSentAt}, nil
+}
+
+// UpdateSettings đổi tần suất email digest
+func (s *DigestService) UpdateSettings(userID uuid.UUID, req UpdateDigestSettingsRequest) (*DigestSettingsResponse, error) {
+    setting, err := s.setFrequency(userID, req.Frequency)
+    if err != nil {
+        return nil, err
+    }
+    return &DigestSettingsResponse{Frequency: setting.Frequency, LastSentAt: setting.LastSentAt}, nil
+}
+
+// CheckUnsubscribeToken kiểm tra token trong link cuối email mà không đổi cài đặt
+func (s *DigestService) CheckUnsubscribeToken(token string) (uuid.UUID, error) {
+    userID, ok := parseUnsubscribeToken(token)
+    if !ok {
+        return uuid.Nil, ErrInvalidUnsubscribeToken
+    }
+
+    var user models.User
+    if err := config.DB.Select("id").First(&user, "id = ?", userID).Error; err != nil {
+        return uuid.Nil, ErrInvalidUnsubscribeToken
+    }
+    return userID, nil
+}
+
+// Unsubscribe tắt email digest bằng token trong link cuối email, không cần đăng nhập
+func (s *DigestService) Unsubscribe(token string) error {
+    userID, err := s.CheckUnsubscribeToken(token)
+    if err != nil {
+        return err
+    }
+
+    _, err = s.setFrequency(userID, models.DigestFrequencyOff)
+    return err
+}
+
+// RenderUnsubscribePage render trang xác nhận/kết quả hủy đăng ký
+func (s *DigestService) RenderUnsubscribePage(page UnsubscribePage) ([]byte, error) {
+    page.AppURL = appBaseURL()
+    var body bytes.Buffer
+    if err := unsubscribePageTemplate.Execute(&body, page); err != nil {
+        return nil, err
+    }
+    return body.Bytes(), nil
+}
+
+func (s *DigestService) setFrequency(userID uuid.UUID, frequency models.DigestFrequency) (*models.EmailDigestSetting, error) {
+    now := time.Now()
+    var setting models.EmailDigestSetting
+    if err := config.DB.
+        Where(models.EmailDigestSetting{UserID: userID}).
+        Attrs(models.EmailDigestSetting{LastSentAt: &now, CreatedAt: now}).
+        FirstOrCreate(&setting).Error; err != nil {
+        return nil, err
+    }
+
+    setting.Frequency = frequency
+    setting.UpdatedAt = now
+    if err := config.DB.Model(&setting).
+        Updates(map[string]interface{}{"frequency": frequency, "updated_at": now}).Error; err != nil {
+        return nil, err
+    }
+    return &setting, nil
+}
+
+// StartScheduler chạy định kỳ để đưa các digest đến hạn vào hàng đợi job
+func (s *DigestService) StartScheduler() {
+    if s.stop != nil {
+        return
+    }
+    s.stop = make(chan struct{})
+    s.done = make(chan struct{})
+
+    go func() {
+        defer close(s.done)
+        ticker := time.NewTicker(digestSchedulerInterval)
+        defer ticker.Stop()
+
+        for {
+            if err := s.scheduleDueDigests(time.Now()); err != nil {
+                log.Printf("Error scheduling email digests: %v", err)
+            }
+            select {
+            case <-s.stop:
+                return
+            case <-ticker.C:
+            }
+        }
+    }()
+
+    log.Println("Email digest scheduler started")
+}
+
+// StopScheduler dừng scheduler, job đã enqueue vẫn nằm trong hàng đợi
+func (s *DigestService) StopScheduler() {
+    if s.stop == nil {
+        return
+    }
+    close(s.stop)
+    <-s.done
+    s.stop = nil
+}
+
+// scheduleDueDigests tạo cài đặt mặc định cho user mới, rồi enqueue digest cho những user đến hạn
+// và có notification chưa đọc. last_sent_at được cập nhật có điều kiện nên nhiều instance
+// chạy cùng lúc cũng không gửi trùng.
+func (s *DigestService) scheduleDueDigests(now time.Time) error {
+    if err := createDefaultDigestSettings(now); err != nil {
+        return err
+    }
+
+    var due []models.EmailDigestSetting
+    if err := config.DB.
+        Where("((frequency = ? AND last_sent_at <= ?) OR (frequency = ? AND last_sent_at <= ?))",
+            models.DigestFrequencyDaily, now.Add(-24*time.Hour),
+            models.DigestFrequencyWeekly, now.Add(-7*24*time.Hour)).
+        Where("EXISTS (SELECT 1 FROM notifications WHERE notifications.user_id = email_digest_settings.user_id AND notifications.is_read = ? AND notifications.created_at > email_digest_settings.last_sent_at)", false).
+        Limit(digestSchedulerBatch).
+        Find(&due).Error; err != nil {
+        return err
+    }
+
+    for _, setting := range due {
+        result := config.DB.Model(&models.EmailDigestSetting{}).
+            Where("id = ? AND last_sent_at = ?", setting.ID, setting.LastSentAt).
+            Updates(map[string]interface{}{"last_sent_at": now, "updated_at": now})
+        if result.Error != nil {
+            log.Printf("Error claiming email digest for user %s: %v", setting.UserID, result.Error)
+            continue
+        }
+        if result.RowsAffected == 0 {
+            continue
+        }
+
+        payload := emailDigestPayload{UserID: setting.UserID, Since: *setting.LastSentAt, Until: now}
+        if _, err := DefaultJobQueue().Enqueue(emailDigestJob, payload, emailDigestMaxAttempts); err != nil {
+            log.Printf("Error enqueueing email digest for user %s: %v", setting.UserID, err)
+        }
+    }
+
+    return nil
+}
+
+// createDefaultDigestSettings tạo cài đặt mặc định cho user chưa có; mốc bắt đầu là hiện tại
+// để user không nhận digest cho các notification cũ
+func createDefaultDigestSettings(now time.Time) error {
+    var userIDs []uuid.UUID
+    if err := config.DB.Model(&models.User{}).
+        Where("id NOT IN (SELECT user_id FROM email_digest_settings)").
+        Limit(digestSchedulerBatch).
+        Pluck("id", &userIDs).Error; err != nil {
+        return err
+    }
+    if len(userIDs) == 0 {
+        return nil
+    }
+
+    settings := make([]models.EmailDigestSetting, 0, len(userIDs))
+    for _, userID := range userIDs {
+        settings = append(settings, models.EmailDigestSetting{
+            UserID:     userID,
+            Frequency:  DefaultDigestFrequency,
+            LastSentAt: &now,
+            CreatedAt:  now,
+            UpdatedAt:  now,
+        })
+    }
+    return config.DB.Create(&settings).Error
+}
+
+// handleDigestJob gom notification chưa đọc trong khoảng thời gian của job và gửi một email.
+// Chỉ các loại notification bật email_digest trong cài đặt của user được đưa vào.
+func (s *DigestService) handleDigestJob(job *models.Job) error {
+    var payload emailDigestPayload
+    if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+        return err
+    }
+
+    var user models.User
+    if err := config.DB.First(&user, "id = ?", payload.UserID).Error; err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            return nil
+        }
+        return err
+    }
+
+    preferences, err := s.preferences.GetPreferences(user.ID)
+    if err != nil {
+        return err
+    }
+    var types []models.NotificationType
+    for _, pref := range preferences {
+        if pref.EmailDigest {
+            types = append(types, pref.Type)
+        }
+    }
+    if len(types) == 0 {
+        return nil
+    }
+
+    query := config.DB.Model(&models.Notification{}).
+        Where("user_id = ? AND is_read = ? AND type IN ? AND created_at > ? AND created_at <= ?",
+            user.ID, false, types, payload.Since, payload.Until)
+
+    var total int64
+    if err := query.Count(&total).Error; err != nil {
+        return err
+    }
+    if total == 0 {
+        return nil
+    }
+
+    var notifications []models.Notification
+    if err := query.Order("created_at DESC").Limit(emailDigestMaxItems).Find(&notifications).Error; err != nil {
+        return err
+    }
+
+    msg, err := buildDigestMessage(user, notifications, int(total), payload.Until.Sub(payload.Since))
+    if err != nil {
+        return err
+    }
+    return s.mailer.Send(*msg)
+}
+
+// buildDigestMessage render email digest từ template (bản text và HTML)
+func buildDigestMessage(user models.User, notifications []models.Notification, total int, period time.Duration) (*mailer.Message, error) {
+    unsubscribeToken, err := UnsubscribeToken(user.ID)
+    if err != nil {
+        return nil, err
+    }
+    view := digestView{
+        Subject:        fmt.Sprintf("[VieTick] Bạn có %d thông báo chưa đọc", total),
+        Username:       user.Username,
+        Total:          total,
+        Remaining:      total - len(notifications),
+        PeriodLabel:    "trong tuần qua",
+        AppURL:         appBaseURL(),
+        UnsubscribeURL: appBaseURL() + "/email/unsubscribe?token=" + url.QueryEscape(unsubscribeToken),
+    }
+    if period <= 48*time.Hour {
+        view.PeriodLabel = "trong ngày qua"
+    }
+    for _, notification := range notifications {
+        view.Items = append(view.Items, digestItem{
+            Title:     notification.Title,
+            Message:   notification.Message,
+            CreatedAt: notification.CreatedAt,
+        })
+    }
+
+    var htmlBody, textBody bytes.Buffer
+    if err := digestHTMLTemplate.Execute(&htmlBody, view); err != nil {
+        return nil, err
+    }
+    if err := digestTextTemplate.Execute(&textBody, view); err != nil {
+        return nil, err
+    }
+
+    return &mailer.Message{
+        To:       user.Email,
+        Subject:  view.Subject,
+        TextBody: textBody.String(),
+        HTMLBody: htmlBody.String(),
+        Headers: map[string]string{
+            "List-Unsubscribe":      "<" + view.UnsubscribeURL + ">",
+            "List-Unsubscribe-Post": "List-Unsubscribe=One-Click", // RFC 8058: mail client hủy đăng ký bằng POST tới URL trên
+        },
+    }, nil
+}
+
+// UnsubscribeToken tạo token hủy đăng ký không hết hạn: <user_id>.<HMAC-SHA256(user_id)>
+func UnsubscribeToken(userID uuid.UUID) (string, error) {
+    signature, err := unsubscribeSignature(userID)
+    if err != nil {
+        return "", err
+    }
+    return userID.String() + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func parseUnsubscribeToken(token string) (uuid.UUID, bool) {
+    parts := strings.SplitN(token, ".", 2)
+    if len(parts) != 2 {
+        return uuid.Nil, false
+    }
+    userID, err := uuid.Parse(parts[0])
+    if err != nil {
+        return uuid.Nil, false
+    }
+    signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+    if err != nil {
+        return uuid.Nil, false
+    }
+    expected, err := unsubscribeSignature(userID)
+    if err != nil {
+        return uuid.Nil, false
+    }
+    return userID, hmac.Equal(signature, expected)
+}
+
+func unsubscribeSignature(userID uuid.UUID) ([]byte, error) {
+    secret, err := tokenSecret()
+    if err != nil {
+        return nil, err
+    }
+    mac := hmac.New(sha256.New, secret)
+    mac.Write([]byte("unsubscribe:" + userID.String()))
+    return mac.Sum(nil), nil
+}
+
+// appBaseURL là địa chỉ public của ứng dụng, dùng để tạo link trong email
+func appBaseURL() string {
+    if base := os.Getenv("APP_BASE_URL"); base != "" {
+        return strings.TrimRight(base, "/")
+    }
+    return "http://localhost:8080"
+}
@@ -0,0 +1,375 @@
+package services
+
+import (
+    "encoding/base64"
+    "encoding/json"
+    "math"
+    "sort"
+    "time"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+    "vietick/config"
+    "vietick/internal/models"
+)
+
+type FeedMode string
+
+const (
+    FeedModeChronological FeedMode = "chronological"
+    FeedModeHot           FeedMode = "hot"
+)
+
+const (
+    FeedItemQuestion = "question"
+    FeedItemAnswer   = "answer"
+
+    FeedReasonFollowedUser = "followed_user"
+    FeedReasonFollowedTag  = "followed_tag"
+)
+
+const (
+    hotFeedWindow        = 7 * 24 * time.Hour // Chỉ xếp hạng nội dung trong 7 ngày gần nhất
+    hotFeedMaxCandidates = 300
+)
+
+type FeedService struct{}
+
+type FeedItem struct {
+    Type      string           `json:"type"`
+    Reason    string           `json:"reason"`
+    CreatedAt time.Time        `json:"created_at"`
+    Score     float64          `json:"score,omitempty"`
+    Question  *models.Question `json:"question,omitempty"`
+    Answer    *models.Answer   `json:"answer,omitempty"`
+}
+
+type FeedPage struct {
+    Items      []FeedItem `json:"data"`
+    Mode       FeedMode   `json:"mode"`
+    Limit      int        `json:"limit"`
+    NextCursor string     `json:"next_cursor"`
+}
+
+// hotCursor giữ mốc thời gian của trang đầu (cố định tập ứng viên và tuổi khi tính điểm) cùng điểm và ID
+// của mục cuối trang trước. Trang sau chỉ lấy các mục xếp sau mục đó, nên vote/câu trả lời mới làm đổi điểm
+// cũng không đẩy lệch vị trí cắt trang như khi dùng offset.
+type hotCursor struct {
+    AsOf  time.Time `json:"as_of"`
+    Score float64   `json:"s"`
+    ID    uuid.UUID `json:"id"`
+}
+
+func NewFeedService() *FeedService {
+    return &FeedService{}
+}
+
+// GetFeed lấy feed cá nhân từ những user và tag mà user đang follow
+func (s *FeedService) GetFeed(userID uuid.UUID, mode FeedMode, limit int, cursor string) (*FeedPage, error) {
+    p, err := NewPagination(1, limit, "", DefaultPageLimit)
+    if err != nil {
+        return nil, err
+    }
+
+    switch mode {
+    case FeedModeHot:
+        return s.getHotFeed(userID, p.Limit, cursor)
+    default:
+        if cursor != "" {
+            if p.Cursor, err = DecodeCursor(cursor); err != nil {
+                return nil, err
+            }
+        }
+        return s.getChronologicalFeed(userID, p)
+    }
+}
+
+// getChronologicalFeed trộn câu hỏi và câu trả lời mới nhất theo keyset (created_at, id)
+func (s *FeedService) getChronologicalFeed(userID uuid.UUID, p Pagination) (*FeedPage, error) {
+    var questions []models.Question
+    if err := p.Apply(config.DB.Preload("User", publicUserColumns).Preload("Tags").Scopes(feedQuestions(userID)),
+        "questions.created_at", "questions.id").
+        Find(&questions).Error; err != nil {
+        return nil, err
+    }
+
+    var answers []models.Answer
+    if err := p.Apply(config.DB.Preload("User", publicUserColumns).Preload("Question").Scopes(feedAnswers(userID)),
+        "answers.created_at", "answers.id").
+        Find(&answers).Error; err != nil {
+        return nil, err
+    }
+
+    followed, err := s.followedAuthors(userID, questions)
+    if err != nil {
+        return nil, err
+    }
+
+    items := make([]FeedItem, 0, len(questions)+len(answers))
+    for i := range questions {
+        reason := FeedReasonFollowedTag
+        if followed[questions[i].UserID] {
+            reason = FeedReasonFollowedUser
+        }
+        items = append(items, FeedItem{
+            Type:      FeedItemQuestion,
+            Reason:    reason,
+            CreatedAt: questions[i].CreatedAt,
+            Question:  &questions[i],
+        })
+    }
+    for i := range answers {
+        items = append(items, FeedItem{
+            Type:      FeedItemAnswer,
+            Reason:    FeedReasonFollowedUser,
+            CreatedAt: answers[i].CreatedAt,
+            Answer:    &answers[i],
+        })
+    }
+
+    sort.SliceStable(items, func(i, j int) bool {
+        if !items[i].CreatedAt.Equal(items[j].CreatedAt) {
+            return items[i].CreatedAt.After(items[j].CreatedAt)
+        }
+        return feedItemID(items[i]).String() > feedItemID(items[j]).String()
+    })
+
+    page := &FeedPage{Mode: FeedModeChronological, Limit: p.Limit}
+    if len(items) > p.Limit {
+        items = items[:p.Limit]
+        last := items[len(items)-1]
+        page.NextCursor = EncodeCursor(last.CreatedAt, feedItemID(last))
+    }
+    page.Items = dedupeFeedItems(items)
+    return page, nil
+}
+
+// getHotFeed xếp hạng câu hỏi gần đây theo điểm giảm dần theo thời gian
+func (s *FeedService) getHotFeed(userID uuid.UUID, limit int, cursor string) (*FeedPage, error) {
+    state := hotCursor{AsOf: time.Now()}
+    if cursor != "" {
+        if err := decodeHotCursor(cursor, &state); err != nil {
+            return nil, err
+        }
+    }
+
+    var questions []models.Question
+    if err := config.DB.Preload("User", publicUserColumns).Preload("Tags").Scopes(feedQuestions(userID)).
+        Where("questions.created_at <= ? AND questions.created_at >= ?", state.AsOf, state.AsOf.Add(-hotFeedWindow)).
+        Order("questions.created_at DESC").
+        Limit(hotFeedMaxCandidates).
+        Find(&questions).Error; err != nil {
+        return nil, err
+    }
+
+    questionIDs := make([]uuid.UUID, 0, len(questions))
+    for _, q := range questions {
+        questionIDs = append(questionIDs, q.ID)
+    }
+    activity, err := questionActivity(questionIDs, state.AsOf)
+    if err != nil {
+        return nil, err
+    }
+
+    followed, err := s.followedAuthors(userID, questions)
+    if err != nil {
+        return nil, err
+    }
+
+    items := make([]FeedItem, 0, len(questions))
+    for i := range questions {
+        stats := activity[questions[i].ID]
+        reason := FeedReasonFollowedTag
+        if followed[questions[i].UserID] {
+            reason = FeedReasonFollowedUser
+        }
+        items = append(items, FeedItem{
+            Type:      FeedItemQuestion,
+            Reason:    reason,
+            CreatedAt: questions[i].CreatedAt,
+            Score:     hotScore(stats, questions[i].CreatedAt, state.AsOf),
+            Question:  &questions[i],
+        })
+    }
+
+    sort.Slice(items, func(i, j int) bool {
+        return hotRanksBefore(items[i].Score, feedItemID(items[i]), items[j].Score, feedItemID(items[j]))
+    })
+    if cursor != "" {
+        start := sort.Search(len(items), func(i int) bool {
+            return hotRanksBefore(state.Score, state.ID, items[i].Score, feedItemID(items[i]))
+        })
+        items = items[start:]
+    }
+
+    page := &FeedPage{Mode: FeedModeHot, Limit: limit}
+    if len(items) > limit {
+        items = items[:limit]
+        last := items[len(items)-1]
+        page.NextCursor = encodeHotCursor(hotCursor{AsOf: state.AsOf, Score: last.Score, ID: feedItemID(last)})
+    }
+    page.Items = items
+    return page, nil
+}
+
+// hotRanksBefore cho biết mục (scoreA, idA) đứng trước (scoreB, idB) trong feed hot: điểm cao trước,
+// bằng điểm thì so ID để thứ tự là toàn phần và cursor luôn xác định đúng vị trí
+func hotRanksBefore(scoreA float64, idA uuid.UUID, scoreB float64, idB uuid.UUID) bool {
+    if scoreA != scoreB {
+        return scoreA > scoreB
+    }
+    return idA.String() > idB.String()
+}
+
+// followedAuthors trả về tập tác giả (trong danh sách câu hỏi) mà user đang follow
+func (s *FeedService) followedAuthors(userID uuid.UUID, questions []models.Question) (map[uuid.UUID]bool, error) {
+    followed := make(map[uuid.UUID]bool)
+    if len(questions) == 0 {
+        return followed, nil
+    }
+
+    authorIDs := make([]uuid.UUID, 0, len(questions))
+    for _, q := range questions {
+        authorIDs = append(authorIDs, q.UserID)
+    }
+
+    var ids []uuid.UUID
+    if err := config.DB.Model(&models.Follow{}).
+        Where("follower_id = ? AND following_id IN ?", userID, authorIDs).
+        Pluck("following_id", &ids).Error; err != nil {
+        return nil, err
+    }
+    for _, id := range ids {
+        followed[id] = true
+    }
+    return followed, nil
+}
+
+// feedQuestions lọc câu hỏi của user được follow hoặc gắn tag được follow (không gồm của chính mình)
+func feedQuestions(userID uuid.UUID) func(db *gorm.DB) *gorm.DB {
+    return func(db *gorm.DB) *gorm.DB {
+        return db.Where("questions.user_id <> ?", userID).
+            Where("(questions.user_id IN (SELECT following_id FROM follows WHERE follower_id = ?) OR "+
+                "questions.id IN (SELECT question_tags.question_id FROM question_tags "+
+                "JOIN tag_follows ON tag_follows.tag_id = question_tags.tag_id WHERE tag_follows.user_id = ?))",
+                userID, userID)
+    }
+}
+
+// feedAnswers lọc câu trả lời của user được follow
+func feedAnswers(userID uuid.UUID) func(db *gorm.DB) *gorm.DB {
+    return func(db *gorm.DB) *gorm.DB {
+        return db.Where("answers.user_id <> ?", userID).
+            Where("answers.user_id IN (SELECT following_id FROM follows WHERE follower_id = ?)", userID)
+    }
+}
+
+type questionStats struct {
+    QuestionID uuid.UUID
+    Answers    int64
+    UpVotes    int64
+    DownVotes  int64
+}
+
+// questionActivity đếm số câu trả lời và vote (tạo không muộn hơn asOf) cho nhiều câu hỏi trong hai query.
+// Bỏ qua tương tác sau asOf để điểm của một câu hỏi không đổi giữa các trang của cùng một lần cuộn.
+func questionActivity(questionIDs []uuid.UUID, asOf time.Time) (map[uuid.UUID]questionStats, error) {
+    result := make(map[uuid.UUID]questionStats)
+    if len(questionIDs) == 0 {
+        return result, nil
+    }
+
+    var answerCounts []questionStats
+    if err := config.DB.Model(&models.Answer{}).
+        Select("question_id, COUNT(*) AS answers").
+        Where("question_id IN ? AND created_at <= ?", questionIDs, asOf).
+        Group("question_id").
+        Scan(&answerCounts).Error; err != nil {
+        return nil, err
+    }
+    for _, row := range answerCounts {
+        result[row.QuestionID] = row
+    }
+
+    var voteCounts []questionStats
+    if err := config.DB.Model(&models.Vote{}).
+        Select("answers.question_id AS question_id, "+
+            "SUM(CASE WHEN votes.type = ? THEN 1 ELSE 0 END) AS up_votes, "+
+            "SUM(CASE WHEN votes.type = ? THEN 1 ELSE 0 END) AS down_votes", models.UpVote, models.DownVote).
+        Joins("JOIN answers ON answers.id = votes.answer_id").
+        Where("answers.question_id IN ? AND answers.created_at <= ? AND votes.created_at <= ?", questionIDs, asOf, asOf).
+        Group("answers.question_id").
+        Scan(&voteCounts).Error; err != nil {
+        return nil, err
+    }
+    for _, row := range voteCounts {
+        stats := result[row.QuestionID]
+        stats.QuestionID = row.QuestionID
+        stats.UpVotes = row.UpVotes
+        stats.DownVotes = row.DownVotes
+        result[row.QuestionID] = stats
+    }
+
+    return result, nil
+}
+
+// hotScore tính điểm "hot": tương tác chia cho tuổi (giờ) lũy thừa 1.5
+func hotScore(stats questionStats, createdAt, now time.Time) float64 {
+    interactions := 1 + float64(stats.Answers)*2 + float64(stats.UpVotes) - float64(stats.DownVotes)
+    if interactions < 0 {
+        interactions = 0
+    }
+    ageHours := now.Sub(createdAt).Hours()
+    if ageHours < 0 {
+        ageHours = 0
+    }
+    return interactions / math.Pow(ageHours+2, 1.5)
+}
+
+func feedItemID(item FeedItem) uuid.UUID {
+    if item.Question != nil {
+        return item.Question.ID
+    }
+    return item.Answer.ID
+}
+
+// dedupeFeedItems bỏ câu trả lời khi câu hỏi của nó đã có trong cùng trang
+func dedupeFeedItems(items []FeedItem) []FeedItem {
+    questionsInPage := make(map[uuid.UUID]bool)
+    for _, item := range items {
+        if item.Question != nil {
+            questionsInPage[item.Question.ID] = true
+        }
+    }
+
+    seen := make(map[uuid.UUID]bool)
+    result := make([]FeedItem, 0, len(items))
+    for _, item := range items {
+        id := feedItemID(item)
+        if seen[id] {
+            continue
+        }
+        if item.Answer != nil && questionsInPage[item.Answer.QuestionID] {
+            continue
+        }
+        seen[id] = true
+        result = append(result, item)
+    }
+    return result
+}
+
+func encodeHotCursor(cursor hotCursor) string {
+    data, _ := json.Marshal(cursor)
+    return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeHotCursor(value string, cursor *hotCursor) error {
+    data, err := base64.RawURLEncoding.DecodeString(value)
+    if err != nil {
+        return ErrInvalidCursor
+    }
+    if err := json.Unmarshal(data, cursor); err != nil || cursor.AsOf.IsZero() || cursor.ID == uuid.Nil {
+        return ErrInvalidCursor
+    }
+    return nil
+}
@@ -61,7 +61,7 @@ func (s *FollowService) FollowUser(followerID, followingID uuid.UUID) (*FollowRe
     }
 
     // Load follow với user data
-    if err := config.DB.Preload("Follower").Preload("Following").First(&follow, follow.ID).Error; err != nil {
+    if err := config.DB.Preload("Follower", publicUserColumns).Preload("Following", publicUserColumns).First(&follow, follow.ID).Error; err != nil {
         return nil, err
     }
 
@@ -78,6 +78,8 @@ func (s *FollowService) FollowUser(followerID, followingID uuid.UUID) (*FollowRe
         },
     )
 
+    PublishBadgeEvent(BadgeEventUserFollowed, followingID, uuid.Nil)
+
     return &FollowResponse{
         ID:          follow.ID,
         FollowerID:  follow.FollowerID,
@@ -134,57 +136,45 @@ func (s *FollowService) IsFollowing(followerID, followingID uuid.UUID) (bool, er
 }
 
 // GetFollowers lấy danh sách followers của một user
-func (s *FollowService) GetFollowers(userID uuid.UUID, page, limit int) ([]models.User, int64, error) {
-    var followers []models.User
-    var total int64
-
-    // Get total count
-    if err := config.DB.Model(&models.Follow{}).
-        Where("following_id = ?", userID).
-        Count(&total).Error; err != nil {
-        return nil, 0, err
-    }
-
-    // Get followers with pagination
-    offset := (page - 1) * limit
-    if err := config.DB.Model(&models.User{}).
-        Joins("JOIN follows ON users.id = follows.follower_id").
-        Where("follows.following_id = ?", userID).
-        Order("follows.created_at DESC").
-        Offset(offset).
-        Limit(limit).
-        Find(&followers).Error; err != nil {
-        return nil, 0, err
-    }
-
-    return followers, total, nil
+func (s *FollowService) GetFollowers(userID uuid.UUID, p Pagination) ([]models.User, int64, string, error) {
+    return s.listFollows("following_id = ?", userID, "Follower", p)
 }
 
 // GetFollowing lấy danh sách những người mà user đang follow
-func (s *FollowService) GetFollowing(userID uuid.UUID, page, limit int) ([]models.User, int64, error) {
-    var following []models.User
-    var total int64
+func (s *FollowService) GetFollowing(userID uuid.UUID, p Pagination) ([]models.User, int64, string, error) {
+    return s.listFollows("follower_id = ?", userID, "Following", p)
+}
+
+// listFollows phân trang theo keyset của bảng follows rồi trả về user ở phía còn lại
+func (s *FollowService) listFollows(condition string, userID uuid.UUID, relation string, p Pagination) ([]models.User, int64, string, error) {
+    var follows []models.Follow
 
     // Get total count
-    if err := config.DB.Model(&models.Follow{}).
-        Where("follower_id = ?", userID).
-        Count(&total).Error; err != nil {
-        return nil, 0, err
+    total, err := p.Count(config.DB.Model(&models.Follow{}).Where(condition, userID))
+    if err != nil {
+        return nil, 0, "", err
     }
 
-    // Get following with pagination
-    offset := (page - 1) * limit
-    if err := config.DB.Model(&models.User{}).
-        Joins("JOIN follows ON users.id = follows.following_id").
-        Where("follows.follower_id = ?", userID).
-        Order("follows.created_at DESC").
-        Offset(offset).
-        Limit(limit).
-        Find(&following).Error; err != nil {
-        return nil, 0, err
+    // Get follows with pagination
+    if err := p.Apply(config.DB.Preload(relation, publicUserColumns).Where(condition, userID), "created_at", "id").
+        Find(&follows).Error; err != nil {
+        return nil, 0, "", err
+    }
+
+    follows, nextCursor := trimPage(follows, p.Limit, func(f models.Follow) (time.Time, uuid.UUID) {
+        return f.CreatedAt, f.ID
+    })
+
+    users := make([]models.User, 0, len(follows))
+    for _, follow := range follows {
+        if relation == "Follower" {
+            users = append(users, follow.Follower)
+        } else {
+            users = append(users, follow.Following)
+        }
     }
 
-    return following, total, nil
+    return users, total, nextCursor, nil
 }
 
 // GetUserFollowStats lấy thống kê follow của user
@@ -224,4 +214,67 @@ func (s *FollowService) GetMutualFollowers(userID1, userID2 uuid.UUID) ([]models
     }
 
     return mutualUsers, nil
-} 
\ No newline at end of file
+}
+
+// FollowTag follow một tag để nhận thông báo và thấy câu hỏi của tag trong feed
+func (s *FollowService) FollowTag(userID, tagID uuid.UUID) (*models.TagFollow, error) {
+    var tag models.Tag
+    if err := config.DB.First(&tag, "id = ?", tagID).Error; err != nil {
+        return nil, errors.New("tag not found")
+    }
+
+    var existingFollow models.TagFollow
+    if err := config.DB.Where("user_id = ? AND tag_id = ?", userID, tagID).First(&existingFollow).Error; err == nil {
+        return nil, errors.New("already following this tag")
+    }
+
+    follow := models.TagFollow{
+        UserID:    userID,
+        TagID:     tagID,
+        CreatedAt: time.Now(),
+    }
+    if err := config.DB.Create(&follow).Error; err != nil {
+        return nil, err
+    }
+    follow.Tag = tag
+
+    return &follow, nil
+}
+
+// UnfollowTag bỏ follow một tag
+func (s *FollowService) UnfollowTag(userID, tagID uuid.UUID) error {
+    result := config.DB.Where("user_id = ? AND tag_id = ?", userID, tagID).Delete(&models.TagFollow{})
+    if result.Error != nil {
+        return result.Error
+    }
+    if result.RowsAffected == 0 {
+        return errors.New("not following this tag")
+    }
+    return nil
+}
+
+// GetFollowedTags lấy danh sách tag mà user đang follow
+func (s *FollowService) GetFollowedTags(userID uuid.UUID, p Pagination) ([]models.Tag, int64, string, error) {
+    var follows []models.TagFollow
+
+    // Get total count
+    total, err := p.Count(config.DB.Model(&models.TagFollow{}).Where("user_id = ?", userID))
+    if err != nil {
+        return nil, 0, "", err
+    }
+
+    if err := p.Apply(config.DB.Preload("Tag").Where("user_id = ?", userID), "created_at", "id").
+        Find(&follows).Error; err != nil {
+        return nil, 0, "", err
+    }
+
+    follows, nextCursor := trimPage(follows, p.Limit, func(f models.TagFollow) (time.Time, uuid.UUID) {
+        return f.CreatedAt, f.ID
+    })
+
+    tags := make([]models.Tag, 0, len(follows))
+    for _, follow := range follows {
+        tags = append(tags, follow.Tag)
+    }
+    return tags, total, nextCursor, nil
+}
@@ -0,0 +1,370 @@
+package services
+
+import (
+    "encoding/json"
+    "fmt"
+    "log"
+    "math/rand"
+    "sync"
+    "sync/atomic"
+    "time"
+
+    "github.com/google/uuid"
+    "vietick/config"
+    "vietick/internal/models"
+)
+
+const (
+    jobPollInterval   = time.Second
+    jobLockTimeout    = 10 * time.Minute // Job "running" không được gia hạn quá lâu (server chết giữa chừng) sẽ được nhận lại
+    jobHeartbeat      = time.Minute      // Chu kỳ gia hạn locked_at khi handler còn chạy, phải nhỏ hơn nhiều so với jobLockTimeout
+    jobBaseBackoff    = 5 * time.Second
+    jobMaxBackoff     = 30 * time.Minute
+    jobDefaultWorkers = 4
+)
+
+// JobHandler xử lý một job; trả về lỗi để job được retry với backoff
+type JobHandler func(job *models.Job) error
+
+// JobMetrics là số liệu của worker pool kể từ khi khởi động
+type JobMetrics struct {
+    Workers   int              `json:"workers"`
+    Enqueued  int64            `json:"enqueued"`
+    Succeeded int64            `json:"succeeded"`
+    Retried   int64            `json:"retried"`
+    Failed    int64            `json:"failed"`
+    InFlight  int64            `json:"in_flight"`
+    Delivered int64            `json:"notifications_delivered"`
+    Backlog   map[string]int64 `json:"backlog"`
+}
+
+// JobQueue là worker pool trong process, dùng bảng jobs làm hàng đợi bền vững
+type JobQueue struct {
+    workers  int
+    handlers map[string]JobHandler
+    mutex    sync.RWMutex
+    wake     chan struct{}
+    stop     chan struct{}
+    wg       sync.WaitGroup
+    started  bool
+
+    enqueued  int64
+    succeeded int64
+    retried   int64
+    failed    int64
+    inFlight  int64
+    delivered int64
+}
+
+var defaultJobQueue = NewJobQueue(jobDefaultWorkers)
+
+// DefaultJobQueue trả về hàng đợi dùng chung của ứng dụng
+func DefaultJobQueue() *JobQueue {
+    return defaultJobQueue
+}
+
+func NewJobQueue(workers int) *JobQueue {
+    if workers <= 0 {
+        workers = jobDefaultWorkers
+    }
+    return &JobQueue{
+        workers:  workers,
+        handlers: make(map[string]JobHandler),
+        wake:     make(chan struct{}, 1),
+        stop:     make(chan struct{}),
+    }
+}
+
+// SetWorkers đổi số worker, chỉ có tác dụng trước khi Start
+func (q *JobQueue) SetWorkers(workers int) {
+    if workers > 0 && !q.started {
+        q.workers = workers
+    }
+}
+
+// Register đăng ký handler cho một loại job
+func (q *JobQueue) Register(jobType string, handler JobHandler) {
+    q.mutex.Lock()
+    defer q.mutex.Unlock()
+    q.handlers[jobType] = handler
+}
+
+// Enqueue lưu job vào DB và đánh thức worker
+func (q *JobQueue) Enqueue(jobType string, payload interface{}, maxAttempts int) (*models.Job, error) {
+    data, err := json.Marshal(payload)
+    if err != nil {
+        return nil, err
+    }
+
+    now := time.Now()
+    job := models.Job{
+        Type:        jobType,
+        Payload:     string(data),
+        Status:      models.JobStatusPending,
+        MaxAttempts: maxAttempts,
+        RunAt:       now,
+        CreatedAt:   now,
+        UpdatedAt:   now,
+    }
+    if err := config.DB.Create(&job).Error; err != nil {
+        return nil, err
+    }
+
+    atomic.AddInt64(&q.enqueued, 1)
+    select {
+    case q.wake <- struct{}{}:
+    default:
+    }
+    return &job, nil
+}
+
+// Start chạy dispatcher và các worker
+func (q *JobQueue) Start() {
+    if q.started {
+        return
+    }
+    q.started = true
+
+    jobs := make(chan *models.Job)
+    for i := 0; i < q.workers; i++ {
+        q.wg.Add(1)
+        go func() {
+            defer q.wg.Done()
+            for job := range jobs {
+                q.run(job)
+            }
+        }()
+    }
+
+    q.wg.Add(1)
+    go func() {
+        defer q.wg.Done()
+        defer close(jobs)
+        q.dispatch(jobs)
+    }()
+
+    log.Printf("Job queue started with %d workers", q.workers)
+}
+
+// Stop dừng nhận job mới và chờ các job đang chạy hoàn tất
+func (q *JobQueue) Stop() {
+    if !q.started {
+        return
+    }
+    close(q.stop)
+    q.wg.Wait()
+    log.Println("Job queue stopped")
+}
+
+// Metrics trả về số liệu hiện tại, kèm số job tồn đọng theo trạng thái
+func (q *JobQueue) Metrics() (*JobMetrics, error) {
+    metrics := &JobMetrics{
+        Workers:   q.workers,
+        Enqueued:  atomic.LoadInt64(&q.enqueued),
+        Succeeded: atomic.LoadInt64(&q.succeeded),
+        Retried:   atomic.LoadInt64(&q.retried),
+        Failed:    atomic.LoadInt64(&q.failed),
+        InFlight:  atomic.LoadInt64(&q.inFlight),
+        Delivered: atomic.LoadInt64(&q.delivered),
+        Backlog:   make(map[string]int64),
+    }
+
+    var rows []struct {
+        Status string
+        Total  int64
+    }
+    if err := config.DB.Model(&models.Job{}).
+        Select("status, COUNT(*) AS total").
+        Where("status IN ?", []models.JobStatus{models.JobStatusPending, models.JobStatusRunning, models.JobStatusFailed}).
+        Group("status").
+        Scan(&rows).Error; err != nil {
+        return nil, err
+    }
+    for _, row := range rows {
+        metrics.Backlog[row.Status] = row.Total
+    }
+
+    return metrics, nil
+}
+
+// recordDelivered cộng dồn số notification đã gửi từ các job fan-out
+func (q *JobQueue) recordDelivered(count int) {
+    atomic.AddInt64(&q.delivered, int64(count))
+}
+
+// dispatch lấy job đến hạn từ DB và chuyển cho worker
+func (q *JobQueue) dispatch(jobs chan<- *models.Job) {
+    ticker := time.NewTicker(jobPollInterval)
+    defer ticker.Stop()
+
+    for {
+        claimed, err := q.claim(q.workers)
+        if err != nil {
+            log.Printf("Error claiming jobs: %v", err)
+        }
+
+        for i := range claimed {
+            select {
+            case jobs <- &claimed[i]:
+            case <-q.stop:
+                q.release(claimed[i:])
+                return
+            }
+        }
+
+        // Còn job thì lấy tiếp ngay, hết thì chờ
+        if len(claimed) == q.workers {
+            continue
+        }
+
+        select {
+        case <-q.stop:
+            return
+        case <-q.wake:
+        case <-ticker.C:
+        }
+    }
+}
+
+// claim đánh dấu tối đa limit job đến hạn là "running" bằng một câu UPDATE
+// để nhiều instance có thể chạy song song mà không lấy trùng job
+func (q *JobQueue) claim(limit int) ([]models.Job, error) {
+    token := uuid.New()
+    now := time.Now()
+
+    result := config.DB.Model(&models.Job{}).
+        Where("((status = ? AND run_at <= ?) OR (status = ? AND locked_at < ?))",
+            models.JobStatusPending, now, models.JobStatusRunning, now.Add(-jobLockTimeout)).
+        Order("run_at ASC").
+        Limit(limit).
+        Updates(map[string]interface{}{
+            "status":     models.JobStatusRunning,
+            "locked_by":  token,
+            "locked_at":  now,
+            "updated_at": now,
+        })
+    if result.Error != nil || result.RowsAffected == 0 {
+        return nil, result.Error
+    }
+
+    var jobs []models.Job
+    if err := config.DB.Where("locked_by = ? AND status = ?", token, models.JobStatusRunning).
+        Find(&jobs).Error; err != nil {
+        return nil, err
+    }
+    return jobs, nil
+}
+
+// release trả các job đã nhận nhưng chưa chạy về trạng thái pending khi dừng
+func (q *JobQueue) release(jobs []models.Job) {
+    for _, job := range jobs {
+        config.DB.Model(&models.Job{}).Where("id = ?", job.ID).
+            Updates(map[string]interface{}{"status": models.JobStatusPending, "locked_by": nil, "locked_at": nil})
+    }
+}
+
+// run chạy handler và cập nhật trạng thái job (done, retry với backoff, hoặc failed)
+func (q *JobQueue) run(job *models.Job) {
+    atomic.AddInt64(&q.inFlight, 1)
+    defer atomic.AddInt64(&q.inFlight, -1)
+
+    q.mutex.RLock()
+    handler, ok := q.handlers[job.Type]
+    q.mutex.RUnlock()
+
+    var err error
+    if !ok {
+        err = fmt.Errorf("no handler registered for job type %s", job.Type)
+    } else {
+        done := make(chan struct{})
+        go q.heartbeat(job, done)
+        err = safeRunJob(handler, job)
+        close(done)
+    }
+
+    now := time.Now()
+    job.Attempts++
+    updates := map[string]interface{}{
+        "attempts":   job.Attempts,
+        "locked_by":  nil,
+        "locked_at":  nil,
+        "updated_at": now,
+    }
+
+    switch {
+    case err == nil:
+        atomic.AddInt64(&q.succeeded, 1)
+        updates["status"] = models.JobStatusDone
+        updates["last_error"] = ""
+    case job.Attempts >= job.MaxAttempts:
+        atomic.AddInt64(&q.failed, 1)
+        updates["status"] = models.JobStatusFailed
+        updates["last_error"] = err.Error()
+        log.Printf("Job %s (%s) failed permanently after %d attempts: %v", job.ID, job.Type, job.Attempts, err)
+    default:
+        atomic.AddInt64(&q.retried, 1)
+        updates["status"] = models.JobStatusPending
+        updates["last_error"] = err.Error()
+        updates["run_at"] = now.Add(jobBackoff(job.Attempts))
+        log.Printf("Job %s (%s) attempt %d failed, retrying: %v", job.ID, job.Type, job.Attempts, err)
+    }
+
+    // Chỉ cập nhật nếu job vẫn do worker này giữ; nếu đã bị nhận lại thì lần chạy mới quyết định trạng thái
+    result := config.DB.Model(&models.Job{}).Where("id = ? AND locked_by = ?", job.ID, job.LockedBy).Updates(updates)
+    if result.Error != nil {
+        log.Printf("Error updating job %s: %v", job.ID, result.Error)
+    } else if result.RowsAffected == 0 {
+        log.Printf("Job %s (%s) was reclaimed by another worker, discarding result", job.ID, job.Type)
+    }
+}
+
+// heartbeat gia hạn locked_at mỗi jobHeartbeat cho tới khi done đóng, để job chạy lâu hơn
+// jobLockTimeout không bị worker khác nhận lại và chạy song song
+func (q *JobQueue) heartbeat(job *models.Job, done <-chan struct{}) {
+    ticker := time.NewTicker(jobHeartbeat)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-done:
+            return
+        case now := <-ticker.C:
+            result := config.DB.Model(&models.Job{}).
+                Where("id = ? AND locked_by = ? AND status = ?", job.ID, job.LockedBy, models.JobStatusRunning).
+                Updates(map[string]interface{}{"locked_at": now, "updated_at": now})
+            if result.Error != nil {
+                log.Printf("Error refreshing lock of job %s: %v", job.ID, result.Error)
+            } else if result.RowsAffected == 0 {
+                log.Printf("Job %s (%s) lost its lock", job.ID, job.Type)
+                return
+            }
+        }
+    }
+}
+
+// safeRunJob chuyển panic trong handler thành lỗi để job được retry
+func safeRunJob(handler JobHandler, job *models.Job) (err error) {
+    defer func() {
+        if r := recover(); r != nil {
+            err = fmt.Errorf("job panicked: %v", r)
+        }
+    }()
+    return handler(job)
+}
+
+// jobBackoff tính thời gian chờ theo cấp số nhân có jitter, tối đa jobMaxBackoff
+func jobBackoff(attempts int) time.Duration {
+    backoff := jobBaseBackoff << uint(attempts-1)
+    if backoff <= 0 || backoff > jobMaxBackoff {
+        backoff = jobMaxBackoff
+    }
+    jitter := time.Duration(rand.Int63n(int64(backoff) / 5))
+    return backoff + jitter
+}
+
+// saveJobCheckpoint lưu tiến độ để lần retry tiếp tục từ đó thay vì làm lại từ đầu
+func saveJobCheckpoint(job *models.Job, checkpoint string) error {
+    job.Checkpoint = checkpoint
+    return config.DB.Model(&models.Job{}).Where("id = ?", job.ID).
+        Update("checkpoint", checkpoint).Error
+}
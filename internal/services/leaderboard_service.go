@@ -0,0 +1,318 @@
+package services
+
+import (
+    "errors"
+    "log"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+    "vietick/config"
+    "vietick/internal/models"
+)
+
+const (
+    leaderboardSize            = 100
+    leaderboardRefreshInterval = 5 * time.Minute
+    leaderboardTagIdleTimeout  = time.Hour // Bảng xếp hạng theo tag không ai xem trong khoảng này sẽ bị bỏ khỏi cache
+)
+
+// LeaderboardPeriod là khoảng thời gian tính bảng xếp hạng
+type LeaderboardPeriod string
+
+const (
+    LeaderboardWeekly  LeaderboardPeriod = "weekly"
+    LeaderboardMonthly LeaderboardPeriod = "monthly"
+    LeaderboardAllTime LeaderboardPeriod = "all"
+)
+
+// LeaderboardMetric cho biết điểm xếp hạng được tính từ đâu
+type LeaderboardMetric string
+
+const (
+    LeaderboardMetricReputation LeaderboardMetric = "reputation"
+    LeaderboardMetricUpvotes    LeaderboardMetric = "upvotes"
+)
+
+var (
+    ErrInvalidLeaderboardPeriod = errors.New("invalid period, must be weekly, monthly or all")
+    ErrTagNotFound              = errors.New("tag not found")
+)
+
+// ParseLeaderboardPeriod chuyển query param thành period, mặc định là weekly
+func ParseLeaderboardPeriod(value string) (LeaderboardPeriod, error) {
+    switch period := LeaderboardPeriod(strings.ToLower(strings.TrimSpace(value))); period {
+    case "":
+        return LeaderboardWeekly, nil
+    case LeaderboardWeekly, LeaderboardMonthly, LeaderboardAllTime:
+        return period, nil
+    default:
+        return "", ErrInvalidLeaderboardPeriod
+    }
+}
+
+// since trả về mốc bắt đầu của period; zero với all
+func (p LeaderboardPeriod) since(now time.Time) time.Time {
+    switch p {
+    case LeaderboardWeekly:
+        return now.AddDate(0, 0, -7)
+    case LeaderboardMonthly:
+        return now.AddDate(0, -1, 0)
+    default:
+        return time.Time{}
+    }
+}
+
+type LeaderboardEntry struct {
+    Rank  int         `json:"rank"`
+    User  models.User `json:"user"`
+    Score int64       `json:"score"`
+}
+
+// Leaderboard là một trang bảng xếp hạng; Total là số user trong bảng (tối đa leaderboardSize)
+type Leaderboard struct {
+    Period     LeaderboardPeriod
+    Metric     LeaderboardMetric
+    Tag        *BadgeTagRef
+    Entries    []LeaderboardEntry
+    Total      int64
+    ComputedAt time.Time
+}
+
+type leaderboardKey struct {
+    period LeaderboardPeriod
+    tagID  uuid.UUID // uuid.Nil: toàn site
+}
+
+type cachedLeaderboard struct {
+    entries       []LeaderboardEntry
+    computedAt    time.Time
+    lastRequested time.Time
+}
+
+// LeaderboardService giữ top user của từng bảng xếp hạng trong bộ nhớ và tính lại định kỳ,
+// nên request xem bảng xếp hạng không phải quét bảng votes. Bảng theo tag chỉ được tính khi
+// có người xem lần đầu và được làm mới chừng nào còn người xem.
+type LeaderboardService struct {
+    mutex sync.Mutex
+    cache map[leaderboardKey]*cachedLeaderboard
+    stop  chan struct{}
+    done  chan struct{}
+}
+
+// defaultLeaderboardService dùng chung để controller và scheduler cùng một cache
+var defaultLeaderboardService = &LeaderboardService{
+    cache: make(map[leaderboardKey]*cachedLeaderboard),
+}
+
+func NewLeaderboardService() *LeaderboardService {
+    return defaultLeaderboardService
+}
+
+// GetLeaderboard trả về một trang bảng xếp hạng từ cache; bảng chưa có trong cache được tính ngay.
+// tagName rỗng là bảng xếp hạng toàn site.
+func (s *LeaderboardService) GetLeaderboard(period LeaderboardPeriod, tagName string, p Pagination) (*Leaderboard, error) {
+    key := leaderboardKey{period: period}
+    var tagRef *BadgeTagRef
+    if tagName = strings.TrimSpace(tagName); tagName != "" {
+        var tag models.Tag
+        if err := config.DB.Select("id", "name").Where("name = ?", tagName).First(&tag).Error; err != nil {
+            if errors.Is(err, gorm.ErrRecordNotFound) {
+                return nil, ErrTagNotFound
+            }
+            return nil, err
+        }
+        key.tagID = tag.ID
+        tagRef = &BadgeTagRef{ID: tag.ID, Name: tag.Name}
+    }
+
+    now := time.Now()
+    s.mutex.Lock()
+    cached, ok := s.cache[key]
+    if ok {
+        cached.lastRequested = now
+    }
+    s.mutex.Unlock()
+
+    if !ok {
+        entries, err := computeLeaderboard(key, now)
+        if err != nil {
+            return nil, err
+        }
+        cached = &cachedLeaderboard{entries: entries, computedAt: now, lastRequested: now}
+        s.mutex.Lock()
+        s.cache[key] = cached
+        s.mutex.Unlock()
+    }
+
+    start := p.Offset()
+    if start > len(cached.entries) {
+        start = len(cached.entries)
+    }
+    end := start + p.Limit
+    if end > len(cached.entries) {
+        end = len(cached.entries)
+    }
+
+    return &Leaderboard{
+        Period:     period,
+        Metric:     key.metric(),
+        Tag:        tagRef,
+        Entries:    cached.entries[start:end],
+        Total:      int64(len(cached.entries)),
+        ComputedAt: cached.computedAt,
+    }, nil
+}
+
+// StartRefresher tính các bảng xếp hạng toàn site ngay, rồi làm mới toàn bộ cache định kỳ
+func (s *LeaderboardService) StartRefresher() {
+    if s.stop != nil {
+        return
+    }
+    s.stop = make(chan struct{})
+    s.done = make(chan struct{})
+
+    go func() {
+        defer close(s.done)
+        ticker := time.NewTicker(leaderboardRefreshInterval)
+        defer ticker.Stop()
+
+        for {
+            if err := s.Refresh(); err != nil {
+                log.Printf("Error refreshing leaderboards: %v", err)
+            }
+            select {
+            case <-s.stop:
+                return
+            case <-ticker.C:
+            }
+        }
+    }()
+
+    log.Println("Leaderboard refresher started")
+}
+
+// StopRefresher dừng refresher
+func (s *LeaderboardService) StopRefresher() {
+    if s.stop == nil {
+        return
+    }
+    close(s.stop)
+    <-s.done
+    s.stop = nil
+}
+
+// Refresh tính lại các bảng toàn site và các bảng theo tag còn người xem, bỏ các bảng theo tag đã lâu không ai xem.
+// Bảng tính lỗi giữ nguyên dữ liệu cũ.
+func (s *LeaderboardService) Refresh() error {
+    now := time.Now()
+    keys := []leaderboardKey{{period: LeaderboardWeekly}, {period: LeaderboardMonthly}, {period: LeaderboardAllTime}}
+
+    s.mutex.Lock()
+    for key, cached := range s.cache {
+        if key.tagID == uuid.Nil {
+            continue
+        }
+        if now.Sub(cached.lastRequested) > leaderboardTagIdleTimeout {
+            delete(s.cache, key)
+            continue
+        }
+        keys = append(keys, key)
+    }
+    s.mutex.Unlock()
+
+    var firstErr error
+    for _, key := range keys {
+        entries, err := computeLeaderboard(key, now)
+        if err != nil {
+            if firstErr == nil {
+                firstErr = err
+            }
+            continue
+        }
+
+        s.mutex.Lock()
+        lastRequested := now
+        if cached, ok := s.cache[key]; ok {
+            lastRequested = cached.lastRequested
+        }
+        s.cache[key] = &cachedLeaderboard{entries: entries, computedAt: now, lastRequested: lastRequested}
+        s.mutex.Unlock()
+    }
+    return firstErr
+}
+
+// metric: bảng toàn site mọi thời điểm xếp theo reputation (điểm của user); bảng theo period hoặc tag
+// xếp theo số upvote nhận được cho câu trả lời trong khoảng thời gian / tag đó
+func (k leaderboardKey) metric() LeaderboardMetric {
+    if k.period == LeaderboardAllTime && k.tagID == uuid.Nil {
+        return LeaderboardMetricReputation
+    }
+    return LeaderboardMetricUpvotes
+}
+
+// computeLeaderboard tính top leaderboardSize user của một bảng xếp hạng, bỏ qua tài khoản giữ chỗ
+func computeLeaderboard(key leaderboardKey, now time.Time) ([]LeaderboardEntry, error) {
+    var ranked []struct {
+        UserID uuid.UUID
+        Score  int64
+    }
+
+    if key.metric() == LeaderboardMetricReputation {
+        if err := config.DB.Model(&models.User{}).
+            Select("id AS user_id, point AS score").
+            Where("id <> ? AND point > 0", DeletedUserID).
+            Order("point DESC").Order("created_at ASC").
+            Limit(leaderboardSize).
+            Scan(&ranked).Error; err != nil {
+            return nil, err
+        }
+    } else {
+        // Upvote được tính theo lúc vote (updated_at, vì đổi down -> up cũng là một upvote mới); tự vote không được tính
+        query := config.DB.Table("votes").
+            Select("answers.user_id AS user_id, COUNT(*) AS score").
+            Joins("JOIN answers ON answers.id = votes.answer_id").
+            Where("votes.type = ? AND votes.user_id <> answers.user_id AND answers.user_id <> ?", models.UpVote, DeletedUserID)
+        if since := key.period.since(now); !since.IsZero() {
+            query = query.Where("votes.updated_at >= ?", since)
+        }
+        if key.tagID != uuid.Nil {
+            query = query.Joins("JOIN question_tags ON question_tags.question_id = answers.question_id AND question_tags.tag_id = ?", key.tagID)
+        }
+        if err := query.Group("answers.user_id").
+            Order("score DESC").Order("answers.user_id ASC").
+            Limit(leaderboardSize).
+            Scan(&ranked).Error; err != nil {
+            return nil, err
+        }
+    }
+
+    if len(ranked) == 0 {
+        return []LeaderboardEntry{}, nil
+    }
+
+    ids := make([]uuid.UUID, 0, len(ranked))
+    for _, row := range ranked {
+        ids = append(ids, row.UserID)
+    }
+    var users []models.User
+    if err := config.DB.Scopes(publicUserColumns).Where("id IN ?", ids).Find(&users).Error; err != nil {
+        return nil, err
+    }
+    byID := make(map[uuid.UUID]models.User, len(users))
+    for _, user := range users {
+        byID[user.ID] = user
+    }
+
+    entries := make([]LeaderboardEntry, 0, len(ranked))
+    for _, row := range ranked {
+        user, ok := byID[row.UserID]
+        if !ok {
+            continue // Bị xóa giữa hai query
+        }
+        entries = append(entries, LeaderboardEntry{Rank: len(entries) + 1, User: user, Score: row.Score})
+    }
+    return entries, nil
+}
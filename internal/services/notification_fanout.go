@@ -0,0 +1,212 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"vietick/config"
+	"vietick/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	notificationFanoutJob         = "notification.fanout"
+	notificationFanoutBatchSize   = 500
+	notificationFanoutMaxAttempts = 5
+)
+
+// Đối tượng nhận của một job fan-out
+const (
+	fanoutAudienceFollowers    = "followers"     // SubjectID là tác giả
+	fanoutAudienceTagFollowers = "tag_followers" // SubjectID là tác giả, TagIDs là các tag
+	fanoutAudienceWatchers     = "watchers"      // SubjectID là câu hỏi
+	fanoutAudienceBookmarkers  = "bookmarkers"   // SubjectID là câu hỏi
+)
+
+type notificationFanoutPayload struct {
+	Audience   string                  `json:"audience"`
+	SubjectID  uuid.UUID               `json:"subject_id"`
+	TagIDs     []uuid.UUID             `json:"tag_ids,omitempty"`
+	ExcludeIDs []uuid.UUID             `json:"exclude_ids,omitempty"`
+	Type       models.NotificationType `json:"type"`
+	Title      string                  `json:"title"`
+	Message    string                  `json:"message"`
+	Data       map[string]interface{}  `json:"data,omitempty"`
+}
+
+func init() {
+	DefaultJobQueue().Register(notificationFanoutJob, defaultNotificationService.handleFanoutJob)
+}
+
+// enqueueFanout đưa việc gửi notification hàng loạt vào hàng đợi nền,
+// để thời gian xử lý request không phụ thuộc vào số người nhận
+func (s *NotificationService) enqueueFanout(payload notificationFanoutPayload) error {
+	if _, err := DefaultJobQueue().Enqueue(notificationFanoutJob, payload, notificationFanoutMaxAttempts); err != nil {
+		log.Printf("Error enqueueing notification fan-out: %v", err)
+		return err
+	}
+	return nil
+}
+
+// handleFanoutJob duyệt người nhận theo từng batch (keyset trên user id) và lưu checkpoint sau mỗi batch
+func (s *NotificationService) handleFanoutJob(job *models.Job) error {
+	var payload notificationFanoutPayload
+	if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+		return err
+	}
+
+	after := uuid.Nil
+	if job.Checkpoint != "" {
+		if checkpoint, err := uuid.Parse(job.Checkpoint); err == nil {
+			after = checkpoint
+		}
+	}
+
+	excluded := make(map[uuid.UUID]bool, len(payload.ExcludeIDs))
+	for _, id := range payload.ExcludeIDs {
+		excluded[id] = true
+	}
+
+	for {
+		recipientIDs, err := fanoutRecipients(payload, after, notificationFanoutBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(recipientIDs) == 0 {
+			return nil
+		}
+
+		batch := make([]uuid.UUID, 0, len(recipientIDs))
+		for _, id := range recipientIDs {
+			if !excluded[id] {
+				batch = append(batch, id)
+			}
+		}
+
+		if err := s.deliverBatch(job.ID, batch, payload); err != nil {
+			return err
+		}
+
+		after = recipientIDs[len(recipientIDs)-1]
+		if err := saveJobCheckpoint(job, after.String()); err != nil {
+			return err
+		}
+
+		if len(recipientIDs) < notificationFanoutBatchSize {
+			return nil
+		}
+	}
+}
+
+// fanoutRecipients lấy batch người nhận tiếp theo có user id lớn hơn after
+func fanoutRecipients(payload notificationFanoutPayload, after uuid.UUID, limit int) ([]uuid.UUID, error) {
+	var query *gorm.DB
+	var column string
+
+	switch payload.Audience {
+	case fanoutAudienceFollowers:
+		column = "follower_id"
+		query = config.DB.Model(&models.Follow{}).
+			Where("following_id = ?", payload.SubjectID)
+	case fanoutAudienceTagFollowers:
+		if len(payload.TagIDs) == 0 {
+			return nil, nil
+		}
+		// Bỏ qua những người đã follow tác giả (họ đã nhận thông báo câu hỏi mới)
+		column = "user_id"
+		query = config.DB.Model(&models.TagFollow{}).
+			Where("tag_id IN ? AND user_id <> ?", payload.TagIDs, payload.SubjectID).
+			Where("user_id NOT IN (SELECT follower_id FROM follows WHERE following_id = ?)", payload.SubjectID)
+	case fanoutAudienceWatchers:
+		column = "user_id"
+		query = config.DB.Model(&models.QuestionWatch{}).
+			Where("question_id = ?", payload.SubjectID)
+	case fanoutAudienceBookmarkers:
+		// Bỏ qua những người đang theo dõi câu hỏi (họ đã nhận thông báo cho người theo dõi)
+		column = "user_id"
+		query = config.DB.Model(&models.Bookmark{}).
+			Where("question_id = ?", payload.SubjectID).
+			Where("user_id NOT IN (SELECT user_id FROM question_watches WHERE question_id = ?)", payload.SubjectID)
+	default:
+		log.Printf("Unknown notification fan-out audience: %s", payload.Audience)
+		return nil, nil
+	}
+
+	var ids []uuid.UUID
+	err := query.Where(column+" > ?", after).
+		Distinct(column).
+		Order(column+" ASC").
+		Limit(limit).
+		Pluck(column, &ids).Error
+	return ids, err
+}
+
+// deliverBatch áp dụng cài đặt của người nhận, lưu notification bằng một câu INSERT rồi gửi SSE.
+// ID notification được sinh cố định từ job và người nhận nên retry không tạo bản ghi trùng.
+func (s *NotificationService) deliverBatch(jobID uuid.UUID, userIDs []uuid.UUID, payload notificationFanoutPayload) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	channels, err := s.preferences.ResolveChannelsBatch(userIDs, payload.Type, payload.Data)
+	if err != nil {
+		return err
+	}
+
+	var data string
+	if payload.Data != nil {
+		dataJSON, err := json.Marshal(payload.Data)
+		if err != nil {
+			return err
+		}
+		data = string(dataJSON)
+	}
+
+	now := time.Now()
+	delivered := 0
+	stored := make([]models.Notification, 0, len(userIDs))
+	pushed := make([]models.Notification, 0, len(userIDs))
+	for _, userID := range userIDs {
+		c := channels[userID]
+		if !c.InApp && !c.SSE && !c.EmailDigest {
+			continue
+		}
+		delivered++
+
+		notification := models.Notification{
+			ID:         uuid.NewSHA1(jobID, userID[:]),
+			UserID:     userID,
+			Type:       payload.Type,
+			Title:      payload.Title,
+			Message:    payload.Message,
+			Data:       data,
+			DigestOnly: !c.InApp,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		if c.stored() {
+			stored = append(stored, notification)
+		}
+		if c.SSE {
+			pushed = append(pushed, notification)
+		}
+	}
+
+	if len(stored) > 0 {
+		if err := config.DB.Clauses(clause.OnConflict{DoNothing: true}).
+			CreateInBatches(&stored, notificationFanoutBatchSize).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, notification := range pushed {
+		s.sendToUser(notification.UserID, notification)
+	}
+
+	DefaultJobQueue().recordDelivered(delivered)
+	return nil
+}
@@ -0,0 +1,299 @@
+package services
+
+import (
+    "errors"
+    "time"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+    "vietick/config"
+    "vietick/internal/models"
+)
+
+type NotificationPreferenceService struct{}
+
+// NotificationChannels là các kênh nhận một loại notification
+type NotificationChannels struct {
+    InApp       bool `json:"in_app"`
+    SSE         bool `json:"sse"`
+    EmailDigest bool `json:"email_digest"`
+}
+
+// stored cho biết notification có cần lưu DB không: để hiện in-app hoặc để gom vào email digest
+func (c NotificationChannels) stored() bool {
+    return c.InApp || c.EmailDigest
+}
+
+type NotificationPreferenceResponse struct {
+    Type models.NotificationType `json:"type"`
+    NotificationChannels
+}
+
+type UpdateNotificationPreferenceRequest struct {
+    Type        models.NotificationType `json:"type" binding:"required"`
+    InApp       *bool                   `json:"in_app"`
+    SSE         *bool                   `json:"sse"`
+    EmailDigest *bool                   `json:"email_digest"`
+}
+
+type UpdateNotificationPreferencesRequest struct {
+    Preferences []UpdateNotificationPreferenceRequest `json:"preferences" binding:"required,min=1,dive"`
+}
+
+type CreateMuteRequest struct {
+    TargetType models.MuteTargetType `json:"target_type" binding:"required,oneof=user question"`
+    TargetID   string                `json:"target_id" binding:"required,uuid"`
+}
+
+func NewNotificationPreferenceService() *NotificationPreferenceService {
+    return &NotificationPreferenceService{}
+}
+
+// DefaultNotificationChannels trả về cấu hình mặc định; unfollow mặc định bị tắt
+func DefaultNotificationChannels(notificationType models.NotificationType) NotificationChannels {
+    if notificationType == models.NotificationTypeUnfollow {
+        return NotificationChannels{}
+    }
+    return NotificationChannels{InApp: true, SSE: true, EmailDigest: true}
+}
+
+// GetPreferences lấy cài đặt hiệu lực của user cho tất cả loại notification
+func (s *NotificationPreferenceService) GetPreferences(userID uuid.UUID) ([]NotificationPreferenceResponse, error) {
+    var stored []models.NotificationPreference
+    if err := config.DB.Where("user_id = ?", userID).Find(&stored).Error; err != nil {
+        return nil, err
+    }
+
+    byType := make(map[models.NotificationType]models.NotificationPreference, len(stored))
+    for _, pref := range stored {
+        byType[pref.Type] = pref
+    }
+
+    preferences := make([]NotificationPreferenceResponse, 0, len(models.NotificationTypes))
+    for _, notificationType := range models.NotificationTypes {
+        channels := DefaultNotificationChannels(notificationType)
+        if pref, ok := byType[notificationType]; ok {
+            channels = NotificationChannels{InApp: pref.InApp, SSE: pref.SSE, EmailDigest: pref.EmailDigest}
+        }
+        preferences = append(preferences, NotificationPreferenceResponse{
+            Type:                 notificationType,
+            NotificationChannels: channels,
+        })
+    }
+
+    return preferences, nil
+}
+
+// UpdatePreferences cập nhật cài đặt; trường không gửi lên giữ nguyên giá trị hiện tại
+func (s *NotificationPreferenceService) UpdatePreferences(userID uuid.UUID, req UpdateNotificationPreferencesRequest) ([]NotificationPreferenceResponse, error) {
+    for _, item := range req.Preferences {
+        if !item.Type.IsValid() {
+            return nil, errors.New("invalid notification type: " + string(item.Type))
+        }
+    }
+
+    err := config.DB.Transaction(func(tx *gorm.DB) error {
+        for _, item := range req.Preferences {
+            var pref models.NotificationPreference
+            err := tx.Where("user_id = ? AND type = ?", userID, item.Type).First(&pref).Error
+            if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+                return err
+            }
+            if errors.Is(err, gorm.ErrRecordNotFound) {
+                defaults := DefaultNotificationChannels(item.Type)
+                pref = models.NotificationPreference{
+                    UserID:      userID,
+                    Type:        item.Type,
+                    InApp:       defaults.InApp,
+                    SSE:         defaults.SSE,
+                    EmailDigest: defaults.EmailDigest,
+                    CreatedAt:   time.Now(),
+                }
+            }
+
+            if item.InApp != nil {
+                pref.InApp = *item.InApp
+            }
+            if item.SSE != nil {
+                pref.SSE = *item.SSE
+            }
+            if item.EmailDigest != nil {
+                pref.EmailDigest = *item.EmailDigest
+            }
+            pref.UpdatedAt = time.Now()
+
+            if err := tx.Save(&pref).Error; err != nil {
+                return err
+            }
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return s.GetPreferences(userID)
+}
+
+// GetMutes lấy danh sách user/câu hỏi đã tắt thông báo
+func (s *NotificationPreferenceService) GetMutes(userID uuid.UUID) ([]models.NotificationMute, error) {
+    var mutes []models.NotificationMute
+    if err := config.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&mutes).Error; err != nil {
+        return nil, err
+    }
+    return mutes, nil
+}
+
+// Mute tắt thông báo từ một user hoặc về một câu hỏi
+func (s *NotificationPreferenceService) Mute(userID uuid.UUID, req CreateMuteRequest) (*models.NotificationMute, error) {
+    targetID, err := uuid.Parse(req.TargetID)
+    if err != nil {
+        return nil, errors.New("invalid target ID")
+    }
+
+    switch req.TargetType {
+    case models.MuteTargetUser:
+        if targetID == userID {
+            return nil, errors.New("cannot mute yourself")
+        }
+        var user models.User
+        if err := config.DB.First(&user, "id = ?", targetID).Error; err != nil {
+            return nil, errors.New("user not found")
+        }
+    case models.MuteTargetQuestion:
+        var question models.Question
+        if err := config.DB.First(&question, "id = ?", targetID).Error; err != nil {
+            return nil, errors.New("question not found")
+        }
+    default:
+        return nil, errors.New("invalid mute target type")
+    }
+
+    var existingMute models.NotificationMute
+    if err := config.DB.Where("user_id = ? AND target_type = ? AND target_id = ?", userID, req.TargetType, targetID).
+        First(&existingMute).Error; err == nil {
+        return nil, errors.New("already muted")
+    }
+
+    mute := models.NotificationMute{
+        UserID:     userID,
+        TargetType: req.TargetType,
+        TargetID:   targetID,
+        CreatedAt:  time.Now(),
+    }
+    if err := config.DB.Create(&mute).Error; err != nil {
+        return nil, err
+    }
+    return &mute, nil
+}
+
+// Unmute bật lại thông báo
+func (s *NotificationPreferenceService) Unmute(userID, muteID uuid.UUID) error {
+    result := config.DB.Where("id = ? AND user_id = ?", muteID, userID).Delete(&models.NotificationMute{})
+    if result.Error != nil {
+        return result.Error
+    }
+    if result.RowsAffected == 0 {
+        return errors.New("mute not found")
+    }
+    return nil
+}
+
+// ResolveChannels quyết định notification được gửi qua kênh nào, dựa trên mute và cài đặt của người nhận
+func (s *NotificationPreferenceService) ResolveChannels(userID uuid.UUID, notificationType models.NotificationType, data map[string]interface{}) (NotificationChannels, error) {
+    channels, err := s.ResolveChannelsBatch([]uuid.UUID{userID}, notificationType, data)
+    if err != nil {
+        return NotificationChannels{}, err
+    }
+    return channels[userID], nil
+}
+
+// ResolveChannelsBatch giống ResolveChannels nhưng cho nhiều người nhận trong hai query, dùng khi fan-out
+func (s *NotificationPreferenceService) ResolveChannelsBatch(userIDs []uuid.UUID, notificationType models.NotificationType, data map[string]interface{}) (map[uuid.UUID]NotificationChannels, error) {
+    result := make(map[uuid.UUID]NotificationChannels, len(userIDs))
+    if len(userIDs) == 0 {
+        return result, nil
+    }
+
+    muted := make(map[uuid.UUID]bool)
+    actorIDs, questionID := notificationSubjects(data)
+    if len(actorIDs) > 0 || questionID != nil {
+        query := config.DB.Model(&models.NotificationMute{}).Where("user_id IN ?", userIDs)
+        switch {
+        case len(actorIDs) > 0 && questionID != nil:
+            query = query.Where("((target_type = ? AND target_id IN ?) OR (target_type = ? AND target_id = ?))",
+                models.MuteTargetUser, actorIDs, models.MuteTargetQuestion, *questionID)
+        case len(actorIDs) > 0:
+            query = query.Where("target_type = ? AND target_id IN ?", models.MuteTargetUser, actorIDs)
+        default:
+            query = query.Where("target_type = ? AND target_id = ?", models.MuteTargetQuestion, *questionID)
+        }
+
+        var mutedIDs []uuid.UUID
+        if err := query.Distinct("user_id").Pluck("user_id", &mutedIDs).Error; err != nil {
+            return nil, err
+        }
+        for _, id := range mutedIDs {
+            muted[id] = true
+        }
+    }
+
+    var prefs []models.NotificationPreference
+    if err := config.DB.Where("user_id IN ? AND type = ?", userIDs, notificationType).Find(&prefs).Error; err != nil {
+        return nil, err
+    }
+    byUser := make(map[uuid.UUID]models.NotificationPreference, len(prefs))
+    for _, pref := range prefs {
+        byUser[pref.UserID] = pref
+    }
+
+    defaults := DefaultNotificationChannels(notificationType)
+    for _, userID := range userIDs {
+        switch pref, ok := byUser[userID]; {
+        case muted[userID]:
+            result[userID] = NotificationChannels{}
+        case ok:
+            result[userID] = NotificationChannels{InApp: pref.InApp, SSE: pref.SSE, EmailDigest: pref.EmailDigest}
+        default:
+            result[userID] = defaults
+        }
+    }
+
+    return result, nil
+}
+
+// notificationActorKeys là các khóa trong data chứa ID người gây ra sự kiện
+var notificationActorKeys = []string{"actor_id", "author_id", "follower_id", "verifier_id"}
+
+// notificationSubjects lấy ID người thực hiện và câu hỏi liên quan từ data của notification
+func notificationSubjects(data map[string]interface{}) ([]uuid.UUID, *uuid.UUID) {
+    var actorIDs []uuid.UUID
+    for _, key := range notificationActorKeys {
+        if id, ok := toUUID(data[key]); ok {
+            actorIDs = append(actorIDs, id)
+        }
+    }
+
+    var questionID *uuid.UUID
+    if id, ok := toUUID(data["question_id"]); ok {
+        questionID = &id
+    }
+
+    return actorIDs, questionID
+}
+
+func toUUID(value interface{}) (uuid.UUID, bool) {
+    switch v := value.(type) {
+    case uuid.UUID:
+        return v, v != uuid.Nil
+    case *uuid.UUID:
+        if v == nil {
+            return uuid.Nil, false
+        }
+        return *v, *v != uuid.Nil
+    case string:
+        id, err := uuid.Parse(v)
+        return id, err == nil
+    }
+    return uuid.Nil, false
+}
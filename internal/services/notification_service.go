@@ -2,6 +2,7 @@ package services
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"sync"
 	"time"
@@ -11,11 +12,13 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type NotificationService struct {
-	clients map[uuid.UUID]*Client
-	mutex   sync.RWMutex
+	clients     map[uuid.UUID]*Client
+	mutex       sync.RWMutex
+	preferences *NotificationPreferenceService
 }
 
 type Client struct {
@@ -35,10 +38,14 @@ type NotificationData struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// defaultNotificationService dùng chung để mọi service gửi SSE đến cùng một tập clients
+var defaultNotificationService = &NotificationService{
+	clients:     make(map[uuid.UUID]*Client),
+	preferences: NewNotificationPreferenceService(),
+}
+
 func NewNotificationService() *NotificationService {
-	return &NotificationService{
-		clients: make(map[uuid.UUID]*Client),
-	}
+	return defaultNotificationService
 }
 
 // AddClient thêm client vào SSE connection
@@ -71,17 +78,27 @@ func (s *NotificationService) RemoveClient(clientID uuid.UUID) {
 	}
 }
 
-// SendNotificationToUser gửi notification đến một user cụ thể
+// SendNotificationToUser gửi notification đến một user cụ thể.
+// Mọi notification đều đi qua đây nên mute và cài đặt kênh của người nhận được áp dụng tại đây.
+// Notification được lưu khi bật in-app hoặc email digest; nếu chỉ bật digest thì bản ghi bị ẩn khỏi danh sách in-app.
 func (s *NotificationService) SendNotificationToUser(userID uuid.UUID, notificationType models.NotificationType, title, message string, data map[string]interface{}) error {
-	// Lưu notification vào database
+	channels, err := s.preferences.ResolveChannels(userID, notificationType, data)
+	if err != nil {
+		return err
+	}
+	if !channels.InApp && !channels.SSE && !channels.EmailDigest {
+		return nil
+	}
+
 	notification := models.Notification{
-		UserID:    userID,
-		Type:      notificationType,
-		Title:     title,
-		Message:   message,
-		IsRead:    false,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		UserID:     userID,
+		Type:       notificationType,
+		Title:      title,
+		Message:    message,
+		IsRead:     false,
+		DigestOnly: !channels.InApp,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
 	}
 
 	if data != nil {
@@ -92,34 +109,194 @@ func (s *NotificationService) SendNotificationToUser(userID uuid.UUID, notificat
 		notification.Data = string(dataJSON)
 	}
 
-	if err := config.DB.Create(&notification).Error; err != nil {
-		return err
+	// Lưu notification vào database
+	if channels.stored() {
+		if err := config.DB.Create(&notification).Error; err != nil {
+			return err
+		}
+	} else {
+		notification.ID = uuid.New()
 	}
 
 	// Gửi notification qua SSE
-	s.sendToUser(userID, notification)
+	if channels.SSE {
+		s.sendToUser(userID, notification)
+	}
 	return nil
 }
 
-// SendNotificationToFollowers gửi notification đến tất cả followers
+// SendNotificationToFollowers gửi notification đến tất cả followers (chạy nền qua job queue)
 func (s *NotificationService) SendNotificationToFollowers(userID uuid.UUID, notificationType models.NotificationType, title, message string, data map[string]interface{}) error {
-	// Lấy danh sách followers
-	var followers []models.User
-	if err := config.DB.Model(&models.User{}).
-		Joins("JOIN follows ON users.id = follows.follower_id").
-		Where("follows.following_id = ?", userID).
-		Find(&followers).Error; err != nil {
+	return s.enqueueFanout(notificationFanoutPayload{
+		Audience:   fanoutAudienceFollowers,
+		SubjectID:  userID,
+		ExcludeIDs: []uuid.UUID{userID},
+		Type:       notificationType,
+		Title:      title,
+		Message:    message,
+		Data:       data,
+	})
+}
+
+// SendNotificationToUsers gửi notification đến nhiều user, bỏ trùng và bỏ qua những user trong excludeIDs
+// (thường là người thực hiện hành động, để không ai nhận thông báo về hành động của chính mình)
+func (s *NotificationService) SendNotificationToUsers(userIDs []uuid.UUID, excludeIDs []uuid.UUID, notificationType models.NotificationType, title, message string, data map[string]interface{}) error {
+	skip := make(map[uuid.UUID]bool, len(excludeIDs))
+	for _, id := range excludeIDs {
+		skip[id] = true
+	}
+
+	for _, userID := range userIDs {
+		if skip[userID] {
+			continue
+		}
+		skip[userID] = true
+		if err := s.SendNotificationToUser(userID, notificationType, title, message, data); err != nil {
+			log.Printf("Error sending notification to user %s: %v", userID, err)
+		}
+	}
+
+	return nil
+}
+
+// SendNotificationToWatchers gửi notification đến những người đang theo dõi câu hỏi, trừ excludeIDs (chạy nền)
+func (s *NotificationService) SendNotificationToWatchers(questionID uuid.UUID, excludeIDs []uuid.UUID, notificationType models.NotificationType, title, message string, data map[string]interface{}) error {
+	return s.enqueueFanout(notificationFanoutPayload{
+		Audience:   fanoutAudienceWatchers,
+		SubjectID:  questionID,
+		ExcludeIDs: excludeIDs,
+		Type:       notificationType,
+		Title:      title,
+		Message:    message,
+		Data:       data,
+	})
+}
+
+// SendNotificationToBookmarkers gửi notification đến những người đã bookmark câu hỏi mà không theo dõi nó,
+// trừ excludeIDs (chạy nền)
+func (s *NotificationService) SendNotificationToBookmarkers(questionID uuid.UUID, excludeIDs []uuid.UUID, notificationType models.NotificationType, title, message string, data map[string]interface{}) error {
+	return s.enqueueFanout(notificationFanoutPayload{
+		Audience:   fanoutAudienceBookmarkers,
+		SubjectID:  questionID,
+		ExcludeIDs: excludeIDs,
+		Type:       notificationType,
+		Title:      title,
+		Message:    message,
+		Data:       data,
+	})
+}
+
+// SendAggregatedNotificationToUser gộp các sự kiện cùng groupKey vào một notification chưa đọc.
+// build nhận tổng số sự kiện đã gộp để dựng title/message (vd: "câu trả lời của bạn nhận được 5 upvote").
+// actorID là người gây ra sự kiện này: nếu người nhận đã mute họ thì sự kiện không được cộng vào notification gộp.
+func (s *NotificationService) SendAggregatedNotificationToUser(userID, actorID uuid.UUID, groupKey string, notificationType models.NotificationType, data map[string]interface{}, build func(count int) (string, string)) error {
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+
+	// actor_id chỉ dùng để kiểm tra mute, không lưu vào notification vì mỗi sự kiện gộp có một người khác nhau
+	subjects := map[string]interface{}{"actor_id": actorID}
+	for key, value := range data {
+		subjects[key] = value
+	}
+	channels, err := s.preferences.ResolveChannels(userID, notificationType, subjects)
+	if err != nil {
+		return err
+	}
+	if !channels.stored() {
+		// Notification gộp cần lưu DB để cộng dồn; chỉ gửi SSE nếu user tắt cả in-app và email digest
+		if channels.SSE {
+			title, message := build(1)
+			s.sendToUser(userID, models.Notification{
+				ID:        uuid.New(),
+				UserID:    userID,
+				Type:      notificationType,
+				Title:     title,
+				Message:   message,
+				CreatedAt: time.Now(),
+			})
+		}
+		return nil
+	}
+
+	var notification models.Notification
+	err = config.DB.Where("user_id = ? AND group_key = ? AND is_read = ? AND digest_only = ?", userID, groupKey, false, !channels.InApp).
+		Order("created_at DESC").
+		First(&notification).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		// Chưa có notification chưa đọc nào, tạo mới
+		data["count"] = 1
+		title, message := build(1)
+		notification = models.Notification{
+			UserID:     userID,
+			Type:       notificationType,
+			GroupKey:   groupKey,
+			Title:      title,
+			Message:    message,
+			DigestOnly: !channels.InApp,
+		}
+		return s.saveAndPush(&notification, data, channels.SSE)
+	}
+
+	// Cộng dồn vào notification chưa đọc và đưa nó lên đầu danh sách
+	var existing map[string]interface{}
+	count := 1
+	if notification.Data != "" && json.Unmarshal([]byte(notification.Data), &existing) == nil {
+		if previous, ok := existing["count"].(float64); ok {
+			count = int(previous) + 1
+		}
+	}
+
+	data["count"] = count
+	notification.Title, notification.Message = build(count)
+	return s.saveAndPush(&notification, data, channels.SSE)
+}
+
+// saveAndPush lưu (tạo mới hoặc cập nhật) notification rồi gửi qua SSE nếu được bật
+func (s *NotificationService) saveAndPush(notification *models.Notification, data map[string]interface{}, push bool) error {
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
 		return err
 	}
 
-	// Gửi notification đến từng follower
-	for _, follower := range followers {
-		s.SendNotificationToUser(follower.ID, notificationType, title, message, data)
+	now := time.Now()
+	notification.Data = string(dataJSON)
+	notification.CreatedAt = now
+	notification.UpdatedAt = now
+
+	if err := config.DB.Save(notification).Error; err != nil {
+		return err
 	}
 
+	if push {
+		s.sendToUser(notification.UserID, *notification)
+	}
 	return nil
 }
 
+// SendNotificationToTagFollowers gửi notification đến người follow các tag (chạy nền).
+// Bỏ qua tác giả và những người đã follow tác giả (họ đã nhận thông báo câu hỏi mới).
+func (s *NotificationService) SendNotificationToTagFollowers(tagIDs []uuid.UUID, authorID uuid.UUID, notificationType models.NotificationType, title, message string, data map[string]interface{}) error {
+	if len(tagIDs) == 0 {
+		return nil
+	}
+
+	return s.enqueueFanout(notificationFanoutPayload{
+		Audience:   fanoutAudienceTagFollowers,
+		SubjectID:  authorID,
+		TagIDs:     tagIDs,
+		ExcludeIDs: []uuid.UUID{authorID},
+		Type:       notificationType,
+		Title:      title,
+		Message:    message,
+		Data:       data,
+	})
+}
+
 // sendToUser gửi notification qua SSE đến user
 func (s *NotificationService) sendToUser(userID uuid.UUID, notification models.Notification) {
 	s.mutex.RLock()
@@ -197,41 +374,39 @@ func (s *NotificationService) SSEHandler(c *gin.Context) {
 }
 
 // GetUserNotifications lấy danh sách notifications của user
-func (s *NotificationService) GetUserNotifications(userID uuid.UUID, page, limit int) ([]models.Notification, int64, error) {
+func (s *NotificationService) GetUserNotifications(userID uuid.UUID, p Pagination) ([]models.Notification, int64, string, error) {
 	var notifications []models.Notification
-	var total int64
 
 	// Get total count
-	if err := config.DB.Model(&models.Notification{}).
-		Where("user_id = ?", userID).
-		Count(&total).Error; err != nil {
-		return nil, 0, err
+	total, err := p.Count(config.DB.Model(&models.Notification{}).Where("user_id = ? AND digest_only = ?", userID, false))
+	if err != nil {
+		return nil, 0, "", err
 	}
 
 	// Get notifications with pagination
-	offset := (page - 1) * limit
-	if err := config.DB.Where("user_id = ?", userID).
-		Order("created_at DESC").
-		Offset(offset).
-		Limit(limit).
+	if err := p.Apply(config.DB.Where("user_id = ? AND digest_only = ?", userID, false), "created_at", "id").
 		Find(&notifications).Error; err != nil {
-		return nil, 0, err
+		return nil, 0, "", err
 	}
 
-	return notifications, total, nil
+	notifications, nextCursor := trimPage(notifications, p.Limit, func(n models.Notification) (time.Time, uuid.UUID) {
+		return n.CreatedAt, n.ID
+	})
+	return notifications, total, nextCursor, nil
 }
 
 // MarkNotificationAsRead đánh dấu notification đã đọc
 func (s *NotificationService) MarkNotificationAsRead(notificationID, userID uuid.UUID) error {
 	return config.DB.Model(&models.Notification{}).
-		Where("id = ? AND user_id = ?", notificationID, userID).
+		Where("id = ? AND user_id = ? AND digest_only = ?", notificationID, userID, false).
 		Update("is_read", true).Error
 }
 
-// MarkAllNotificationsAsRead đánh dấu tất cả notifications đã đọc
+// MarkAllNotificationsAsRead đánh dấu tất cả notifications đã đọc.
+// Bản ghi chỉ dành cho digest không bị đụng tới để vẫn được gửi trong email digest kế tiếp.
 func (s *NotificationService) MarkAllNotificationsAsRead(userID uuid.UUID) error {
 	return config.DB.Model(&models.Notification{}).
-		Where("user_id = ?", userID).
+		Where("user_id = ? AND digest_only = ?", userID, false).
 		Update("is_read", true).Error
 }
 
@@ -239,13 +414,13 @@ func (s *NotificationService) MarkAllNotificationsAsRead(userID uuid.UUID) error
 func (s *NotificationService) GetUnreadCount(userID uuid.UUID) (int64, error) {
 	var count int64
 	err := config.DB.Model(&models.Notification{}).
-		Where("user_id = ? AND is_read = ?", userID, false).
+		Where("user_id = ? AND is_read = ? AND digest_only = ?", userID, false, false).
 		Count(&count).Error
 	return count, err
 }
 
 // DeleteNotification xóa notification
 func (s *NotificationService) DeleteNotification(notificationID, userID uuid.UUID) error {
-	return config.DB.Where("id = ? AND user_id = ?", notificationID, userID).
+	return config.DB.Where("id = ? AND user_id = ? AND digest_only = ?", notificationID, userID, false).
 		Delete(&models.Notification{}).Error
 }
@@ -0,0 +1,507 @@
+package services
+
+import (
+    "context"
+    "crypto/rand"
+    "encoding/base64"
+    "errors"
+    "fmt"
+    "log"
+    "regexp"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/go-sql-driver/mysql"
+    "github.com/golang-jwt/jwt/v5"
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+    "vietick/config"
+    "vietick/internal/models"
+    "vietick/pkg/oauth"
+)
+
+const (
+    oauthStateTTL  = 10 * time.Minute
+    oauthSignupTTL = 15 * time.Minute
+    oauthReauthTTL = 5 * time.Minute
+
+    oauthPurposeLogin  = "login"
+    oauthPurposeLink   = "link"
+    oauthPurposeSignup = "signup"
+    oauthPurposeReauth = "reauth"       // state của lần đăng nhập lại tại provider
+    oauthPurposeGrant  = "reauth_grant" // token chứng minh user vừa đăng nhập lại
+)
+
+var (
+    ErrUnknownProvider       = errors.New("unknown identity provider")
+    ErrInvalidOAuthState     = errors.New("invalid or expired oauth state")
+    ErrInvalidSignupToken    = errors.New("invalid or expired signup token")
+    ErrOAuthEmailTaken       = errors.New("an account with this email already exists; log in with your password and link the provider from account settings")
+    ErrOAuthEmailMissing     = errors.New("identity provider did not return an email address")
+    ErrIdentityAlreadyLinked = errors.New("this external account is already linked to another user")
+    ErrProviderAlreadyLinked = errors.New("a different account from this provider is already linked")
+    ErrCannotUnlinkIdentity  = errors.New("cannot unlink the only login method; set a password first")
+    ErrIdentityNotLinked     = errors.New("this external account is not linked to your account")
+    ErrInvalidReauthToken    = errors.New("invalid or expired reauth token")
+)
+
+var (
+    oauthProviders     map[string]oauth.Provider
+    oauthProvidersOnce sync.Once
+)
+
+// OAuthProviders trả về các provider được cấu hình, khởi tạo lần đầu khi env đã được nạp
+func OAuthProviders() map[string]oauth.Provider {
+    oauthProvidersOnce.Do(func() {
+        oauthProviders = oauth.NewProvidersFromEnv()
+    })
+    return oauthProviders
+}
+
+var usernameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+type OAuthService struct {
+    providers map[string]oauth.Provider
+}
+
+type OAuthCallbackRequest struct {
+    Code  string `json:"code" binding:"required"`
+    State string `json:"state" binding:"required"`
+}
+
+type OAuthSignupRequest struct {
+    SignupToken string `json:"signup_token" binding:"required"`
+    Username    string `json:"username" binding:"required,min=3,max=20"`
+}
+
+// OAuthAuthorization là URL client cần chuyển user tới, kèm state để client tự đối chiếu khi provider redirect về
+type OAuthAuthorization struct {
+    URL   string `json:"url"`
+    State string `json:"state"`
+}
+
+// OAuthLoginResponse là kết quả đăng nhập qua provider: có token (hoặc challenge 2FA) nếu đã có tài khoản,
+// hoặc signup_token nếu user cần chọn username để tạo tài khoản mới
+type OAuthLoginResponse struct {
+    LoginResponse
+    SignupRequired    bool   `json:"signup_required"`
+    SignupToken       string `json:"signup_token,omitempty"`
+    SuggestedUsername string `json:"suggested_username,omitempty"`
+    Email             string `json:"email,omitempty"`
+}
+
+// OAuthReauthResponse là token ngắn hạn thay cho mật khẩu khi tài khoản không có mật khẩu
+// xác nhận thao tác nhạy cảm (xóa tài khoản, đổi email)
+type OAuthReauthResponse struct {
+    ReauthToken string `json:"reauth_token"`
+    ExpiresIn   int64  `json:"expires_in"`
+}
+
+// oauthClaims dùng chung cho state (login/link) và signup token, phân biệt bằng Purpose
+type oauthClaims struct {
+    Purpose  string          `json:"purpose"`
+    Provider string          `json:"provider"`
+    Nonce    string          `json:"nonce,omitempty"`
+    UserID   *uuid.UUID      `json:"uid,omitempty"` // User đang liên kết/đăng nhập lại (purpose = link, reauth, reauth_grant)
+    Identity *oauth.Identity `json:"identity,omitempty"`
+    jwt.RegisteredClaims
+}
+
+func NewOAuthService() *OAuthService {
+    return &OAuthService{
+        providers: OAuthProviders(),
+    }
+}
+
+// Providers trả về tên các provider đang bật
+func (s *OAuthService) Providers() []string {
+    names := make([]string, 0, len(s.providers))
+    for name := range s.providers {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+// AuthorizationURL tạo URL đăng nhập tại provider
+func (s *OAuthService) AuthorizationURL(ctx context.Context, provider string) (*OAuthAuthorization, error) {
+    return s.authorize(ctx, provider, oauthPurposeLogin, nil)
+}
+
+// LinkAuthorizationURL tạo URL để user đang đăng nhập liên kết thêm một provider
+func (s *OAuthService) LinkAuthorizationURL(ctx context.Context, userID uuid.UUID, provider string) (*OAuthAuthorization, error) {
+    return s.authorize(ctx, provider, oauthPurposeLink, &userID)
+}
+
+// ReauthAuthorizationURL tạo URL để user đang đăng nhập xác thực lại với provider đã liên kết
+func (s *OAuthService) ReauthAuthorizationURL(ctx context.Context, userID uuid.UUID, provider string) (*OAuthAuthorization, error) {
+    return s.authorize(ctx, provider, oauthPurposeReauth, &userID)
+}
+
+func (s *OAuthService) authorize(ctx context.Context, provider, purpose string, userID *uuid.UUID) (*OAuthAuthorization, error) {
+    p, ok := s.providers[provider]
+    if !ok {
+        return nil, ErrUnknownProvider
+    }
+
+    nonce, err := randomToken()
+    if err != nil {
+        return nil, err
+    }
+    state, err := signOAuthToken(oauthClaims{Purpose: purpose, Provider: provider, Nonce: nonce, UserID: userID}, oauthStateTTL)
+    if err != nil {
+        return nil, err
+    }
+
+    authURL, err := p.AuthCodeURL(ctx, state, nonce, oauthRedirectURI(provider))
+    if err != nil {
+        return nil, err
+    }
+    return &OAuthAuthorization{URL: authURL, State: state}, nil
+}
+
+// Login hoàn tất đăng nhập bằng code provider trả về. Danh tính đã liên kết thì đăng nhập ngay;
+// email đã xác minh ở cả hai phía thì tự liên kết với tài khoản có sẵn; còn lại yêu cầu tạo tài khoản.
+func (s *OAuthService) Login(ctx context.Context, provider string, req OAuthCallbackRequest) (*OAuthLoginResponse, error) {
+    identity, _, err := s.exchange(ctx, provider, oauthPurposeLogin, req)
+    if err != nil {
+        return nil, err
+    }
+
+    var linked models.UserIdentity
+    err = config.DB.Where("provider = ? AND subject = ?", identity.Provider, identity.Subject).First(&linked).Error
+    if err == nil {
+        return oauthLoginResponse(linked.UserID)
+    }
+    if !errors.Is(err, gorm.ErrRecordNotFound) {
+        return nil, err
+    }
+
+    if identity.Email == "" {
+        return nil, ErrOAuthEmailMissing
+    }
+
+    var existing models.User
+    err = config.DB.Where("email = ?", identity.Email).First(&existing).Error
+    if err == nil {
+        // Chỉ tự liên kết khi cả provider và tài khoản đều đã xác minh email, tránh chiếm tài khoản
+        // bằng cách đăng ký trước một email chưa xác minh
+        if !identity.EmailVerified || existing.EmailVerifiedAt == nil {
+            return nil, ErrOAuthEmailTaken
+        }
+        if err := linkIdentity(config.DB, existing.ID, identity); err != nil {
+            return nil, err
+        }
+        return oauthLoginResponse(existing.ID)
+    }
+    if !errors.Is(err, gorm.ErrRecordNotFound) {
+        return nil, err
+    }
+
+    signupToken, err := signOAuthToken(oauthClaims{Purpose: oauthPurposeSignup, Provider: provider, Identity: identity}, oauthSignupTTL)
+    if err != nil {
+        return nil, err
+    }
+    return &OAuthLoginResponse{
+        SignupRequired:    true,
+        SignupToken:       signupToken,
+        SuggestedUsername: suggestUsername(identity),
+        Email:             identity.Email,
+    }, nil
+}
+
+// CompleteSignup tạo tài khoản cho lần đăng nhập đầu tiên bằng provider, với username user đã chọn
+func (s *OAuthService) CompleteSignup(req OAuthSignupRequest) (*OAuthLoginResponse, error) {
+    claims, err := parseOAuthToken(req.SignupToken, oauthPurposeSignup)
+    if err != nil || claims.Identity == nil {
+        return nil, ErrInvalidSignupToken
+    }
+    identity := claims.Identity
+
+    var existingUser models.User
+    if err := config.DB.Where("email = ?", identity.Email).First(&existingUser).Error; err == nil {
+        return nil, errors.New("email already exists")
+    }
+    if err := config.DB.Where("username = ?", req.Username).First(&existingUser).Error; err == nil {
+        return nil, errors.New("username already exists")
+    }
+
+    // Không có mật khẩu: chỉ đăng nhập được qua provider cho tới khi đặt mật khẩu bằng forgot-password
+    now := time.Now()
+    user := models.User{
+        Email:       identity.Email,
+        Username:    req.Username,
+        DisplayName: truncateRunes(identity.Name, 50),
+        CreatedAt:   now,
+        UpdatedAt:   now,
+    }
+    if identity.EmailVerified {
+        user.EmailVerifiedAt = &now
+    }
+    if len(identity.AvatarURL) <= 500 {
+        user.AvatarURL = identity.AvatarURL
+    }
+
+    err = config.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Create(&user).Error; err != nil {
+            return err
+        }
+        return linkIdentity(tx, user.ID, identity)
+    })
+    if err != nil {
+        var mysqlErr *mysql.MySQLError
+        if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+            return nil, errors.New("username or email already exists")
+        }
+        return nil, err
+    }
+    log.Printf("User registered via %s with ID: %s", identity.Provider, user.ID)
+
+    if user.EmailVerifiedAt == nil {
+        if err := NewAuthService().SendVerificationEmail(user.ID); err != nil {
+            log.Printf("Error sending verification email: %v", err)
+        }
+    }
+
+    return oauthLoginResponse(user.ID)
+}
+
+// LinkIdentity liên kết provider vào tài khoản đang đăng nhập
+func (s *OAuthService) LinkIdentity(ctx context.Context, userID uuid.UUID, provider string, req OAuthCallbackRequest) (*models.UserIdentity, error) {
+    identity, claims, err := s.exchange(ctx, provider, oauthPurposeLink, req)
+    if err != nil {
+        return nil, err
+    }
+    if claims.UserID == nil || *claims.UserID != userID {
+        return nil, ErrInvalidOAuthState
+    }
+
+    if err := linkIdentity(config.DB, userID, identity); err != nil {
+        return nil, err
+    }
+
+    var linked models.UserIdentity
+    if err := config.DB.Where("user_id = ? AND provider = ?", userID, provider).First(&linked).Error; err != nil {
+        return nil, err
+    }
+    return &linked, nil
+}
+
+// Reauthenticate đổi code của lần đăng nhập lại lấy reauth token. Danh tính provider trả về
+// phải là danh tính đã liên kết với chính tài khoản này.
+func (s *OAuthService) Reauthenticate(ctx context.Context, userID uuid.UUID, provider string, req OAuthCallbackRequest) (*OAuthReauthResponse, error) {
+    identity, claims, err := s.exchange(ctx, provider, oauthPurposeReauth, req)
+    if err != nil {
+        return nil, err
+    }
+    if claims.UserID == nil || *claims.UserID != userID {
+        return nil, ErrInvalidOAuthState
+    }
+
+    var count int64
+    if err := config.DB.Model(&models.UserIdentity{}).
+        Where("user_id = ? AND provider = ? AND subject = ?", userID, provider, identity.Subject).
+        Count(&count).Error; err != nil {
+        return nil, err
+    }
+    if count == 0 {
+        return nil, ErrIdentityNotLinked
+    }
+
+    token, err := signOAuthToken(oauthClaims{Purpose: oauthPurposeGrant, Provider: provider, UserID: &userID}, oauthReauthTTL)
+    if err != nil {
+        return nil, err
+    }
+    return &OAuthReauthResponse{ReauthToken: token, ExpiresIn: int64(oauthReauthTTL.Seconds())}, nil
+}
+
+// verifyReauthToken kiểm tra reauth token còn hạn và được cấp cho userID
+func verifyReauthToken(token string, userID uuid.UUID) error {
+    claims, err := parseOAuthToken(token, oauthPurposeGrant)
+    if err != nil || claims.UserID == nil || *claims.UserID != userID {
+        return ErrInvalidReauthToken
+    }
+    return nil
+}
+
+// GetIdentities liệt kê các provider đã liên kết với tài khoản
+func (s *OAuthService) GetIdentities(userID uuid.UUID) ([]models.UserIdentity, error) {
+    var identities []models.UserIdentity
+    if err := config.DB.Where("user_id = ?", userID).Order("created_at").Find(&identities).Error; err != nil {
+        return nil, err
+    }
+    return identities, nil
+}
+
+// UnlinkIdentity gỡ liên kết provider; không cho gỡ nếu đó là cách đăng nhập duy nhất còn lại
+func (s *OAuthService) UnlinkIdentity(userID uuid.UUID, provider string) error {
+    var user models.User
+    if err := config.DB.First(&user, "id = ?", userID).Error; err != nil {
+        return ErrUserNotFound
+    }
+
+    return config.DB.Transaction(func(tx *gorm.DB) error {
+        var count int64
+        if err := tx.Model(&models.UserIdentity{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+            return err
+        }
+        if user.Password == "" && count <= 1 {
+            return ErrCannotUnlinkIdentity
+        }
+
+        result := tx.Where("user_id = ? AND provider = ?", userID, provider).Delete(&models.UserIdentity{})
+        if result.Error != nil {
+            return result.Error
+        }
+        if result.RowsAffected == 0 {
+            return errors.New("identity not found")
+        }
+        return nil
+    })
+}
+
+// exchange kiểm tra state rồi đổi code lấy danh tính từ provider
+func (s *OAuthService) exchange(ctx context.Context, provider, purpose string, req OAuthCallbackRequest) (*oauth.Identity, *oauthClaims, error) {
+    p, ok := s.providers[provider]
+    if !ok {
+        return nil, nil, ErrUnknownProvider
+    }
+
+    claims, err := parseOAuthToken(req.State, purpose)
+    if err != nil || claims.Provider != provider {
+        return nil, nil, ErrInvalidOAuthState
+    }
+
+    identity, err := p.Exchange(ctx, req.Code, claims.Nonce, oauthRedirectURI(provider))
+    if err != nil {
+        log.Printf("OAuth exchange with %s failed: %v", provider, err)
+        return nil, nil, err
+    }
+    return identity, claims, nil
+}
+
+// linkIdentity lưu liên kết; danh tính đã thuộc user khác hoặc user đã liên kết tài khoản khác cùng provider thì báo lỗi
+func linkIdentity(tx *gorm.DB, userID uuid.UUID, identity *oauth.Identity) error {
+    var existing models.UserIdentity
+    err := tx.Where("provider = ? AND (subject = ? OR user_id = ?)", identity.Provider, identity.Subject, userID).
+        First(&existing).Error
+    if err == nil {
+        switch {
+        case existing.UserID != userID:
+            return ErrIdentityAlreadyLinked
+        case existing.Subject != identity.Subject:
+            return ErrProviderAlreadyLinked
+        default:
+            return nil
+        }
+    }
+    if !errors.Is(err, gorm.ErrRecordNotFound) {
+        return err
+    }
+
+    now := time.Now()
+    if err := tx.Create(&models.UserIdentity{
+        UserID:    userID,
+        Provider:  identity.Provider,
+        Subject:   identity.Subject,
+        Email:     identity.Email,
+        CreatedAt: now,
+        UpdatedAt: now,
+    }).Error; err != nil {
+        var mysqlErr *mysql.MySQLError
+        if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+            return ErrIdentityAlreadyLinked
+        }
+        return err
+    }
+    return nil
+}
+
+// oauthLoginResponse hoàn tất đăng nhập giống đăng nhập bằng mật khẩu, kể cả bước 2FA
+func oauthLoginResponse(userID uuid.UUID) (*OAuthLoginResponse, error) {
+    var user models.User
+    if err := config.DB.First(&user, "id = ?", userID).Error; err != nil {
+        return nil, ErrUserNotFound
+    }
+    login, err := completeLogin(user)
+    if err != nil {
+        return nil, err
+    }
+    return &OAuthLoginResponse{LoginResponse: *login}, nil
+}
+
+// suggestUsername gợi ý username từ username/email phía provider, thêm số nếu đã có người dùng
+func suggestUsername(identity *oauth.Identity) string {
+    base := identity.Username
+    if base == "" {
+        base, _, _ = strings.Cut(identity.Email, "@")
+    }
+    base = truncateRunes(usernameInvalidChars.ReplaceAllString(base, ""), 16)
+    for len(base) < 3 {
+        base += "_"
+    }
+
+    candidate := base
+    for i := 1; i <= 20; i++ {
+        var count int64
+        if err := config.DB.Model(&models.User{}).Where("username = ?", candidate).Count(&count).Error; err != nil || count == 0 {
+            return candidate
+        }
+        candidate = fmt.Sprintf("%s%d", base, i)
+    }
+    return candidate
+}
+
+// oauthRedirectURI là trang callback của client (phải khai báo với provider); client gửi code và state về API
+func oauthRedirectURI(provider string) string {
+    return appBaseURL() + "/oauth/callback/" + provider
+}
+
+func signOAuthToken(claims oauthClaims, ttl time.Duration) (string, error) {
+    claims.RegisteredClaims = jwt.RegisteredClaims{
+        ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+    }
+    key, err := oauthSigningKey(claims.Purpose)
+    if err != nil {
+        return "", err
+    }
+    return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+}
+
+func parseOAuthToken(token, purpose string) (*oauthClaims, error) {
+    claims := &oauthClaims{}
+    _, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+        return oauthSigningKey(purpose)
+    }, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+    if err != nil {
+        return nil, err
+    }
+    if claims.Purpose != purpose || claims.ExpiresAt == nil {
+        return nil, ErrInvalidOAuthState
+    }
+    return claims, nil
+}
+
+// oauthSigningKey tách khóa theo mục đích, để state/signup token không bao giờ dùng được
+// như JWT đăng nhập (khi JWT đăng nhập cũng là HS256) và ngược lại
+func oauthSigningKey(purpose string) ([]byte, error) {
+    return derivedKey("oauth:" + purpose)
+}
+
+func randomToken() (string, error) {
+    raw := make([]byte, 32)
+    if _, err := rand.Read(raw); err != nil {
+        return "", err
+    }
+    return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func truncateRunes(s string, max int) string {
+    runes := []rune(s)
+    if len(runes) <= max {
+        return s
+    }
+    return string(runes[:max])
+}
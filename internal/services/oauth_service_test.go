@@ -0,0 +1,513 @@
+package services
+
+import (
+    "context"
+    "crypto/rand"
+    "crypto/rsa"
+    "encoding/json"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/golang-jwt/jwt/v5"
+    "github.com/google/uuid"
+    "vietick/config"
+    "vietick/internal/models"
+    "vietick/pkg/oauth"
+    "vietick/pkg/utils"
+)
+
+const (
+    testOIDCProvider     = "mock"
+    testOIDCClientID     = "vietick-test"
+    testOIDCClientSecret = "test-secret"
+    testOIDCKeyID        = "test-key"
+)
+
+// mockLogin là user đăng nhập ở provider giả lập, được cấp cho một authorization code
+type mockLogin struct {
+    Subject       string
+    Email         string
+    EmailVerified bool
+    Nonce         string // Rỗng: dùng nonce trong URL authorize
+    EmailOnlyInfo bool   // Email không có trong ID token, chỉ trả ở userinfo endpoint
+}
+
+// mockOIDCServer là OpenID provider cục bộ: discovery, JWKS, token và userinfo endpoint
+type mockOIDCServer struct {
+    *httptest.Server
+    key *rsa.PrivateKey
+
+    mu           sync.Mutex
+    codes        map[string]mockLogin
+    accessTokens map[string]mockLogin
+}
+
+func newMockOIDCServer(t *testing.T) *mockOIDCServer {
+    t.Helper()
+    key, err := rsa.GenerateKey(rand.Reader, 2048)
+    if err != nil {
+        t.Fatalf("generate key: %v", err)
+    }
+    s := &mockOIDCServer{key: key, codes: map[string]mockLogin{}, accessTokens: map[string]mockLogin{}}
+
+    mux := http.NewServeMux()
+    mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+        writeTestJSON(w, http.StatusOK, map[string]string{
+            "issuer":                 s.URL,
+            "authorization_endpoint": s.URL + "/authorize",
+            "token_endpoint":         s.URL + "/token",
+            "userinfo_endpoint":      s.URL + "/userinfo",
+            "jwks_uri":               s.URL + "/jwks",
+        })
+    })
+    mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+        jwk, err := oauth.NewJSONWebKey(testOIDCKeyID, "RS256", &s.key.PublicKey)
+        if err != nil {
+            writeTestJSON(w, http.StatusInternalServerError, nil)
+            return
+        }
+        writeTestJSON(w, http.StatusOK, oauth.JSONWebKeySet{Keys: []oauth.JSONWebKey{jwk}})
+    })
+    mux.HandleFunc("/token", s.handleToken)
+    mux.HandleFunc("/userinfo", s.handleUserinfo)
+
+    s.Server = httptest.NewServer(mux)
+    t.Cleanup(s.Close)
+    return s
+}
+
+func (s *mockOIDCServer) handleToken(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost || r.ParseForm() != nil ||
+        r.PostForm.Get("grant_type") != "authorization_code" ||
+        r.PostForm.Get("client_id") != testOIDCClientID ||
+        r.PostForm.Get("client_secret") != testOIDCClientSecret {
+        writeTestJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_client"})
+        return
+    }
+
+    code := r.PostForm.Get("code")
+    s.mu.Lock()
+    login, ok := s.codes[code]
+    delete(s.codes, code) // Code chỉ dùng được một lần
+    s.mu.Unlock()
+    if !ok {
+        writeTestJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+        return
+    }
+
+    now := time.Now()
+    claims := jwt.MapClaims{
+        "iss":            s.URL,
+        "aud":            testOIDCClientID,
+        "sub":            login.Subject,
+        "iat":            now.Unix(),
+        "exp":            now.Add(5 * time.Minute).Unix(),
+        "nonce":          login.Nonce,
+        "email_verified": login.EmailVerified,
+    }
+    if !login.EmailOnlyInfo {
+        claims["email"] = login.Email
+    }
+    token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+    token.Header["kid"] = testOIDCKeyID
+    idToken, err := token.SignedString(s.key)
+    if err != nil {
+        writeTestJSON(w, http.StatusInternalServerError, nil)
+        return
+    }
+
+    accessToken := "access-" + code
+    s.mu.Lock()
+    s.accessTokens[accessToken] = login
+    s.mu.Unlock()
+    writeTestJSON(w, http.StatusOK, map[string]string{
+        "access_token": accessToken,
+        "token_type":   "Bearer",
+        "id_token":     idToken,
+    })
+}
+
+func (s *mockOIDCServer) handleUserinfo(w http.ResponseWriter, r *http.Request) {
+    s.mu.Lock()
+    login, ok := s.accessTokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+    s.mu.Unlock()
+    if !ok {
+        writeTestJSON(w, http.StatusUnauthorized, nil)
+        return
+    }
+    writeTestJSON(w, http.StatusOK, map[string]interface{}{
+        "sub":            login.Subject,
+        "email":          login.Email,
+        "email_verified": login.EmailVerified,
+    })
+}
+
+// authorize mô phỏng user đăng nhập ở provider: lấy state và nonce từ URL authorize rồi cấp code cho login
+func (s *mockOIDCServer) authorize(t *testing.T, svc *OAuthService, login mockLogin) OAuthCallbackRequest {
+    t.Helper()
+    auth, err := svc.AuthorizationURL(context.Background(), testOIDCProvider)
+    if err != nil {
+        t.Fatalf("AuthorizationURL: %v", err)
+    }
+    return s.callback(t, auth, login)
+}
+
+// callback cấp code cho một URL authorize bất kỳ (login, link, reauth)
+func (s *mockOIDCServer) callback(t *testing.T, auth *OAuthAuthorization, login mockLogin) OAuthCallbackRequest {
+    t.Helper()
+    authURL, err := url.Parse(auth.URL)
+    if err != nil {
+        t.Fatalf("parse authorization URL: %v", err)
+    }
+    query := authURL.Query()
+    if authURL.Path != "/authorize" || query.Get("state") != auth.State || query.Get("client_id") != testOIDCClientID {
+        t.Fatalf("unexpected authorization URL %s", auth.URL)
+    }
+    if query.Get("nonce") == "" {
+        t.Fatalf("authorization URL has no nonce: %s", auth.URL)
+    }
+    if login.Nonce == "" {
+        login.Nonce = query.Get("nonce")
+    }
+    return OAuthCallbackRequest{Code: s.issueCode(login), State: auth.State}
+}
+
+func (s *mockOIDCServer) issueCode(login mockLogin) string {
+    code := uuid.NewString()
+    s.mu.Lock()
+    s.codes[code] = login
+    s.mu.Unlock()
+    return code
+}
+
+func writeTestJSON(w http.ResponseWriter, status int, body interface{}) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    json.NewEncoder(w).Encode(body)
+}
+
+func setupOAuthTest(t *testing.T) (*OAuthService, *mockOIDCServer) {
+    t.Helper()
+    t.Setenv("JWT_SECRET", "test-jwt-secret")
+    t.Setenv("JWT_ALGORITHM", "HS256")
+    t.Setenv("EMAIL_TOKEN_SECRET", "test-email-secret")
+    t.Setenv("APP_BASE_URL", "http://vietick.test")
+    useTestDB(t)
+
+    server := newMockOIDCServer(t)
+    svc := &OAuthService{providers: map[string]oauth.Provider{
+        testOIDCProvider: &oauth.OIDCProvider{
+            ProviderName: testOIDCProvider,
+            IssuerURL:    server.URL,
+            ClientID:     testOIDCClientID,
+            ClientSecret: testOIDCClientSecret,
+            Scopes:       []string{"openid", "email", "profile"},
+        },
+    }}
+    return svc, server
+}
+
+func createTestUser(t *testing.T, email string, emailVerified bool) models.User {
+    t.Helper()
+    now := time.Now()
+    user := models.User{
+        Email:     email,
+        Username:  strings.Split(email, "@")[0],
+        Password:  "not-a-real-hash",
+        CreatedAt: now,
+        UpdatedAt: now,
+    }
+    if emailVerified {
+        user.EmailVerifiedAt = &now
+    }
+    if err := config.DB.Create(&user).Error; err != nil {
+        t.Fatalf("create user: %v", err)
+    }
+    return user
+}
+
+// assertLoggedInAs kiểm tra response có JWT đăng nhập của đúng user
+func assertLoggedInAs(t *testing.T, resp *OAuthLoginResponse, userID uuid.UUID) {
+    t.Helper()
+    if resp.SignupRequired || resp.Token == "" {
+        t.Fatalf("expected a login token, got %+v", resp)
+    }
+    claims, err := utils.ParseToken(resp.Token)
+    if err != nil {
+        t.Fatalf("parse login token: %v", err)
+    }
+    if claims.UserID != userID {
+        t.Fatalf("logged in as %s, want %s", claims.UserID, userID)
+    }
+}
+
+func countIdentities(t *testing.T, userID uuid.UUID) int64 {
+    t.Helper()
+    var count int64
+    if err := config.DB.Model(&models.UserIdentity{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+        t.Fatalf("count identities: %v", err)
+    }
+    return count
+}
+
+func TestOAuthLoginLinkedIdentity(t *testing.T) {
+    svc, server := setupOAuthTest(t)
+    user := createTestUser(t, "linked@example.com", true)
+    if err := linkIdentity(config.DB, user.ID, &oauth.Identity{Provider: testOIDCProvider, Subject: "sub-linked"}); err != nil {
+        t.Fatalf("link identity: %v", err)
+    }
+
+    // Email phía provider khác email tài khoản: danh tính đã liên kết vẫn đăng nhập theo subject
+    req := server.authorize(t, svc, mockLogin{Subject: "sub-linked", Email: "other@example.com", EmailVerified: true})
+    resp, err := svc.Login(context.Background(), testOIDCProvider, req)
+    if err != nil {
+        t.Fatalf("Login: %v", err)
+    }
+    assertLoggedInAs(t, resp, user.ID)
+
+    // Code đã dùng thì provider từ chối
+    if _, err := svc.Login(context.Background(), testOIDCProvider, req); !errors.Is(err, oauth.ErrProviderResponse) {
+        t.Fatalf("reusing code: got %v, want ErrProviderResponse", err)
+    }
+}
+
+func TestOAuthLoginAutoLinksOnlyVerifiedEmails(t *testing.T) {
+    tests := []struct {
+        name             string
+        accountVerified  bool
+        providerVerified bool
+        wantLinked       bool
+    }{
+        {name: "both verified", accountVerified: true, providerVerified: true, wantLinked: true},
+        {name: "provider email unverified", accountVerified: true, providerVerified: false},
+        {name: "account email unverified", accountVerified: false, providerVerified: true},
+        {name: "neither verified", accountVerified: false, providerVerified: false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            svc, server := setupOAuthTest(t)
+            user := createTestUser(t, "existing@example.com", tt.accountVerified)
+
+            req := server.authorize(t, svc, mockLogin{Subject: "sub-new", Email: "existing@example.com", EmailVerified: tt.providerVerified})
+            resp, err := svc.Login(context.Background(), testOIDCProvider, req)
+
+            if tt.wantLinked {
+                if err != nil {
+                    t.Fatalf("Login: %v", err)
+                }
+                assertLoggedInAs(t, resp, user.ID)
+                if got := countIdentities(t, user.ID); got != 1 {
+                    t.Fatalf("identities = %d, want 1", got)
+                }
+                return
+            }
+            if !errors.Is(err, ErrOAuthEmailTaken) {
+                t.Fatalf("Login: got %v, want ErrOAuthEmailTaken", err)
+            }
+            if got := countIdentities(t, user.ID); got != 0 {
+                t.Fatalf("identities = %d, want 0", got)
+            }
+        })
+    }
+}
+
+func TestOAuthLoginReadsEmailFromUserinfo(t *testing.T) {
+    svc, server := setupOAuthTest(t)
+    user := createTestUser(t, "info@example.com", true)
+
+    req := server.authorize(t, svc, mockLogin{Subject: "sub-info", Email: "info@example.com", EmailVerified: true, EmailOnlyInfo: true})
+    resp, err := svc.Login(context.Background(), testOIDCProvider, req)
+    if err != nil {
+        t.Fatalf("Login: %v", err)
+    }
+    assertLoggedInAs(t, resp, user.ID)
+}
+
+func TestOAuthSignupTokenRoundTrip(t *testing.T) {
+    svc, server := setupOAuthTest(t)
+
+    req := server.authorize(t, svc, mockLogin{Subject: "sub-signup", Email: "new.user@example.com", EmailVerified: true})
+    resp, err := svc.Login(context.Background(), testOIDCProvider, req)
+    if err != nil {
+        t.Fatalf("Login: %v", err)
+    }
+    if !resp.SignupRequired || resp.SignupToken == "" || resp.Token != "" {
+        t.Fatalf("expected signup to be required, got %+v", resp)
+    }
+    if resp.Email != "new.user@example.com" || resp.SuggestedUsername != "new.user" {
+        t.Fatalf("email = %q, suggested username = %q", resp.Email, resp.SuggestedUsername)
+    }
+
+    // Signup token bị sửa, hoặc state dùng thay signup token, đều bị từ chối
+    tampered := resp.SignupToken[:len(resp.SignupToken)-2] + "xx"
+    for _, token := range []string{tampered, req.State} {
+        if _, err := svc.CompleteSignup(OAuthSignupRequest{SignupToken: token, Username: "newuser"}); !errors.Is(err, ErrInvalidSignupToken) {
+            t.Fatalf("CompleteSignup with %q: got %v, want ErrInvalidSignupToken", token, err)
+        }
+    }
+
+    signup, err := svc.CompleteSignup(OAuthSignupRequest{SignupToken: resp.SignupToken, Username: "newuser"})
+    if err != nil {
+        t.Fatalf("CompleteSignup: %v", err)
+    }
+    var user models.User
+    if err := config.DB.Where("username = ?", "newuser").First(&user).Error; err != nil {
+        t.Fatalf("load created user: %v", err)
+    }
+    if user.Email != "new.user@example.com" || user.EmailVerifiedAt == nil || user.Password != "" {
+        t.Fatalf("unexpected user %+v", user)
+    }
+    assertLoggedInAs(t, signup, user.ID)
+
+    // Đăng nhập lại bằng provider vào đúng tài khoản vừa tạo
+    again, err := svc.Login(context.Background(), testOIDCProvider, server.authorize(t, svc, mockLogin{Subject: "sub-signup", Email: "new.user@example.com", EmailVerified: true}))
+    if err != nil {
+        t.Fatalf("Login after signup: %v", err)
+    }
+    assertLoggedInAs(t, again, user.ID)
+
+    // Signup token chỉ tạo được một tài khoản
+    if _, err := svc.CompleteSignup(OAuthSignupRequest{SignupToken: resp.SignupToken, Username: "another"}); err == nil {
+        t.Fatal("reusing signup token created a second account")
+    }
+}
+
+func TestOAuthLoginRejectsInvalidStateAndNonce(t *testing.T) {
+    svc, server := setupOAuthTest(t)
+    createTestUser(t, "victim@example.com", true)
+    login := mockLogin{Subject: "sub-state", Email: "victim@example.com", EmailVerified: true}
+
+    expired, err := signOAuthToken(oauthClaims{Purpose: oauthPurposeLogin, Provider: testOIDCProvider, Nonce: "n"}, -time.Minute)
+    if err != nil {
+        t.Fatalf("sign state: %v", err)
+    }
+    linkState, err := signOAuthToken(oauthClaims{Purpose: oauthPurposeLink, Provider: testOIDCProvider, Nonce: "n"}, oauthStateTTL)
+    if err != nil {
+        t.Fatalf("sign state: %v", err)
+    }
+    otherProvider, err := signOAuthToken(oauthClaims{Purpose: oauthPurposeLogin, Provider: "github", Nonce: "n"}, oauthStateTTL)
+    if err != nil {
+        t.Fatalf("sign state: %v", err)
+    }
+    valid := server.authorize(t, svc, login)
+    // Đổi một ký tự giữa chữ ký: ký tự cuối còn bit thừa của base64 nên đổi nó có thể không làm hỏng chữ ký
+    tampered := []byte(valid.State)
+    i := len(tampered) - 10
+    if tampered[i] == 'A' {
+        tampered[i] = 'B'
+    } else {
+        tampered[i] = 'A'
+    }
+
+    states := map[string]string{
+        "tampered":       string(tampered),
+        "expired":        expired,
+        "link purpose":   linkState,
+        "other provider": otherProvider,
+        "garbage":        "not-a-state",
+    }
+    for name, state := range states {
+        t.Run("state "+name, func(t *testing.T) {
+            req := OAuthCallbackRequest{Code: server.issueCode(login), State: state}
+            if _, err := svc.Login(context.Background(), testOIDCProvider, req); !errors.Is(err, ErrInvalidOAuthState) {
+                t.Fatalf("Login: got %v, want ErrInvalidOAuthState", err)
+            }
+        })
+    }
+
+    t.Run("nonce mismatch", func(t *testing.T) {
+        login := login
+        login.Nonce = "nonce-from-another-session"
+        req := server.authorize(t, svc, login)
+        _, err := svc.Login(context.Background(), testOIDCProvider, req)
+        if !errors.Is(err, oauth.ErrProviderResponse) || !strings.Contains(err.Error(), "nonce") {
+            t.Fatalf("Login: got %v, want nonce mismatch", err)
+        }
+    })
+
+    t.Run("unknown provider", func(t *testing.T) {
+        if _, err := svc.Login(context.Background(), "unknown", valid); !errors.Is(err, ErrUnknownProvider) {
+            t.Fatalf("Login: got %v, want ErrUnknownProvider", err)
+        }
+    })
+
+    // Không lần thử nào ở trên tạo liên kết
+    var count int64
+    config.DB.Model(&models.UserIdentity{}).Count(&count)
+    if count != 0 {
+        t.Fatalf("identities = %d, want 0", count)
+    }
+}
+
+func TestOAuthReauthConfirmsPasswordlessAccount(t *testing.T) {
+    svc, server := setupOAuthTest(t)
+    user := createTestUser(t, "oauth-only@example.com", true)
+    if err := config.DB.Model(&user).Update("password", "").Error; err != nil {
+        t.Fatalf("clear password: %v", err)
+    }
+    other := createTestUser(t, "other@example.com", true)
+    if err := linkIdentity(config.DB, user.ID, &oauth.Identity{Provider: testOIDCProvider, Subject: "sub-owner"}); err != nil {
+        t.Fatalf("link identity: %v", err)
+    }
+    users := NewUserService()
+
+    reauth := func(t *testing.T, userID uuid.UUID, subject string) (*OAuthReauthResponse, error) {
+        t.Helper()
+        auth, err := svc.ReauthAuthorizationURL(context.Background(), userID, testOIDCProvider)
+        if err != nil {
+            t.Fatalf("ReauthAuthorizationURL: %v", err)
+        }
+        req := server.callback(t, auth, mockLogin{Subject: subject, Email: "oauth-only@example.com", EmailVerified: true})
+        return svc.Reauthenticate(context.Background(), userID, testOIDCProvider, req)
+    }
+
+    if _, err := users.confirmIdentity(user.ID, "", "", ""); !errors.Is(err, ErrReauthRequired) {
+        t.Fatalf("no credentials: got %v, want ErrReauthRequired", err)
+    }
+    if _, err := users.confirmIdentity(user.ID, "", "123456", ""); !errors.Is(err, ErrTwoFactorNotEnabled) {
+        t.Fatalf("code without 2FA: got %v, want ErrTwoFactorNotEnabled", err)
+    }
+
+    // Đăng nhập lại bằng tài khoản provider khác với tài khoản đã liên kết thì không được cấp token
+    if _, err := reauth(t, user.ID, "sub-stranger"); !errors.Is(err, ErrIdentityNotLinked) {
+        t.Fatalf("reauth with unlinked subject: got %v, want ErrIdentityNotLinked", err)
+    }
+
+    // State đăng nhập thông thường không dùng được để lấy reauth token
+    if _, err := svc.Reauthenticate(context.Background(), user.ID, testOIDCProvider,
+        server.authorize(t, svc, mockLogin{Subject: "sub-owner", EmailVerified: true})); !errors.Is(err, ErrInvalidOAuthState) {
+        t.Fatalf("reauth with login state: got %v, want ErrInvalidOAuthState", err)
+    }
+
+    resp, err := reauth(t, user.ID, "sub-owner")
+    if err != nil {
+        t.Fatalf("Reauthenticate: %v", err)
+    }
+    if _, err := users.confirmIdentity(user.ID, "", "", resp.ReauthToken); err != nil {
+        t.Fatalf("confirm with reauth token: %v", err)
+    }
+    if _, err := users.confirmIdentity(user.ID, "", "", "not-a-token"); !errors.Is(err, ErrInvalidReauthToken) {
+        t.Fatalf("garbage reauth token: got %v, want ErrInvalidReauthToken", err)
+    }
+
+    // Token cấp cho user này không xác nhận được user khác; user có mật khẩu vẫn phải nhập mật khẩu
+    if err := config.DB.Model(&other).Update("password", "").Error; err != nil {
+        t.Fatalf("clear password: %v", err)
+    }
+    if _, err := users.confirmIdentity(other.ID, "", "", resp.ReauthToken); !errors.Is(err, ErrInvalidReauthToken) {
+        t.Fatalf("reauth token of another user: got %v, want ErrInvalidReauthToken", err)
+    }
+    if err := config.DB.Model(&user).Update("password", "not-a-real-hash").Error; err != nil {
+        t.Fatalf("set password: %v", err)
+    }
+    if _, err := users.confirmIdentity(user.ID, "", "", resp.ReauthToken); !errors.Is(err, ErrIncorrectPassword) {
+        t.Fatalf("account with password: got %v, want ErrIncorrectPassword", err)
+    }
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+    "encoding/base64"
+    "encoding/json"
+    "errors"
+    "time"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+)
+
+const (
+    DefaultPageLimit = 10
+    MaxPageLimit     = 100
+)
+
+// ErrInvalidCursor được trả về khi cursor client gửi lên không giải mã được
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor là vị trí keyset (created_at, id) của phần tử cuối cùng đã trả về
+type Cursor struct {
+    CreatedAt time.Time `json:"t"`
+    ID        uuid.UUID `json:"id"`
+}
+
+// Pagination chứa tham số phân trang, hỗ trợ cả page/limit cũ và cursor
+type Pagination struct {
+    Page   int
+    Limit  int
+    Cursor *Cursor
+}
+
+// EncodeCursor mã hóa cursor thành chuỗi opaque để trả về client
+func EncodeCursor(createdAt time.Time, id uuid.UUID) string {
+    data, _ := json.Marshal(Cursor{CreatedAt: createdAt, ID: id})
+    return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor giải mã cursor do client gửi lên
+func DecodeCursor(value string) (*Cursor, error) {
+    data, err := base64.RawURLEncoding.DecodeString(value)
+    if err != nil {
+        return nil, ErrInvalidCursor
+    }
+
+    var cursor Cursor
+    if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == uuid.Nil || cursor.CreatedAt.IsZero() {
+        return nil, ErrInvalidCursor
+    }
+    return &cursor, nil
+}
+
+// NewPagination chuẩn hóa page/limit và giải mã cursor nếu có
+func NewPagination(page, limit int, cursor string, defaultLimit int) (Pagination, error) {
+    if page < 1 {
+        page = 1
+    }
+    if limit <= 0 {
+        limit = defaultLimit
+    }
+    if limit > MaxPageLimit {
+        limit = MaxPageLimit
+    }
+
+    p := Pagination{Page: page, Limit: limit}
+    if cursor != "" {
+        decoded, err := DecodeCursor(cursor)
+        if err != nil {
+            return p, err
+        }
+        p.Cursor = decoded
+    }
+    return p, nil
+}
+
+// UsesCursor cho biết request đang dùng cursor thay vì page
+func (p Pagination) UsesCursor() bool {
+    return p.Cursor != nil
+}
+
+// Offset trả về offset cho chế độ page/limit
+func (p Pagination) Offset() int {
+    return (p.Page - 1) * p.Limit
+}
+
+// Count đếm tổng số bản ghi, chỉ chạy ở chế độ page/limit vì cursor không cần total
+func (p Pagination) Count(db *gorm.DB) (int64, error) {
+    var total int64
+    if p.Cursor != nil {
+        return 0, nil
+    }
+    err := db.Count(&total).Error
+    return total, err
+}
+
+// Apply thêm điều kiện keyset (hoặc offset), sắp xếp và limit vào query.
+// Lấy dư một bản ghi để biết còn trang tiếp theo hay không.
+func (p Pagination) Apply(db *gorm.DB, createdAtColumn, idColumn string) *gorm.DB {
+    if p.Cursor != nil {
+        db = db.Where("("+createdAtColumn+" < ? OR ("+createdAtColumn+" = ? AND "+idColumn+" < ?))",
+            p.Cursor.CreatedAt, p.Cursor.CreatedAt, p.Cursor.ID)
+    } else {
+        db = db.Offset(p.Offset())
+    }
+
+    return db.Order(createdAtColumn + " DESC").
+        Order(idColumn + " DESC").
+        Limit(p.Limit + 1)
+}
+
+// trimPage cắt bản ghi dư và tạo next_cursor từ phần tử cuối cùng
+func trimPage[T any](items []T, limit int, key func(T) (time.Time, uuid.UUID)) ([]T, string) {
+    if len(items) <= limit {
+        return items, ""
+    }
+
+    items = items[:limit]
+    createdAt, id := key(items[len(items)-1])
+    return items, EncodeCursor(createdAt, id)
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+    "crypto/rand"
+    "encoding/base64"
+    "errors"
+    "log"
+    "strings"
+    "time"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+    "vietick/config"
+    "vietick/internal/models"
+)
+
+const (
+    // PersonalTokenPrefix phân biệt personal access token với JWT trong header Authorization
+    PersonalTokenPrefix = "vtk_"
+
+    maxPersonalTokensPerUser = 50
+    personalTokenPrefixLen   = 12              // Số ký tự đầu lưu lại để hiển thị, gồm cả "vtk_"
+    tokenLastUsedResolution  = 5 * time.Minute // Không ghi last_used_at mỗi request
+)
+
+var (
+    ErrInvalidTokenScope = errors.New("invalid token scope")
+    ErrTokenNotFound     = errors.New("token not found")
+    ErrTooManyTokens     = errors.New("too many personal access tokens, revoke unused ones first")
+    ErrInvalidAPIToken   = errors.New("invalid or expired personal access token")
+)
+
+type PersonalTokenService struct{}
+
+type CreatePersonalTokenRequest struct {
+    Name          string   `json:"name" binding:"required,max=100"`
+    Scopes        []string `json:"scopes" binding:"required,min=1"`
+    ExpiresInDays *int     `json:"expires_in_days" binding:"omitempty,min=1,max=3650"` // Bỏ trống: không hết hạn
+}
+
+type PersonalTokenResponse struct {
+    ID         uuid.UUID           `json:"id"`
+    Name       string              `json:"name"`
+    Prefix     string              `json:"prefix"`
+    Scopes     []models.TokenScope `json:"scopes"`
+    ExpiresAt  *time.Time          `json:"expires_at"`
+    LastUsedAt *time.Time          `json:"last_used_at"`
+    LastUsedIP string              `json:"last_used_ip,omitempty"`
+    CreatedAt  time.Time           `json:"created_at"`
+}
+
+// CreatedPersonalTokenResponse kèm token gốc, chỉ trả về một lần lúc tạo
+type CreatedPersonalTokenResponse struct {
+    PersonalTokenResponse
+    Token string `json:"token"`
+}
+
+func NewPersonalTokenService() *PersonalTokenService {
+    return &PersonalTokenService{}
+}
+
+// CreateToken tạo personal access token mới với các scope được chọn
+func (s *PersonalTokenService) CreateToken(userID uuid.UUID, req CreatePersonalTokenRequest) (*CreatedPersonalTokenResponse, error) {
+    scopes, err := normalizeTokenScopes(req.Scopes)
+    if err != nil {
+        return nil, err
+    }
+
+    var count int64
+    if err := config.DB.Model(&models.PersonalAccessToken{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+        return nil, err
+    }
+    if count >= maxPersonalTokensPerUser {
+        return nil, ErrTooManyTokens
+    }
+
+    raw := make([]byte, 32)
+    if _, err := rand.Read(raw); err != nil {
+        return nil, err
+    }
+    token := PersonalTokenPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+    now := time.Now()
+    pat := models.PersonalAccessToken{
+        UserID:    userID,
+        Name:      strings.TrimSpace(req.Name),
+        TokenHash: hashUserToken(token),
+        Prefix:    token[:personalTokenPrefixLen],
+        Scopes:    strings.Join(scopes, " "),
+        CreatedAt: now,
+    }
+    if req.ExpiresInDays != nil {
+        expiresAt := now.Add(time.Duration(*req.ExpiresInDays) * 24 * time.Hour)
+        pat.ExpiresAt = &expiresAt
+    }
+
+    if err := config.DB.Create(&pat).Error; err != nil {
+        return nil, err
+    }
+
+    return &CreatedPersonalTokenResponse{PersonalTokenResponse: toPersonalTokenResponse(pat), Token: token}, nil
+}
+
+// GetTokens liệt kê token của user (không có token gốc)
+func (s *PersonalTokenService) GetTokens(userID uuid.UUID) ([]PersonalTokenResponse, error) {
+    var tokens []models.PersonalAccessToken
+    if err := config.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&tokens).Error; err != nil {
+        return nil, err
+    }
+
+    responses := make([]PersonalTokenResponse, 0, len(tokens))
+    for _, token := range tokens {
+        responses = append(responses, toPersonalTokenResponse(token))
+    }
+    return responses, nil
+}
+
+// RevokeToken thu hồi token, request sau đó dùng token này bị từ chối ngay
+func (s *PersonalTokenService) RevokeToken(userID, tokenID uuid.UUID) error {
+    result := config.DB.Where("id = ? AND user_id = ?", tokenID, userID).Delete(&models.PersonalAccessToken{})
+    if result.Error != nil {
+        return result.Error
+    }
+    if result.RowsAffected == 0 {
+        return ErrTokenNotFound
+    }
+    return nil
+}
+
+// Authenticate tìm token theo hash, kiểm tra hạn và ghi lại lần dùng gần nhất
+func (s *PersonalTokenService) Authenticate(token, clientIP string) (*models.PersonalAccessToken, error) {
+    var pat models.PersonalAccessToken
+    if err := config.DB.Where("token_hash = ?", hashUserToken(token)).First(&pat).Error; err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            return nil, ErrInvalidAPIToken
+        }
+        return nil, err
+    }
+
+    now := time.Now()
+    if pat.ExpiresAt != nil && !now.Before(*pat.ExpiresAt) {
+        return nil, ErrInvalidAPIToken
+    }
+
+    if pat.LastUsedAt == nil || now.Sub(*pat.LastUsedAt) >= tokenLastUsedResolution || pat.LastUsedIP != clientIP {
+        if err := config.DB.Model(&models.PersonalAccessToken{}).Where("id = ?", pat.ID).
+            Updates(map[string]interface{}{"last_used_at": now, "last_used_ip": clientIP}).Error; err != nil {
+            log.Printf("Error updating personal access token %s last use: %v", pat.ID, err)
+        }
+    }
+
+    return &pat, nil
+}
+
+// normalizeTokenScopes kiểm tra scope hợp lệ, bỏ trùng và sắp theo thứ tự của models.TokenScopes
+func normalizeTokenScopes(requested []string) ([]string, error) {
+    wanted := make(map[models.TokenScope]bool, len(requested))
+    for _, scope := range requested {
+        scope = strings.TrimSpace(scope)
+        valid := false
+        for _, known := range models.TokenScopes {
+            if models.TokenScope(scope) == known {
+                valid = true
+                break
+            }
+        }
+        if !valid {
+            return nil, ErrInvalidTokenScope
+        }
+        wanted[models.TokenScope(scope)] = true
+    }
+
+    scopes := make([]string, 0, len(wanted))
+    for _, known := range models.TokenScopes {
+        if wanted[known] {
+            scopes = append(scopes, string(known))
+        }
+    }
+    return scopes, nil
+}
+
+func toPersonalTokenResponse(token models.PersonalAccessToken) PersonalTokenResponse {
+    return PersonalTokenResponse{
+        ID:         token.ID,
+        Name:       token.Name,
+        Prefix:     token.Prefix,
+        Scopes:     token.ScopeList(),
+        ExpiresAt:  token.ExpiresAt,
+        LastUsedAt: token.LastUsedAt,
+        LastUsedIP: token.LastUsedIP,
+        CreatedAt:  token.CreatedAt,
+    }
+}
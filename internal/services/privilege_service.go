@@ -0,0 +1,139 @@
+package services
+
+import (
+    "errors"
+    "fmt"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+    "vietick/config"
+    "vietick/internal/models"
+)
+
+// Privilege là một quyền mở khóa theo reputation
+type Privilege string
+
+const (
+    PrivilegeComment      Privilege = "comment"
+    PrivilegeVoteDown     Privilege = "vote_down"
+    PrivilegeCreateTag    Privilege = "create_tag"
+    PrivilegeVerifyAnswer Privilege = "verify_answer"
+    PrivilegeEditTag      Privilege = "edit_tag"
+    PrivilegeEditOthers   Privilege = "edit_others_posts"
+    PrivilegeVoteClose    Privilege = "vote_close"
+)
+
+// PrivilegeThreshold là reputation tối thiểu để có một quyền
+type PrivilegeThreshold struct {
+    Privilege     Privilege `json:"privilege"`
+    Action        string    `json:"action"` // Mô tả ngắn, dùng trong thông báo lỗi
+    MinReputation int64     `json:"min_reputation"`
+}
+
+// privilegeThresholds xếp theo reputation tăng dần. comment và vote_close chưa có tính năng tương ứng,
+// ngưỡng được định nghĩa sẵn để tính năng sau này dùng requirePrivilege.
+var privilegeThresholds = []PrivilegeThreshold{
+    {Privilege: PrivilegeComment, Action: "comment", MinReputation: 50},
+    {Privilege: PrivilegeVoteDown, Action: "vote down", MinReputation: 125},
+    {Privilege: PrivilegeCreateTag, Action: "create new tags", MinReputation: 300},
+    {Privilege: PrivilegeVerifyAnswer, Action: "verify answers on other users' questions", MinReputation: 1000},
+    {Privilege: PrivilegeEditTag, Action: "edit or delete tags", MinReputation: 1500},
+    {Privilege: PrivilegeEditOthers, Action: "edit other users' posts", MinReputation: 2000},
+    {Privilege: PrivilegeVoteClose, Action: "vote to close questions", MinReputation: 3000},
+}
+
+// PrivilegeError là lỗi thiếu reputation cho một thao tác; controller trả 403
+type PrivilegeError struct {
+    PrivilegeThreshold
+    Reputation int64
+}
+
+func (e *PrivilegeError) Error() string {
+    return fmt.Sprintf("you need at least %d reputation to %s (you have %d)", e.MinReputation, e.Action, e.Reputation)
+}
+
+// PrivilegeService cho biết user có những quyền nào
+type PrivilegeService struct{}
+
+// UserPrivilege là một quyền kèm trạng thái của user
+type UserPrivilege struct {
+    PrivilegeThreshold
+    Granted bool `json:"granted"`
+}
+
+// UserPrivileges là reputation của user và các quyền đã/chưa có
+type UserPrivileges struct {
+    Reputation int64           `json:"reputation"`
+    Role       models.UserRole `json:"role"`
+    Privileges []UserPrivilege `json:"privileges"`
+}
+
+func NewPrivilegeService() *PrivilegeService {
+    return &PrivilegeService{}
+}
+
+// GetPrivileges liệt kê mọi quyền và reputation cần có
+func (s *PrivilegeService) GetPrivileges() []PrivilegeThreshold {
+    return privilegeThresholds
+}
+
+// GetUserPrivileges liệt kê quyền của user; moderator/admin có mọi quyền
+func (s *PrivilegeService) GetUserPrivileges(userID uuid.UUID) (*UserPrivileges, error) {
+    user, err := privilegeUser(userID)
+    if err != nil {
+        return nil, err
+    }
+
+    result := &UserPrivileges{
+        Reputation: user.Point,
+        Role:       user.Role,
+        Privileges: make([]UserPrivilege, 0, len(privilegeThresholds)),
+    }
+    for _, threshold := range privilegeThresholds {
+        result.Privileges = append(result.Privileges, UserPrivilege{
+            PrivilegeThreshold: threshold,
+            Granted:            hasPrivilege(user, threshold),
+        })
+    }
+    return result, nil
+}
+
+// requirePrivilege trả *PrivilegeError nếu user chưa đủ reputation cho quyền này
+func requirePrivilege(userID uuid.UUID, privilege Privilege) error {
+    threshold, ok := findPrivilege(privilege)
+    if !ok {
+        return fmt.Errorf("unknown privilege %q", privilege)
+    }
+    user, err := privilegeUser(userID)
+    if err != nil {
+        return err
+    }
+    if !hasPrivilege(user, threshold) {
+        return &PrivilegeError{PrivilegeThreshold: threshold, Reputation: user.Point}
+    }
+    return nil
+}
+
+func hasPrivilege(user *models.User, threshold PrivilegeThreshold) bool {
+    return user.Role == models.RoleModerator || user.Role == models.RoleAdmin || user.Point >= threshold.MinReputation
+}
+
+func findPrivilege(privilege Privilege) (PrivilegeThreshold, bool) {
+    for _, threshold := range privilegeThresholds {
+        if threshold.Privilege == privilege {
+            return threshold, true
+        }
+    }
+    return PrivilegeThreshold{}, false
+}
+
+func privilegeUser(userID uuid.UUID) (*models.User, error) {
+    var user models.User
+    if err := config.DB.Select("id", "point", "role").First(&user, "id = ?", userID).Error; err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            return nil, ErrUserNotFound
+        }
+        return nil, err
+    }
+    return &user, nil
+}
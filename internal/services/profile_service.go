@@ -0,0 +1,314 @@
+package services
+
+import (
+    "errors"
+    "sort"
+    "time"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+    "vietick/config"
+    "vietick/internal/models"
+)
+
+const (
+    ActivityQuestion = "question"
+    ActivityAnswer   = "answer"
+
+    defaultTopTagsLimit = 10
+
+    // maxActivityDepth giới hạn offset+limit của hoạt động phân trang theo page: mỗi nguồn phải đọc
+    // offset+limit bản ghi rồi trộn lại, nên trang sâu hơn phải dùng cursor
+    maxActivityDepth = 1000
+)
+
+// ErrUserNotFound được trả về khi user của hồ sơ không tồn tại
+var ErrUserNotFound = errors.New("user not found")
+
+// ErrActivityPageTooDeep được trả về khi page của hoạt động vượt quá maxActivityDepth
+var ErrActivityPageTooDeep = errors.New("activity page is too deep, use cursor pagination")
+
+type ProfileService struct {
+    followService *FollowService
+}
+
+// PublicProfile là thông tin công khai của user, không bao gồm email và mật khẩu
+type PublicProfile struct {
+    ID                   uuid.UUID        `json:"id"`
+    Username             string           `json:"username"`
+    DisplayName          string           `json:"display_name"`
+    Bio                  string           `json:"bio"`
+    Location             string           `json:"location"`
+    Website              string           `json:"website"`
+    AvatarURL            string           `json:"avatar_url"`
+    Reputation           int64            `json:"reputation"`
+    EmailVerified        bool             `json:"email_verified"`
+    CreatedAt            time.Time        `json:"created_at"`
+    FollowStats          *UserFollowStats `json:"follow_stats"`
+    QuestionsCount       int64            `json:"questions_count"`
+    AnswersCount         int64            `json:"answers_count"`
+    VerifiedAnswersCount int64            `json:"verified_answers_count"`
+}
+
+// publicUserColumns giới hạn cột khi preload tác giả kèm câu hỏi/câu trả lời,
+// để email và các thông tin riêng không lộ ra ở các route đọc công khai
+func publicUserColumns(db *gorm.DB) *gorm.DB {
+    return db.Select("id", "username", "display_name", "avatar_url", "point", "created_at")
+}
+
+// UserTagStat là một tag user hoạt động nhiều, kèm số câu hỏi đã hỏi hoặc trả lời trong tag đó
+type UserTagStat struct {
+    Tag   models.Tag `json:"tag"`
+    Count int64      `json:"count"`
+}
+
+// ActivityItem là một mục trong dòng hoạt động gần đây của user
+type ActivityItem struct {
+    Type      string           `json:"type"`
+    CreatedAt time.Time        `json:"created_at"`
+    Question  *models.Question `json:"question,omitempty"`
+    Answer    *models.Answer   `json:"answer,omitempty"`
+}
+
+func NewProfileService() *ProfileService {
+    return &ProfileService{
+        followService: NewFollowService(),
+    }
+}
+
+// GetPublicProfile lấy hồ sơ công khai theo ID
+func (s *ProfileService) GetPublicProfile(userID uuid.UUID) (*PublicProfile, error) {
+    var user models.User
+    if err := config.DB.First(&user, "id = ?", userID).Error; err != nil {
+        return nil, ErrUserNotFound
+    }
+    return s.buildProfile(user)
+}
+
+// GetPublicProfileByUsername lấy hồ sơ công khai theo username
+func (s *ProfileService) GetPublicProfileByUsername(username string) (*PublicProfile, error) {
+    var user models.User
+    if err := config.DB.First(&user, "username = ?", username).Error; err != nil {
+        return nil, ErrUserNotFound
+    }
+    return s.buildProfile(user)
+}
+
+func (s *ProfileService) buildProfile(user models.User) (*PublicProfile, error) {
+    followStats, err := s.followService.GetUserFollowStats(user.ID)
+    if err != nil {
+        return nil, err
+    }
+
+    profile := &PublicProfile{
+        ID:            user.ID,
+        Username:      user.Username,
+        DisplayName:   user.DisplayName,
+        Bio:           user.Bio,
+        Location:      user.Location,
+        Website:       user.Website,
+        AvatarURL:     user.AvatarURL,
+        Reputation:    user.Point,
+        EmailVerified: user.EmailVerifiedAt != nil,
+        CreatedAt:     user.CreatedAt,
+        FollowStats:   followStats,
+    }
+
+    if err := config.DB.Model(&models.Question{}).Where("user_id = ?", user.ID).
+        Count(&profile.QuestionsCount).Error; err != nil {
+        return nil, err
+    }
+    if err := config.DB.Model(&models.Answer{}).Where("user_id = ?", user.ID).
+        Count(&profile.AnswersCount).Error; err != nil {
+        return nil, err
+    }
+    if err := config.DB.Model(&models.Answer{}).Where("user_id = ? AND is_verified = ?", user.ID, true).
+        Count(&profile.VerifiedAnswersCount).Error; err != nil {
+        return nil, err
+    }
+
+    return profile, nil
+}
+
+// GetUserQuestions lấy danh sách câu hỏi của user
+func (s *ProfileService) GetUserQuestions(userID uuid.UUID, p Pagination) ([]models.Question, int64, string, error) {
+    if err := ensureUserExists(userID); err != nil {
+        return nil, 0, "", err
+    }
+
+    var questions []models.Question
+
+    // Get total count
+    total, err := p.Count(config.DB.Model(&models.Question{}).Where("user_id = ?", userID))
+    if err != nil {
+        return nil, 0, "", err
+    }
+
+    if err := p.Apply(config.DB.Preload("User", publicUserColumns).Preload("Tags").Where("user_id = ?", userID), "created_at", "id").
+        Find(&questions).Error; err != nil {
+        return nil, 0, "", err
+    }
+
+    questions, nextCursor := trimPage(questions, p.Limit, questionCursorKey)
+    return questions, total, nextCursor, nil
+}
+
+// GetUserAnswers lấy danh sách câu trả lời của user, kèm câu hỏi được trả lời
+func (s *ProfileService) GetUserAnswers(userID uuid.UUID, p Pagination) ([]models.Answer, int64, string, error) {
+    if err := ensureUserExists(userID); err != nil {
+        return nil, 0, "", err
+    }
+
+    var answers []models.Answer
+
+    // Get total count
+    total, err := p.Count(config.DB.Model(&models.Answer{}).Where("user_id = ?", userID))
+    if err != nil {
+        return nil, 0, "", err
+    }
+
+    if err := p.Apply(config.DB.Preload("User", publicUserColumns).Preload("Question").Where("user_id = ?", userID), "created_at", "id").
+        Find(&answers).Error; err != nil {
+        return nil, 0, "", err
+    }
+
+    answers, nextCursor := trimPage(answers, p.Limit, func(a models.Answer) (time.Time, uuid.UUID) {
+        return a.CreatedAt, a.ID
+    })
+    return answers, total, nextCursor, nil
+}
+
+// GetUserTopTags lấy các tag user hoạt động nhiều nhất (tính trên câu hỏi đã hỏi hoặc đã trả lời)
+func (s *ProfileService) GetUserTopTags(userID uuid.UUID, limit int) ([]UserTagStat, error) {
+    if err := ensureUserExists(userID); err != nil {
+        return nil, err
+    }
+    if limit <= 0 || limit > MaxPageLimit {
+        limit = defaultTopTagsLimit
+    }
+
+    var rows []struct {
+        TagID uuid.UUID
+        Total int64
+    }
+    if err := config.DB.Table("question_tags").
+        Select("question_tags.tag_id, COUNT(DISTINCT question_tags.question_id) AS total").
+        Joins("JOIN questions ON questions.id = question_tags.question_id").
+        Where("(questions.user_id = ? OR questions.id IN (SELECT question_id FROM answers WHERE user_id = ?))", userID, userID).
+        Group("question_tags.tag_id").
+        Order("total DESC").
+        Limit(limit).
+        Scan(&rows).Error; err != nil {
+        return nil, err
+    }
+    if len(rows) == 0 {
+        return []UserTagStat{}, nil
+    }
+
+    tagIDs := make([]uuid.UUID, 0, len(rows))
+    for _, row := range rows {
+        tagIDs = append(tagIDs, row.TagID)
+    }
+    var tags []models.Tag
+    if err := config.DB.Where("id IN ?", tagIDs).Find(&tags).Error; err != nil {
+        return nil, err
+    }
+    byID := make(map[uuid.UUID]models.Tag, len(tags))
+    for _, tag := range tags {
+        byID[tag.ID] = tag
+    }
+
+    stats := make([]UserTagStat, 0, len(rows))
+    for _, row := range rows {
+        if tag, ok := byID[row.TagID]; ok {
+            stats = append(stats, UserTagStat{Tag: tag, Count: row.Total})
+        }
+    }
+    return stats, nil
+}
+
+// GetUserActivity lấy hoạt động gần đây (câu hỏi và câu trả lời) của user, mới nhất trước
+func (s *ProfileService) GetUserActivity(userID uuid.UUID, p Pagination) ([]ActivityItem, int64, string, error) {
+    if !p.UsesCursor() && p.Offset()+p.Limit > maxActivityDepth {
+        return nil, 0, "", ErrActivityPageTooDeep
+    }
+    if err := ensureUserExists(userID); err != nil {
+        return nil, 0, "", err
+    }
+
+    var total int64
+    if !p.UsesCursor() {
+        var questionsCount, answersCount int64
+        if err := config.DB.Model(&models.Question{}).Where("user_id = ?", userID).Count(&questionsCount).Error; err != nil {
+            return nil, 0, "", err
+        }
+        if err := config.DB.Model(&models.Answer{}).Where("user_id = ?", userID).Count(&answersCount).Error; err != nil {
+            return nil, 0, "", err
+        }
+        total = questionsCount + answersCount
+    }
+
+    // Mỗi nguồn lấy đủ offset+limit+1 bản ghi đầu tiên rồi trộn lại; ở chế độ cursor offset = 0
+    window := p
+    window.Page = 1
+    if !p.UsesCursor() {
+        window.Limit = p.Offset() + p.Limit
+    }
+
+    var questions []models.Question
+    if err := window.Apply(config.DB.Preload("Tags").Where("user_id = ?", userID), "created_at", "id").
+        Find(&questions).Error; err != nil {
+        return nil, 0, "", err
+    }
+
+    var answers []models.Answer
+    if err := window.Apply(config.DB.Preload("Question").Where("user_id = ?", userID), "created_at", "id").
+        Find(&answers).Error; err != nil {
+        return nil, 0, "", err
+    }
+
+    items := make([]ActivityItem, 0, len(questions)+len(answers))
+    for i := range questions {
+        items = append(items, ActivityItem{Type: ActivityQuestion, CreatedAt: questions[i].CreatedAt, Question: &questions[i]})
+    }
+    for i := range answers {
+        items = append(items, ActivityItem{Type: ActivityAnswer, CreatedAt: answers[i].CreatedAt, Answer: &answers[i]})
+    }
+    sort.Slice(items, func(i, j int) bool {
+        ti, idI := activityCursorKey(items[i])
+        tj, idJ := activityCursorKey(items[j])
+        if !ti.Equal(tj) {
+            return ti.After(tj)
+        }
+        return idI.String() > idJ.String()
+    })
+
+    if !p.UsesCursor() {
+        if p.Offset() >= len(items) {
+            items = items[:0]
+        } else {
+            items = items[p.Offset():]
+        }
+    }
+
+    items, nextCursor := trimPage(items, p.Limit, activityCursorKey)
+    return items, total, nextCursor, nil
+}
+
+func activityCursorKey(item ActivityItem) (time.Time, uuid.UUID) {
+    if item.Question != nil {
+        return item.CreatedAt, item.Question.ID
+    }
+    return item.CreatedAt, item.Answer.ID
+}
+
+func ensureUserExists(userID uuid.UUID) error {
+    var count int64
+    if err := config.DB.Model(&models.User{}).Where("id = ?", userID).Count(&count).Error; err != nil {
+        return err
+    }
+    if count == 0 {
+        return ErrUserNotFound
+    }
+    return nil
+}
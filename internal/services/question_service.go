@@ -2,25 +2,47 @@ package services
 
 import (
     "errors"
+    "log"
     "time"
 
     "github.com/google/uuid"
+    "gorm.io/gorm"
     "vietick/config"
     "vietick/internal/models"
 )
 
 type QuestionService struct{}
 
+// Trọng số và độ suy giảm theo thời gian của điểm trending:
+// score = max(views*w_view + answers*w_answer + net_votes*w_vote, 0) / (tuổi theo giờ + 2)^gravity
+const (
+    trendingWindow       = 7 * 24 * time.Hour // Chỉ xét câu hỏi tạo trong khoảng này
+    trendingViewWeight   = 0.1
+    trendingAnswerWeight = 4.0
+    trendingVoteWeight   = 2.0
+    trendingGravity      = 1.5
+)
+
+// TrendingQuestion là câu hỏi kèm các số liệu dùng để xếp hạng trending
+type TrendingQuestion struct {
+    models.Question
+    AnswerCount int64   `json:"answer_count"`
+    VoteScore   int64   `json:"vote_score"` // Tổng upvote trừ downvote của các câu trả lời
+    Score       float64 `json:"trending_score"`
+}
+
 type CreateQuestionRequest struct {
-    Title   string   `json:"title" binding:"required,min=3"`
-    Content string   `json:"content" binding:"required,min=3"`
-    Tags    []string `json:"tags"` // Array of tag names
+    Title         string   `json:"title" binding:"required,min=3"`
+    Content       string   `json:"content" binding:"required,min=3"`
+    Tags          []string `json:"tags"`           // Array of tag names
+    AttachmentIDs []string `json:"attachment_ids"` // ID các file đã tải lên qua POST /uploads
 }
 
 type UpdateQuestionRequest struct {
-    Title   string   `json:"title" binding:"required,min=10"`
-    Content string   `json:"content" binding:"required,min=20"`
-    Tags    []string `json:"tags"` // Array of tag names
+    Title         string   `json:"title" binding:"required,min=10"`
+    Content       string   `json:"content" binding:"required,min=20"`
+    Tags          []string `json:"tags"`           // Array of tag names
+    AttachmentIDs []string `json:"attachment_ids"` // Không gửi thì giữ nguyên, gửi [] để gỡ hết
 }
 
 func NewQuestionService() *QuestionService {
@@ -29,12 +51,15 @@ func NewQuestionService() *QuestionService {
 
 func (s *QuestionService) CreateQuestion(userID uuid.UUID, req CreateQuestionRequest) (*models.Question, error) {
     now := time.Now()
+    rendered := renderContent(req.Content)
     question := models.Question{
-        Title:     req.Title,
-        Content:   req.Content,
-        UserID:    userID,
-        CreatedAt: now,
-        UpdatedAt: now,
+        Title:       req.Title,
+        Content:     req.Content,
+        ContentHTML: rendered.HTML,
+        ContentText: rendered.Text,
+        UserID:      userID,
+        CreatedAt:   now,
+        UpdatedAt:   now,
     }
 
     // Start transaction
@@ -54,7 +79,7 @@ func (s *QuestionService) CreateQuestion(userID uuid.UUID, req CreateQuestionReq
     // Handle tags if provided
     if len(req.Tags) > 0 {
         tagService := NewTagService()
-        tagIDs, err := tagService.GetOrCreateTags(req.Tags)
+        tagIDs, err := tagService.GetOrCreateTags(userID, req.Tags)
         if err != nil {
             tx.Rollback()
             return nil, err
@@ -81,13 +106,26 @@ func (s *QuestionService) CreateQuestion(userID uuid.UUID, req CreateQuestionReq
         }
     }
 
+    // Attach uploaded files
+    attachments, err := resolveAttachments(tx, userID, req.AttachmentIDs)
+    if err != nil {
+        tx.Rollback()
+        return nil, err
+    }
+    if len(attachments) > 0 {
+        if err := tx.Model(&question).Association("Attachments").Append(attachments); err != nil {
+            tx.Rollback()
+            return nil, err
+        }
+    }
+
     // Commit transaction
     if err := tx.Commit().Error; err != nil {
         return nil, err
     }
 
     // Load question with tags for response
-    if err := config.DB.Preload("Tags").Preload("User").First(&question, question.ID).Error; err != nil {
+    if err := config.DB.Preload("Tags").Preload("User", publicUserColumns).Preload("Attachments").First(&question, question.ID).Error; err != nil {
         return nil, err
     }
 
@@ -105,34 +143,56 @@ func (s *QuestionService) CreateQuestion(userID uuid.UUID, req CreateQuestionReq
         },
     )
 
+    // Gửi notification đến người follow các tag của câu hỏi
+    var tagIDs []uuid.UUID
+    for _, tag := range question.Tags {
+        tagIDs = append(tagIDs, tag.ID)
+    }
+    notificationService.SendNotificationToTagFollowers(
+        tagIDs,
+        userID,
+        models.NotificationTypeTag,
+        "Câu hỏi mới với tag bạn follow",
+        question.User.Username+" vừa đăng câu hỏi: "+question.Title,
+        map[string]interface{}{
+            "question_id": question.ID,
+            "author_id":   question.UserID,
+            "author_name": question.User.Username,
+        },
+    )
+
+    // Tác giả tự động theo dõi câu hỏi của mình
+    if err := ensureWatch(userID, question.ID); err != nil {
+        log.Printf("Failed to auto-watch question %s: %v", question.ID, err)
+    }
+
+    PublishBadgeEvent(BadgeEventQuestionCreated, userID, question.ID)
+
     return &question, nil
 }
 
-func (s *QuestionService) GetQuestions(page, limit int) ([]models.Question, int64, error) {
+func (s *QuestionService) GetQuestions(p Pagination) ([]models.Question, int64, string, error) {
     var questions []models.Question
-    var total int64
 
-    // Get total count
-    if err := config.DB.Model(&models.Question{}).Count(&total).Error; err != nil {
-        return nil, 0, err
+    // Get total count (page mode only)
+    total, err := p.Count(config.DB.Model(&models.Question{}))
+    if err != nil {
+        return nil, 0, "", err
     }
 
     // Get questions with pagination and preload tags
-    offset := (page - 1) * limit
-    if err := config.DB.Preload("User").Preload("Tags").
-        Order("created_at DESC").
-        Offset(offset).
-        Limit(limit).
+    if err := p.Apply(config.DB.Preload("User", publicUserColumns).Preload("Tags"), "created_at", "id").
         Find(&questions).Error; err != nil {
-        return nil, 0, err
+        return nil, 0, "", err
     }
 
-    return questions, total, nil
+    questions, nextCursor := trimPage(questions, p.Limit, questionCursorKey)
+    return questions, total, nextCursor, nil
 }
 
 func (s *QuestionService) GetQuestionByID(questionID uuid.UUID) (*models.Question, error) {
     var question models.Question
-    if err := config.DB.Preload("User").Preload("Tags").First(&question, "id = ?", questionID).Error; err != nil {
+    if err := config.DB.Preload("User", publicUserColumns).Preload("Tags").Preload("Attachments").First(&question, "id = ?", questionID).Error; err != nil {
         return nil, errors.New("question not found")
     }
     return &question, nil
@@ -144,9 +204,11 @@ func (s *QuestionService) UpdateQuestion(questionID, userID uuid.UUID, req Updat
         return nil, errors.New("question not found")
     }
 
-    // Check if user is the owner of the question
+    // Sửa câu hỏi của người khác cần quyền edit_others_posts
     if question.UserID != userID {
-        return nil, errors.New("unauthorized to update this question")
+        if err := requirePrivilege(userID, PrivilegeEditOthers); err != nil {
+            return nil, err
+        }
     }
 
     // Start transaction
@@ -158,11 +220,15 @@ func (s *QuestionService) UpdateQuestion(questionID, userID uuid.UUID, req Updat
     }()
 
     // Update basic fields
+    rendered := renderContent(req.Content)
     question.Title = req.Title
     question.Content = req.Content
+    question.ContentHTML = rendered.HTML
+    question.ContentText = rendered.Text
     question.UpdatedAt = time.Now()
 
-    if err := tx.Save(&question).Error; err != nil {
+    // bookmark_count, view_count được cập nhật riêng bằng biểu thức, không ghi đè bằng giá trị đã đọc
+    if err := tx.Omit("bookmark_count", "view_count").Save(&question).Error; err != nil {
         tx.Rollback()
         return nil, err
     }
@@ -172,9 +238,12 @@ func (s *QuestionService) UpdateQuestion(questionID, userID uuid.UUID, req Updat
     
     // Get current tag IDs
     var currentTagIDs []uuid.UUID
+    currentTags := make(map[uuid.UUID]bool)
     for _, tag := range question.Tags {
         currentTagIDs = append(currentTagIDs, tag.ID)
+        currentTags[tag.ID] = true
     }
+    var addedTagIDs []uuid.UUID
 
     // Decrease usage count for current tags
     if len(currentTagIDs) > 0 {
@@ -192,7 +261,7 @@ func (s *QuestionService) UpdateQuestion(questionID, userID uuid.UUID, req Updat
 
     // Add new tags if provided
     if len(req.Tags) > 0 {
-        newTagIDs, err := tagService.GetOrCreateTags(req.Tags)
+        newTagIDs, err := tagService.GetOrCreateTags(userID, req.Tags)
         if err != nil {
             tx.Rollback()
             return nil, err
@@ -215,6 +284,26 @@ func (s *QuestionService) UpdateQuestion(questionID, userID uuid.UUID, req Updat
                 tx.Rollback()
                 return nil, err
             }
+
+            for _, tagID := range newTagIDs {
+                if !currentTags[tagID] {
+                    addedTagIDs = append(addedTagIDs, tagID)
+                }
+            }
+        }
+    }
+
+    // Replace attachments if provided. File đính kèm thuộc về tác giả câu hỏi, kể cả khi người sửa
+    // là người khác có quyền edit_others_posts, nên kiểm tra theo question.UserID
+    if req.AttachmentIDs != nil {
+        attachments, err := resolveAttachments(tx, question.UserID, req.AttachmentIDs)
+        if err != nil {
+            tx.Rollback()
+            return nil, err
+        }
+        if err := tx.Model(&question).Association("Attachments").Replace(attachments); err != nil {
+            tx.Rollback()
+            return nil, err
         }
     }
 
@@ -224,10 +313,37 @@ func (s *QuestionService) UpdateQuestion(questionID, userID uuid.UUID, req Updat
     }
 
     // Load updated question with tags
-    if err := config.DB.Preload("Tags").Preload("User").First(&question, question.ID).Error; err != nil {
+    if err := config.DB.Preload("Tags").Preload("User", publicUserColumns).Preload("Attachments").First(&question, question.ID).Error; err != nil {
         return nil, err
     }
 
+    notificationService := NewNotificationService()
+    notificationData := map[string]interface{}{
+        "question_id": question.ID,
+        "author_id":   question.UserID,
+        "author_name": question.User.Username,
+    }
+
+    // Thông báo cho người theo dõi câu hỏi về chỉnh sửa
+    notificationService.SendNotificationToWatchers(
+        question.ID,
+        []uuid.UUID{userID},
+        models.NotificationTypeQuestion,
+        "Câu hỏi bạn theo dõi vừa được chỉnh sửa",
+        question.User.Username+" đã chỉnh sửa câu hỏi: "+question.Title,
+        notificationData,
+    )
+
+    // Thông báo cho người follow các tag mới được gắn
+    notificationService.SendNotificationToTagFollowers(
+        addedTagIDs,
+        userID,
+        models.NotificationTypeTag,
+        "Câu hỏi mới với tag bạn follow",
+        question.User.Username+" vừa gắn tag bạn follow cho câu hỏi: "+question.Title,
+        notificationData,
+    )
+
     return &question, nil
 }
 
@@ -256,6 +372,25 @@ func (s *QuestionService) DeleteQuestion(questionID, userID uuid.UUID) error {
         tagIDs = append(tagIDs, tag.ID)
     }
 
+    // A question with an open bounty cannot be deleted once answered, otherwise the answers would be
+    // thrown away and the bounty refunded to the sponsor
+    answers, err := countAnswersLocked(tx, questionID)
+    if err != nil {
+        tx.Rollback()
+        return err
+    }
+    if answers > 0 {
+        var openBounties int64
+        if err := tx.Model(&models.Bounty{}).Where("open_question_id = ?", questionID).Count(&openBounties).Error; err != nil {
+            tx.Rollback()
+            return err
+        }
+        if openBounties > 0 {
+            tx.Rollback()
+            return ErrBountyQuestionAnswered
+        }
+    }
+
     // Delete all related answers first
     if err := tx.Where("question_id = ?", questionID).Delete(&models.Answer{}).Error; err != nil {
         tx.Rollback()
@@ -268,6 +403,12 @@ func (s *QuestionService) DeleteQuestion(questionID, userID uuid.UUID) error {
         return err
     }
 
+    // Refund the open bounty; closed bounty rows are kept for the point audit trail
+    if err := refundOpenBounty(tx, questionID); err != nil {
+        tx.Rollback()
+        return err
+    }
+
     // Delete the question
     if err := tx.Delete(&question).Error; err != nil {
         tx.Rollback()
@@ -292,59 +433,114 @@ func (s *QuestionService) DeleteQuestion(questionID, userID uuid.UUID) error {
 }
 
 // GetQuestionsByTag lấy câu hỏi theo tag
-func (s *QuestionService) GetQuestionsByTag(tagName string, page, limit int) ([]models.Question, int64, error) {
+func (s *QuestionService) GetQuestionsByTag(tagName string, p Pagination) ([]models.Question, int64, string, error) {
     var questions []models.Question
-    var total int64
+
+    byTag := func(db *gorm.DB) *gorm.DB {
+        return db.Joins("JOIN question_tags ON questions.id = question_tags.question_id").
+            Joins("JOIN tags ON question_tags.tag_id = tags.id").
+            Where("LOWER(tags.name) = LOWER(?)", tagName)
+    }
 
     // Get total count
-    if err := config.DB.Model(&models.Question{}).
-        Joins("JOIN question_tags ON questions.id = question_tags.question_id").
-        Joins("JOIN tags ON question_tags.tag_id = tags.id").
-        Where("LOWER(tags.name) = LOWER(?)", tagName).
-        Count(&total).Error; err != nil {
-        return nil, 0, err
+    total, err := p.Count(config.DB.Model(&models.Question{}).Scopes(byTag))
+    if err != nil {
+        return nil, 0, "", err
     }
 
     // Get questions with pagination
-    offset := (page - 1) * limit
-    if err := config.DB.Preload("User").Preload("Tags").
-        Joins("JOIN question_tags ON questions.id = question_tags.question_id").
-        Joins("JOIN tags ON question_tags.tag_id = tags.id").
-        Where("LOWER(tags.name) = LOWER(?)", tagName).
-        Order("questions.created_at DESC").
-        Offset(offset).
-        Limit(limit).
+    if err := p.Apply(config.DB.Preload("User", publicUserColumns).Preload("Tags").Scopes(byTag), "questions.created_at", "questions.id").
         Find(&questions).Error; err != nil {
-        return nil, 0, err
+        return nil, 0, "", err
     }
 
-    return questions, total, nil
+    questions, nextCursor := trimPage(questions, p.Limit, questionCursorKey)
+    return questions, total, nextCursor, nil
 }
 
 // SearchQuestions tìm kiếm câu hỏi theo từ khóa
-func (s *QuestionService) SearchQuestions(query string, page, limit int) ([]models.Question, int64, error) {
+func (s *QuestionService) SearchQuestions(query string, p Pagination) ([]models.Question, int64, string, error) {
     var questions []models.Question
-    var total int64
 
     searchQuery := "%" + query + "%"
+    matches := func(db *gorm.DB) *gorm.DB {
+        return db.Where("(LOWER(title) LIKE LOWER(?) OR LOWER(content_text) LIKE LOWER(?))", searchQuery, searchQuery)
+    }
 
     // Get total count
-    if err := config.DB.Model(&models.Question{}).
-        Where("LOWER(title) LIKE LOWER(?) OR LOWER(content) LIKE LOWER(?)", searchQuery, searchQuery).
-        Count(&total).Error; err != nil {
-        return nil, 0, err
+    total, err := p.Count(config.DB.Model(&models.Question{}).Scopes(matches))
+    if err != nil {
+        return nil, 0, "", err
     }
 
     // Get questions with pagination
-    offset := (page - 1) * limit
-    if err := config.DB.Preload("User").Preload("Tags").
-        Where("LOWER(title) LIKE LOWER(?) OR LOWER(content) LIKE LOWER(?)", searchQuery, searchQuery).
-        Order("created_at DESC").
-        Offset(offset).
-        Limit(limit).
+    if err := p.Apply(config.DB.Preload("User", publicUserColumns).Preload("Tags").Scopes(matches), "created_at", "id").
         Find(&questions).Error; err != nil {
-        return nil, 0, err
+        return nil, 0, "", err
+    }
+
+    questions, nextCursor := trimPage(questions, p.Limit, questionCursorKey)
+    return questions, total, nextCursor, nil
+}
+
+// GetTrendingQuestions xếp hạng câu hỏi gần đây theo lượt xem, số câu trả lời và vote, giảm dần theo tuổi câu hỏi
+func (s *QuestionService) GetTrendingQuestions(p Pagination) ([]TrendingQuestion, error) {
+    stats := config.DB.Model(&models.Question{}).
+        Select("questions.id, questions.view_count, questions.created_at, "+
+            "(SELECT COUNT(*) FROM answers WHERE answers.question_id = questions.id) AS answer_count, "+
+            "(SELECT COALESCE(SUM(CASE votes.type WHEN ? THEN 1 WHEN ? THEN -1 ELSE 0 END), 0) FROM votes "+
+            "JOIN answers ON answers.id = votes.answer_id WHERE answers.question_id = questions.id) AS vote_score",
+            models.UpVote, models.DownVote).
+        Where("questions.created_at >= ?", time.Now().Add(-trendingWindow))
+
+    var ranked []struct {
+        ID          uuid.UUID
+        AnswerCount int64
+        VoteScore   int64
+        Score       float64
+    }
+    if err := config.DB.Table("(?) AS stats", stats).
+        Select("id, answer_count, vote_score, "+
+            "GREATEST(view_count * ? + answer_count * ? + vote_score * ?, 0) / POW(TIMESTAMPDIFF(HOUR, created_at, NOW()) + 2, ?) AS score",
+            trendingViewWeight, trendingAnswerWeight, trendingVoteWeight, trendingGravity).
+        Order("score DESC").Order("created_at DESC").
+        Offset(p.Offset()).Limit(p.Limit).
+        Scan(&ranked).Error; err != nil {
+        return nil, err
+    }
+    if len(ranked) == 0 {
+        return []TrendingQuestion{}, nil
     }
 
-    return questions, total, nil
-} 
\ No newline at end of file
+    ids := make([]uuid.UUID, 0, len(ranked))
+    for _, row := range ranked {
+        ids = append(ids, row.ID)
+    }
+    var questions []models.Question
+    if err := config.DB.Preload("User", publicUserColumns).Preload("Tags").Where("id IN ?", ids).Find(&questions).Error; err != nil {
+        return nil, err
+    }
+    byID := make(map[uuid.UUID]models.Question, len(questions))
+    for _, question := range questions {
+        byID[question.ID] = question
+    }
+
+    trending := make([]TrendingQuestion, 0, len(ranked))
+    for _, row := range ranked {
+        question, ok := byID[row.ID]
+        if !ok {
+            continue // Bị xóa giữa hai query
+        }
+        trending = append(trending, TrendingQuestion{
+            Question:    question,
+            AnswerCount: row.AnswerCount,
+            VoteScore:   row.VoteScore,
+            Score:       row.Score,
+        })
+    }
+    return trending, nil
+}
+
+func questionCursorKey(q models.Question) (time.Time, uuid.UUID) {
+    return q.CreatedAt, q.ID
+}
\ No newline at end of file
@@ -0,0 +1,70 @@
+package services
+
+import (
+    "errors"
+    "testing"
+    "time"
+
+    "github.com/google/uuid"
+    "vietick/config"
+    "vietick/internal/models"
+)
+
+func TestUpdateQuestionByPrivilegedEditorKeepsOwnerAttachments(t *testing.T) {
+    useTestDB(t)
+
+    owner := createTestUser(t, "owner@example.com", true)
+    editor := createTestUser(t, "editor@example.com", true)
+    if err := config.DB.Model(&editor).UpdateColumn("point", 5000).Error; err != nil {
+        t.Fatalf("set editor reputation: %v", err)
+    }
+
+    now := time.Now()
+    attachment := models.Upload{
+        UserID:      owner.ID,
+        Kind:        models.UploadKindAttachment,
+        ContentType: "image/png",
+        Size:        1,
+        Checksum:    "checksum",
+        StorageKey:  "ch/checksum.png",
+        URL:         "http://vietick.test/uploads/ch/checksum.png",
+        CreatedAt:   now,
+    }
+    if err := config.DB.Create(&attachment).Error; err != nil {
+        t.Fatalf("create upload: %v", err)
+    }
+    question := models.Question{Title: "Câu hỏi ban đầu", Content: "Nội dung ban đầu", UserID: owner.ID, CreatedAt: now, UpdatedAt: now}
+    if err := config.DB.Create(&question).Error; err != nil {
+        t.Fatalf("create question: %v", err)
+    }
+    if err := config.DB.Model(&question).Association("Attachments").Append(&attachment); err != nil {
+        t.Fatalf("attach upload: %v", err)
+    }
+
+    updated, err := NewQuestionService().UpdateQuestion(question.ID, editor.ID, UpdateQuestionRequest{
+        Title:         "Câu hỏi đã được sửa",
+        Content:       "Nội dung đã được người khác sửa lại",
+        AttachmentIDs: []string{attachment.ID.String()},
+    })
+    if err != nil {
+        t.Fatalf("update question: %v", err)
+    }
+    if len(updated.Attachments) != 1 || updated.Attachments[0].ID != attachment.ID {
+        t.Fatalf("expected the owner's attachment to be kept, got %+v", updated.Attachments)
+    }
+
+    // File của chính người sửa không thuộc tác giả nên không gắn được
+    own := attachment
+    own.ID, own.UserID = uuid.Nil, editor.ID
+    if err := config.DB.Create(&own).Error; err != nil {
+        t.Fatalf("create editor upload: %v", err)
+    }
+    _, err = NewQuestionService().UpdateQuestion(question.ID, editor.ID, UpdateQuestionRequest{
+        Title:         "Câu hỏi đã được sửa",
+        Content:       "Nội dung đã được người khác sửa lại",
+        AttachmentIDs: []string{own.ID.String()},
+    })
+    if !errors.Is(err, ErrAttachmentNotFound) {
+        t.Fatalf("expected ErrAttachmentNotFound for the editor's own upload, got %v", err)
+    }
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+    "errors"
+    "log"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+    "vietick/config"
+    "vietick/internal/models"
+)
+
+// Điểm (reputation) tác giả câu trả lời nhận được; bị trừ lại khi vote bị bỏ hoặc câu trả lời bị bỏ xác minh
+const (
+    reputationUpVote   = 10
+    reputationDownVote = -2
+    reputationVerified = 15
+)
+
+// voteReputation là điểm tác giả câu trả lời nhận từ một vote
+func voteReputation(voteType models.VoteType) int64 {
+    switch voteType {
+    case models.UpVote:
+        return reputationUpVote
+    case models.DownVote:
+        return reputationDownVote
+    default:
+        return 0
+    }
+}
+
+// addVoteReputation cộng điểm cho tác giả câu trả lời khi vote thay đổi (from/to rỗng nghĩa là chưa có vote / bỏ vote).
+// Tự vote câu trả lời của mình không được tính.
+func addVoteReputation(tx *gorm.DB, answer models.Answer, voterID uuid.UUID, from, to models.VoteType) error {
+    if voterID == answer.UserID {
+        return nil
+    }
+    return changePoints(tx, models.PointTransaction{
+        UserID:   answer.UserID,
+        Amount:   voteReputation(to) - voteReputation(from),
+        Reason:   models.PointReasonAnswerVote,
+        AnswerID: &answer.ID,
+    })
+}
+
+// addVerifyReputation cộng (verified) hoặc trừ lại điểm cho tác giả câu trả lời khi trạng thái xác minh thay đổi.
+// Câu trả lời cho câu hỏi của chính mình không được tính.
+func addVerifyReputation(tx *gorm.DB, answer models.Answer, verified bool) error {
+    var question models.Question
+    if err := tx.Select("id", "user_id").First(&question, "id = ?", answer.QuestionID).Error; err != nil {
+        return err
+    }
+    if question.UserID == answer.UserID {
+        return nil
+    }
+
+    amount := int64(reputationVerified)
+    if !verified {
+        amount = -amount
+    }
+    return changePoints(tx, models.PointTransaction{
+        UserID:   answer.UserID,
+        Amount:   amount,
+        Reason:   models.PointReasonAnswerVerify,
+        AnswerID: &answer.ID,
+    })
+}
+
+// setAnswerVerified đổi trạng thái xác minh bằng UPDATE có điều kiện trên trạng thái cũ và cộng/trừ reputation trong
+// cùng transaction, nên khi hai request đồng thời cùng đổi thì chỉ một request được tính (request kia nhận changed = false)
+func setAnswerVerified(answer models.Answer, verified bool, verifiedBy *uuid.UUID) (bool, error) {
+    var verifier interface{}
+    if verifiedBy != nil {
+        verifier = *verifiedBy
+    }
+
+    changed := false
+    err := config.DB.Transaction(func(tx *gorm.DB) error {
+        result := tx.Model(&models.Answer{}).
+            Where("id = ? AND is_verified = ?", answer.ID, !verified).
+            Updates(map[string]interface{}{"is_verified": verified, "verified_by": verifier})
+        if result.Error != nil {
+            return result.Error
+        }
+        if result.RowsAffected == 0 {
+            return nil
+        }
+        changed = true
+        return addVerifyReputation(tx, answer, verified)
+    })
+    return changed, err
+}
+
+// changePoints ghi một thay đổi điểm vào users.point và point_transactions (Balance được điền sau khi cập nhật),
+// trong transaction của người gọi. Chi điểm (đặt bounty) thất bại với ErrInsufficientReputation nếu không đủ điểm.
+// Điểm bị trừ vì downvote, bỏ vote, bỏ xác minh được trừ đủ, kể cả khi điểm thành âm: nếu chặn ở 0 thì điểm
+// đã tiêu (đặt bounty rồi hủy để được hoàn) sẽ không bao giờ bị thu lại, và lịch sử không còn khớp với users.point.
+// Tài khoản giữ chỗ không nhận điểm.
+func changePoints(tx *gorm.DB, entry models.PointTransaction) error {
+    if entry.UserID == DeletedUserID || entry.Amount == 0 {
+        return nil
+    }
+
+    spending := entry.Reason == models.PointReasonBountyEscrow
+    query := tx.Model(&models.User{}).Where("id = ?", entry.UserID)
+    if spending {
+        query = query.Where("point >= ?", -entry.Amount)
+    }
+    result := query.UpdateColumn("point", gorm.Expr("point + ?", entry.Amount))
+    if result.Error != nil {
+        return result.Error
+    }
+    if spending && result.RowsAffected == 0 {
+        return ErrInsufficientReputation
+    }
+
+    var user models.User
+    if err := tx.Select("id", "point").First(&user, "id = ?", entry.UserID).Error; err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            return ErrUserNotFound
+        }
+        return err
+    }
+    entry.Balance = user.Point
+    return tx.Create(&entry).Error
+}
+
+// reputationBackfillLock là tên MySQL named lock (GET_LOCK) giữ trong lúc tính bù, để nhiều instance khởi động
+// cùng lúc không cùng tính bù
+const (
+    reputationBackfillLock        = "vietick:reputation_backfill"
+    reputationBackfillLockTimeout = 60 // giây
+)
+
+// BackfillReputation tính điểm từ vote và xác minh có sẵn trước khi điểm được cộng tự động, chạy một lần lúc khởi động.
+// Bỏ qua nếu đã có giao dịch điểm từ vote/xác minh/backfill, tức là đã chạy hoặc điểm đã được cộng trực tiếp.
+// Việc kiểm tra và ghi điểm nằm trong một transaction và giữ named lock: instance khác chờ rồi thấy đã chạy,
+// còn nếu lỗi giữa chừng thì không điểm nào được ghi và lần khởi động sau tính lại từ đầu.
+func BackfillReputation() {
+    err := config.DB.Connection(func(conn *gorm.DB) error {
+        var acquired int
+        if err := conn.Raw("SELECT GET_LOCK(?, ?)", reputationBackfillLock, reputationBackfillLockTimeout).Scan(&acquired).Error; err != nil {
+            return err
+        }
+        if acquired != 1 {
+            return errors.New("timed out waiting for another instance to finish")
+        }
+        defer conn.Exec("SELECT RELEASE_LOCK(?)", reputationBackfillLock)
+
+        return conn.Transaction(backfillReputation)
+    })
+    if err != nil {
+        log.Printf("Error backfilling reputation: %v", err)
+    }
+}
+
+func backfillReputation(tx *gorm.DB) error {
+    var done int64
+    if err := tx.Model(&models.PointTransaction{}).
+        Where("reason IN ?", []models.PointTransactionReason{models.PointReasonAnswerVote, models.PointReasonAnswerVerify, models.PointReasonBackfill}).
+        Count(&done).Error; err != nil {
+        return err
+    }
+    if done > 0 {
+        return nil
+    }
+
+    var earned []struct {
+        UserID uuid.UUID
+        Amount int64
+    }
+    if err := tx.Raw(
+        "SELECT user_id, SUM(amount) AS amount FROM ("+
+            "SELECT answers.user_id, CASE votes.type WHEN ? THEN ? WHEN ? THEN ? ELSE 0 END AS amount "+
+            "FROM votes JOIN answers ON answers.id = votes.answer_id WHERE votes.user_id <> answers.user_id "+
+            "UNION ALL "+
+            "SELECT answers.user_id, ? AS amount "+
+            "FROM answers JOIN questions ON questions.id = answers.question_id "+
+            "WHERE answers.is_verified = ? AND answers.user_id <> questions.user_id"+
+            ") AS earned WHERE user_id <> ? GROUP BY user_id HAVING SUM(amount) > 0",
+        models.UpVote, reputationUpVote, models.DownVote, reputationDownVote,
+        reputationVerified, true, DeletedUserID,
+    ).Scan(&earned).Error; err != nil {
+        return err
+    }
+
+    for _, row := range earned {
+        if err := changePoints(tx, models.PointTransaction{UserID: row.UserID, Amount: row.Amount, Reason: models.PointReasonBackfill}); err != nil {
+            return err
+        }
+    }
+    if len(earned) > 0 {
+        log.Printf("Backfilled reputation for %d users", len(earned))
+    }
+    return nil
+}
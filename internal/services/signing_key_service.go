@@ -0,0 +1,300 @@
+package services
+
+import (
+    "crypto"
+    "crypto/ed25519"
+    "crypto/rand"
+    "crypto/rsa"
+    "crypto/x509"
+    "encoding/pem"
+    "errors"
+    "log"
+    "os"
+    "sort"
+    "strconv"
+    "time"
+
+    "github.com/go-sql-driver/mysql"
+    "gorm.io/gorm"
+    "vietick/config"
+    "vietick/internal/models"
+    "vietick/pkg/oauth"
+    "vietick/pkg/utils"
+)
+
+const (
+    signingKeyRefreshInterval = 10 * time.Minute // Mỗi instance nạp lại khóa từ DB theo chu kỳ này
+    signingKeyPublishLead     = 24 * time.Hour   // Khóa mới có trong JWKS ít nhất chừng này trước khi được dùng để ký
+    defaultSigningKeyRotation = 30 * 24 * time.Hour
+    rsaKeyBits                = 2048
+)
+
+// SigningKeyService quản lý khóa ký JWT khi JWT_ALGORITHM là RS256 hoặc EdDSA.
+// Khóa lưu trong DB nên mọi instance dùng chung; khóa kế tiếp được tạo và công bố trước
+// khi tới lúc ký, còn khóa cũ vẫn xác thực được tới khi token cuối cùng nó ký hết hạn.
+type SigningKeyService struct {
+    stop chan struct{}
+    done chan struct{}
+}
+
+func NewSigningKeyService() *SigningKeyService {
+    return &SigningKeyService{}
+}
+
+// StartRotation nạp khóa ngay (lỗi thì trả về để server không chạy mà không phát hành được token),
+// sau đó định kỳ xoay khóa và nạp lại. Không làm gì khi dùng HS256.
+func (s *SigningKeyService) StartRotation() error {
+    if s.stop != nil || utils.JWTAlgorithm() == "HS256" {
+        return nil
+    }
+    if err := rotateSigningKeys(time.Now()); err != nil {
+        return err
+    }
+
+    s.stop = make(chan struct{})
+    s.done = make(chan struct{})
+
+    go func() {
+        defer close(s.done)
+        ticker := time.NewTicker(signingKeyRefreshInterval)
+        defer ticker.Stop()
+
+        for {
+            select {
+            case <-s.stop:
+                return
+            case <-ticker.C:
+            }
+            if err := rotateSigningKeys(time.Now()); err != nil {
+                log.Printf("Error rotating JWT signing keys: %v", err)
+            }
+        }
+    }()
+
+    log.Printf("JWT signing key rotation started (%s)", utils.JWTAlgorithm())
+    return nil
+}
+
+// StopRotation dừng việc xoay khóa, các khóa đã nạp vẫn dùng được
+func (s *SigningKeyService) StopRotation() {
+    if s.stop == nil {
+        return
+    }
+    close(s.stop)
+    <-s.done
+    s.stop = nil
+}
+
+// JWKS trả các public key đang được chấp nhận (kể cả khóa sắp dùng) theo RFC 7517
+func (s *SigningKeyService) JWKS() oauth.JSONWebKeySet {
+    keys := utils.VerificationKeys()
+    sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+
+    set := oauth.JSONWebKeySet{Keys: []oauth.JSONWebKey{}}
+    for _, key := range keys {
+        jwk, err := oauth.NewJSONWebKey(key.ID, key.Algorithm, key.Public)
+        if err != nil {
+            log.Printf("Error encoding signing key %s: %v", key.ID, err)
+            continue
+        }
+        set.Keys = append(set.Keys, jwk)
+    }
+    return set
+}
+
+// rotateSigningKeys xóa khóa hết hạn, tạo khóa kế tiếp nếu đến hạn rồi nạp khóa vào utils
+func rotateSigningKeys(now time.Time) error {
+    if err := config.DB.Where("expires_at <= ?", now).Delete(&models.SigningKey{}).Error; err != nil {
+        return err
+    }
+
+    var keys []models.SigningKey
+    if err := config.DB.Order("activates_at ASC").Find(&keys).Error; err != nil {
+        return err
+    }
+
+    algorithm := utils.JWTAlgorithm()
+    if activatesAt := nextSigningKeyActivation(keys, algorithm, now); activatesAt != nil {
+        if err := createSigningKey(algorithm, *activatesAt); err != nil {
+            return err
+        }
+        if err := config.DB.Order("activates_at ASC").Find(&keys).Error; err != nil {
+            return err
+        }
+    }
+
+    return loadSigningKeys(keys, now)
+}
+
+// nextSigningKeyActivation trả thời điểm khóa kế tiếp bắt đầu ký, nil nếu chưa cần tạo khóa mới.
+// Khóa kế tiếp được tạo trước signingKeyPublishLead để các instance khác và client JWKS kịp nạp;
+// khi đổi JWT_ALGORITHM hoặc server ngừng chạy quá lâu thì chỉ chờ đủ hai chu kỳ nạp lại.
+func nextSigningKeyActivation(keys []models.SigningKey, algorithm string, now time.Time) *time.Time {
+    if len(keys) == 0 {
+        return &now
+    }
+
+    latest := keys[len(keys)-1]
+    if latest.ActivatesAt.After(now) {
+        return nil
+    }
+
+    earliest := now.Add(2 * signingKeyRefreshInterval)
+    if latest.Algorithm != algorithm {
+        return &earliest
+    }
+
+    due := latest.ActivatesAt.Add(signingKeyRotation())
+    if now.Before(due.Add(-signingKeyPublishLead)) {
+        return nil
+    }
+    if due.Before(earliest) {
+        due = earliest
+    }
+    return &due
+}
+
+// createSigningKey tạo cặp khóa mới và đặt hạn cho các khóa trước nó. Nhiều instance cùng xoay
+// sẽ tính ra cùng activates_at, unique index bảo đảm chỉ một khóa được tạo.
+func createSigningKey(algorithm string, activatesAt time.Time) error {
+    private, err := generateSigningKey(algorithm)
+    if err != nil {
+        return err
+    }
+    privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+    if err != nil {
+        return err
+    }
+    publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+    if err != nil {
+        return err
+    }
+    kek, err := signingKeyEncryptionKey()
+    if err != nil {
+        return err
+    }
+    encrypted, err := encryptSecret(kek, string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})))
+    if err != nil {
+        return err
+    }
+
+    key := models.SigningKey{
+        Algorithm:   algorithm,
+        PrivateKey:  encrypted,
+        PublicKey:   string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})),
+        ActivatesAt: activatesAt,
+        CreatedAt:   time.Now(),
+    }
+
+    err = config.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Create(&key).Error; err != nil {
+            return err
+        }
+        // Khóa cũ còn ký thêm tối đa một chu kỳ nạp lại sau activatesAt, token đó sống thêm TokenTTL
+        expiresAt := activatesAt.Add(utils.TokenTTL + signingKeyRefreshInterval)
+        return tx.Model(&models.SigningKey{}).
+            Where("expires_at IS NULL AND activates_at < ?", activatesAt).
+            Update("expires_at", expiresAt).Error
+    })
+    if err != nil {
+        var mysqlErr *mysql.MySQLError
+        if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+            return nil
+        }
+        return err
+    }
+
+    log.Printf("Created JWT signing key %s (%s), active from %s", key.ID, algorithm, activatesAt.Format(time.RFC3339))
+    return nil
+}
+
+// loadSigningKeys đưa khóa vào utils: khóa mới nhất đã tới hạn dùng để ký, mọi khóa chưa hết hạn dùng để xác thực
+func loadSigningKeys(keys []models.SigningKey, now time.Time) error {
+    var signing *utils.SigningKey
+    verify := make([]utils.SigningKey, 0, len(keys))
+    for i, k := range keys {
+        if k.ExpiresAt != nil && !k.ExpiresAt.After(now) {
+            continue
+        }
+        public, err := parsePublicKeyPEM(k.PublicKey)
+        if err != nil {
+            log.Printf("Error parsing JWT signing key %s: %v", k.ID, err)
+            continue
+        }
+        key := utils.SigningKey{ID: k.ID.String(), Algorithm: k.Algorithm, Public: public}
+        verify = append(verify, key)
+
+        // keys sắp theo activates_at tăng dần nên khóa đã tới hạn cuối cùng là khóa ký
+        if !k.ActivatesAt.After(now) && (i == len(keys)-1 || keys[i+1].ActivatesAt.After(now)) {
+            private, err := parsePrivateKey(k.PrivateKey)
+            if err != nil {
+                return err
+            }
+            key.Private = private
+            signing = &key
+        }
+    }
+
+    if signing == nil {
+        return errors.New("no active JWT signing key")
+    }
+    utils.SetSigningKeys(signing, verify)
+    return nil
+}
+
+func generateSigningKey(algorithm string) (crypto.Signer, error) {
+    switch algorithm {
+    case "EdDSA":
+        _, private, err := ed25519.GenerateKey(rand.Reader)
+        return private, err
+    case "RS256":
+        return rsa.GenerateKey(rand.Reader, rsaKeyBits)
+    default:
+        return nil, errors.New("unsupported JWT signing algorithm")
+    }
+}
+
+func parsePublicKeyPEM(encoded string) (crypto.PublicKey, error) {
+    block, _ := pem.Decode([]byte(encoded))
+    if block == nil {
+        return nil, errors.New("invalid public key PEM")
+    }
+    return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+func parsePrivateKey(encrypted string) (crypto.Signer, error) {
+    key, err := signingKeyEncryptionKey()
+    if err != nil {
+        return nil, err
+    }
+    decrypted, err := decryptSecret(key, encrypted)
+    if err != nil {
+        return nil, err
+    }
+    block, _ := pem.Decode([]byte(decrypted))
+    if block == nil {
+        return nil, errors.New("invalid private key PEM")
+    }
+    parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+    if err != nil {
+        return nil, err
+    }
+    signer, ok := parsed.(crypto.Signer)
+    if !ok {
+        return nil, errors.New("unsupported private key type")
+    }
+    return signer, nil
+}
+
+// signingKeyEncryptionKey dùng JWT_KEY_ENCRYPTION_KEY nếu có, nếu không thì khóa dẫn xuất từ secret chung
+func signingKeyEncryptionKey() ([]byte, error) {
+    return encryptionKey("JWT_KEY_ENCRYPTION_KEY", "jwt-signing-key")
+}
+
+// signingKeyRotation đọc chu kỳ xoay khóa từ JWT_KEY_ROTATION_DAYS (mặc định 30 ngày)
+func signingKeyRotation() time.Duration {
+    if days, err := strconv.Atoi(os.Getenv("JWT_KEY_ROTATION_DAYS")); err == nil && days > 0 {
+        return time.Duration(days) * 24 * time.Hour
+    }
+    return defaultSigningKeyRotation
+}
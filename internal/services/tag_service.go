@@ -29,8 +29,12 @@ func NewTagService() *TagService {
     return &TagService{}
 }
 
-// CreateTag tạo tag mới
-func (s *TagService) CreateTag(req CreateTagRequest) (*models.Tag, error) {
+// CreateTag tạo tag mới, cần quyền create_tag
+func (s *TagService) CreateTag(userID uuid.UUID, req CreateTagRequest) (*models.Tag, error) {
+    if err := requirePrivilege(userID, PrivilegeCreateTag); err != nil {
+        return nil, err
+    }
+
     // Normalize tag name (lowercase, trim spaces)
     normalizedName := strings.ToLower(strings.TrimSpace(req.Name))
     
@@ -61,25 +65,23 @@ func (s *TagService) CreateTag(req CreateTagRequest) (*models.Tag, error) {
     return &tag, nil
 }
 
-// GetTags lấy danh sách tags với pagination
-func (s *TagService) GetTags(page, limit int) ([]models.Tag, int64, error) {
+// GetTags lấy danh sách tags theo độ phổ biến, chỉ hỗ trợ phân trang page/limit
+func (s *TagService) GetTags(p Pagination) ([]models.Tag, int64, error) {
     var tags []models.Tag
-    var total int64
 
     // Get total count
-    if err := config.DB.Model(&models.Tag{}).Count(&total).Error; err != nil {
+    total, err := p.Count(config.DB.Model(&models.Tag{}))
+    if err != nil {
         return nil, 0, err
     }
 
     // Get tags with pagination, ordered by usage count
-    offset := (page - 1) * limit
     if err := config.DB.Order("usage_count DESC, name ASC").
-        Offset(offset).
-        Limit(limit).
+        Offset(p.Offset()).
+        Limit(p.Limit).
         Find(&tags).Error; err != nil {
         return nil, 0, err
     }
-
     return tags, total, nil
 }
 
@@ -102,8 +104,12 @@ func (s *TagService) GetTagByName(name string) (*models.Tag, error) {
     return &tag, nil
 }
 
-// UpdateTag cập nhật tag
-func (s *TagService) UpdateTag(tagID uuid.UUID, req UpdateTagRequest) (*models.Tag, error) {
+// UpdateTag cập nhật tag, cần quyền edit_tag
+func (s *TagService) UpdateTag(userID, tagID uuid.UUID, req UpdateTagRequest) (*models.Tag, error) {
+    if err := requirePrivilege(userID, PrivilegeEditTag); err != nil {
+        return nil, err
+    }
+
     var tag models.Tag
     if err := config.DB.First(&tag, "id = ?", tagID).Error; err != nil {
         return nil, errors.New("tag not found")
@@ -133,8 +139,12 @@ func (s *TagService) UpdateTag(tagID uuid.UUID, req UpdateTagRequest) (*models.T
     return &tag, nil
 }
 
-// DeleteTag xóa tag
-func (s *TagService) DeleteTag(tagID uuid.UUID) error {
+// DeleteTag xóa tag, cần quyền edit_tag
+func (s *TagService) DeleteTag(userID, tagID uuid.UUID) error {
+    if err := requirePrivilege(userID, PrivilegeEditTag); err != nil {
+        return err
+    }
+
     var tag models.Tag
     if err := config.DB.First(&tag, "id = ?", tagID).Error; err != nil {
         return errors.New("tag not found")
@@ -152,9 +162,11 @@ func (s *TagService) DeleteTag(tagID uuid.UUID) error {
     return nil
 }
 
-// GetOrCreateTags tạo tags nếu chưa tồn tại, trả về danh sách tag IDs
-func (s *TagService) GetOrCreateTags(tagNames []string) ([]uuid.UUID, error) {
+// GetOrCreateTags tạo tags nếu chưa tồn tại, trả về danh sách tag IDs.
+// Tạo tag mới cần quyền create_tag như khi tạo qua /tags.
+func (s *TagService) GetOrCreateTags(userID uuid.UUID, tagNames []string) ([]uuid.UUID, error) {
     var tagIDs []uuid.UUID
+    canCreate := false
 
     for _, name := range tagNames {
         normalizedName := strings.ToLower(strings.TrimSpace(name))
@@ -166,6 +178,12 @@ func (s *TagService) GetOrCreateTags(tagNames []string) ([]uuid.UUID, error) {
         var tag models.Tag
         if err := config.DB.Where("name = ?", normalizedName).First(&tag).Error; err != nil {
             // Tag doesn't exist, create new one
+            if !canCreate {
+                if err := requirePrivilege(userID, PrivilegeCreateTag); err != nil {
+                    return nil, err
+                }
+                canCreate = true
+            }
             now := time.Now()
             newTag := models.Tag{
                 Name:        normalizedName,
@@ -0,0 +1,498 @@
+package services
+
+import (
+    "crypto/rand"
+    "encoding/base32"
+    "errors"
+    "os"
+    "strings"
+    "time"
+
+    "github.com/golang-jwt/jwt/v5"
+    "github.com/google/uuid"
+    "golang.org/x/crypto/bcrypt"
+    "gorm.io/gorm"
+    "vietick/config"
+    "vietick/internal/models"
+    "vietick/pkg/totp"
+    "vietick/pkg/utils"
+)
+
+const (
+    twoFactorChallengeTTL = 5 * time.Minute
+    twoFactorEnrollTTL    = 15 * time.Minute // Thời gian để tài khoản bắt buộc 2FA quét QR và nhập mã đầu tiên
+    twoFactorMaxAttempts  = 5
+    twoFactorLockDuration = 15 * time.Minute
+    twoFactorSkew         = 1 // Chấp nhận lệch ±1 bước (30 giây) giữa đồng hồ server và điện thoại
+
+    recoveryCodeCount = 10
+
+    challengePurposeLogin  = "2fa"
+    challengePurposeEnroll = "2fa_enroll"
+)
+
+var (
+    ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+    ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+    ErrTwoFactorNotSetUp       = errors.New("start two-factor setup first")
+    ErrTwoFactorRequired       = errors.New("two-factor authentication is required for this account")
+    ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+    ErrTwoFactorLocked         = errors.New("too many invalid codes, try again later")
+    ErrInvalidChallenge        = errors.New("invalid or expired challenge token")
+)
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type TwoFactorService struct{}
+
+type TwoFactorCodeRequest struct {
+    Code string `json:"code" binding:"required"`
+}
+
+type DisableTwoFactorRequest struct {
+    Password string `json:"password"` // Bắt buộc nếu tài khoản có mật khẩu
+    Code     string `json:"code" binding:"required"`
+}
+
+type TwoFactorChallengeRequest struct {
+    ChallengeToken string `json:"challenge_token" binding:"required"`
+    Code           string `json:"code" binding:"required"` // Mã TOTP 6 số hoặc mã khôi phục
+}
+
+type TwoFactorEnrollRequest struct {
+    ChallengeToken string `json:"challenge_token" binding:"required"`
+}
+
+type TwoFactorStatus struct {
+    Enabled                bool       `json:"enabled"`
+    EnabledAt              *time.Time `json:"enabled_at"`
+    Required               bool       `json:"required"` // Tài khoản moderator/admin không được tắt 2FA
+    RecoveryCodesRemaining int64      `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorSetup là secret mới để user thêm vào app authenticator
+type TwoFactorSetup struct {
+    Secret          string `json:"secret"`
+    ProvisioningURI string `json:"provisioning_uri"` // otpauth://, client hiển thị thành mã QR
+}
+
+// TwoFactorEnrollResponse trả về khi tài khoản bắt buộc 2FA hoàn tất đăng ký lúc đăng nhập
+type TwoFactorEnrollResponse struct {
+    LoginResponse
+    RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type twoFactorClaims struct {
+    Purpose string    `json:"purpose"`
+    UserID  uuid.UUID `json:"uid"`
+    jwt.RegisteredClaims
+}
+
+func NewTwoFactorService() *TwoFactorService {
+    return &TwoFactorService{}
+}
+
+// GetStatus lấy trạng thái 2FA của user
+func (s *TwoFactorService) GetStatus(userID uuid.UUID) (*TwoFactorStatus, error) {
+    var user models.User
+    if err := config.DB.First(&user, "id = ?", userID).Error; err != nil {
+        return nil, ErrUserNotFound
+    }
+
+    status := &TwoFactorStatus{Required: user.Role.RequiresTwoFactor()}
+    tf, err := findTwoFactor(userID)
+    if err != nil {
+        return nil, err
+    }
+    if tf != nil && tf.EnabledAt != nil {
+        status.Enabled = true
+        status.EnabledAt = tf.EnabledAt
+        if err := config.DB.Model(&models.RecoveryCode{}).
+            Where("user_id = ? AND used_at IS NULL", userID).
+            Count(&status.RecoveryCodesRemaining).Error; err != nil {
+            return nil, err
+        }
+    }
+    return status, nil
+}
+
+// BeginSetup tạo secret TOTP mới (chưa có hiệu lực cho tới khi user xác nhận bằng Enable)
+func (s *TwoFactorService) BeginSetup(userID uuid.UUID) (*TwoFactorSetup, error) {
+    var user models.User
+    if err := config.DB.First(&user, "id = ?", userID).Error; err != nil {
+        return nil, ErrUserNotFound
+    }
+    return beginTwoFactorSetup(user)
+}
+
+// Enable xác nhận mã đầu tiên từ app, bật 2FA và trả về mã khôi phục (chỉ hiển thị một lần)
+func (s *TwoFactorService) Enable(userID uuid.UUID, req TwoFactorCodeRequest) ([]string, error) {
+    return enableTwoFactor(userID, req.Code)
+}
+
+// Disable tắt 2FA, yêu cầu mật khẩu (nếu có) và mã TOTP hoặc mã khôi phục
+func (s *TwoFactorService) Disable(userID uuid.UUID, req DisableTwoFactorRequest) error {
+    var user models.User
+    if err := config.DB.First(&user, "id = ?", userID).Error; err != nil {
+        return ErrUserNotFound
+    }
+    if user.Role.RequiresTwoFactor() {
+        return ErrTwoFactorRequired
+    }
+    if user.Password != "" {
+        if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+            return ErrIncorrectPassword
+        }
+    }
+
+    tf, err := findTwoFactor(userID)
+    if err != nil {
+        return err
+    }
+    if tf == nil || tf.EnabledAt == nil {
+        return ErrTwoFactorNotEnabled
+    }
+    if err := verifyTwoFactorCode(tf, req.Code, true); err != nil {
+        return err
+    }
+
+    return config.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+            return err
+        }
+        return tx.Where("user_id = ?", userID).Delete(&models.TwoFactor{}).Error
+    })
+}
+
+// RegenerateRecoveryCodes tạo bộ mã khôi phục mới, các mã cũ mất hiệu lực
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID uuid.UUID, req TwoFactorCodeRequest) ([]string, error) {
+    tf, err := findTwoFactor(userID)
+    if err != nil {
+        return nil, err
+    }
+    if tf == nil || tf.EnabledAt == nil {
+        return nil, ErrTwoFactorNotEnabled
+    }
+    if err := verifyTwoFactorCode(tf, req.Code, false); err != nil {
+        return nil, err
+    }
+
+    var codes []string
+    err = config.DB.Transaction(func(tx *gorm.DB) error {
+        codes, err = replaceRecoveryCodes(tx, userID)
+        return err
+    })
+    if err != nil {
+        return nil, err
+    }
+    return codes, nil
+}
+
+// VerifyLogin là bước thứ hai của đăng nhập: đổi challenge token + mã 2FA lấy JWT
+func (s *TwoFactorService) VerifyLogin(req TwoFactorChallengeRequest) (*LoginResponse, error) {
+    claims, err := parseChallengeToken(req.ChallengeToken, challengePurposeLogin)
+    if err != nil {
+        return nil, err
+    }
+
+    tf, err := findTwoFactor(claims.UserID)
+    if err != nil {
+        return nil, err
+    }
+    if tf == nil || tf.EnabledAt == nil {
+        return nil, ErrInvalidChallenge
+    }
+    if err := verifyTwoFactorCode(tf, req.Code, true); err != nil {
+        return nil, err
+    }
+
+    var user models.User
+    if err := config.DB.First(&user, "id = ?", claims.UserID).Error; err != nil {
+        return nil, ErrUserNotFound
+    }
+    return issueLoginToken(user)
+}
+
+// BeginEnrollment tạo secret cho tài khoản bắt buộc 2FA nhưng chưa bật, bằng challenge token lúc đăng nhập
+func (s *TwoFactorService) BeginEnrollment(req TwoFactorEnrollRequest) (*TwoFactorSetup, error) {
+    claims, err := parseChallengeToken(req.ChallengeToken, challengePurposeEnroll)
+    if err != nil {
+        return nil, err
+    }
+
+    var user models.User
+    if err := config.DB.First(&user, "id = ?", claims.UserID).Error; err != nil {
+        return nil, ErrUserNotFound
+    }
+    return beginTwoFactorSetup(user)
+}
+
+// CompleteEnrollment bật 2FA cho tài khoản bắt buộc rồi hoàn tất đăng nhập
+func (s *TwoFactorService) CompleteEnrollment(req TwoFactorChallengeRequest) (*TwoFactorEnrollResponse, error) {
+    claims, err := parseChallengeToken(req.ChallengeToken, challengePurposeEnroll)
+    if err != nil {
+        return nil, err
+    }
+
+    codes, err := enableTwoFactor(claims.UserID, req.Code)
+    if err != nil {
+        return nil, err
+    }
+
+    var user models.User
+    if err := config.DB.First(&user, "id = ?", claims.UserID).Error; err != nil {
+        return nil, ErrUserNotFound
+    }
+    login, err := issueLoginToken(user)
+    if err != nil {
+        return nil, err
+    }
+    return &TwoFactorEnrollResponse{LoginResponse: *login, RecoveryCodes: codes}, nil
+}
+
+// completeLogin được gọi sau khi user đã chứng minh danh tính (mật khẩu hoặc provider OAuth).
+// Tài khoản bật 2FA nhận challenge token thay vì JWT; tài khoản bắt buộc 2FA mà chưa bật phải đăng ký trước.
+func completeLogin(user models.User) (*LoginResponse, error) {
+    tf, err := findTwoFactor(user.ID)
+    if err != nil {
+        return nil, err
+    }
+
+    switch {
+    case tf != nil && tf.EnabledAt != nil:
+        challenge, err := signChallengeToken(user.ID, challengePurposeLogin, twoFactorChallengeTTL)
+        if err != nil {
+            return nil, err
+        }
+        return &LoginResponse{TwoFactorRequired: true, ChallengeToken: challenge}, nil
+    case user.Role.RequiresTwoFactor():
+        challenge, err := signChallengeToken(user.ID, challengePurposeEnroll, twoFactorEnrollTTL)
+        if err != nil {
+            return nil, err
+        }
+        return &LoginResponse{TwoFactorSetupRequired: true, ChallengeToken: challenge}, nil
+    default:
+        return issueLoginToken(user)
+    }
+}
+
+func issueLoginToken(user models.User) (*LoginResponse, error) {
+    token, err := utils.GenerateToken(user.ID)
+    if err != nil {
+        return nil, err
+    }
+    return &LoginResponse{Token: token, User: &user}, nil
+}
+
+func beginTwoFactorSetup(user models.User) (*TwoFactorSetup, error) {
+    tf, err := findTwoFactor(user.ID)
+    if err != nil {
+        return nil, err
+    }
+    if tf != nil && tf.EnabledAt != nil {
+        return nil, ErrTwoFactorAlreadyEnabled
+    }
+
+    secret, err := totp.GenerateSecret()
+    if err != nil {
+        return nil, err
+    }
+    key, err := totpEncryptionKey()
+    if err != nil {
+        return nil, err
+    }
+    encrypted, err := encryptSecret(key, secret)
+    if err != nil {
+        return nil, err
+    }
+
+    now := time.Now()
+    if tf == nil {
+        err = config.DB.Create(&models.TwoFactor{
+            UserID:    user.ID,
+            Secret:    encrypted,
+            CreatedAt: now,
+            UpdatedAt: now,
+        }).Error
+    } else {
+        // Bắt đầu lại khi chưa xác nhận: thay secret cũ, giữ nguyên bộ đếm lần nhập sai
+        err = config.DB.Model(tf).Updates(map[string]interface{}{"secret": encrypted, "updated_at": now}).Error
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    return &TwoFactorSetup{
+        Secret:          secret,
+        ProvisioningURI: totp.ProvisioningURI(totpIssuer(), user.Email, secret),
+    }, nil
+}
+
+func enableTwoFactor(userID uuid.UUID, code string) ([]string, error) {
+    tf, err := findTwoFactor(userID)
+    if err != nil {
+        return nil, err
+    }
+    if tf == nil {
+        return nil, ErrTwoFactorNotSetUp
+    }
+    if tf.EnabledAt != nil {
+        return nil, ErrTwoFactorAlreadyEnabled
+    }
+    if err := verifyTwoFactorCode(tf, code, false); err != nil {
+        return nil, err
+    }
+
+    var codes []string
+    err = config.DB.Transaction(func(tx *gorm.DB) error {
+        now := time.Now()
+        result := tx.Model(&models.TwoFactor{}).Where("id = ? AND enabled_at IS NULL", tf.ID).
+            Updates(map[string]interface{}{"enabled_at": now, "updated_at": now})
+        if result.Error != nil {
+            return result.Error
+        }
+        if result.RowsAffected == 0 {
+            return ErrTwoFactorAlreadyEnabled
+        }
+        codes, err = replaceRecoveryCodes(tx, userID)
+        return err
+    })
+    if err != nil {
+        return nil, err
+    }
+    return codes, nil
+}
+
+// verifyTwoFactorCode kiểm tra mã TOTP (hoặc mã khôi phục nếu allowRecovery). Mỗi mã TOTP chỉ dùng được một lần;
+// nhập sai twoFactorMaxAttempts lần liên tiếp thì khóa nhập mã trong twoFactorLockDuration.
+// Không chạy trong transaction để số lần sai luôn được lưu.
+func verifyTwoFactorCode(tf *models.TwoFactor, code string, allowRecovery bool) error {
+    now := time.Now()
+    if tf.LockedUntil != nil && now.Before(*tf.LockedUntil) {
+        return ErrTwoFactorLocked
+    }
+
+    code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+    if len(code) == totp.Digits {
+        key, err := totpEncryptionKey()
+        if err != nil {
+            return err
+        }
+        secret, err := decryptSecret(key, tf.Secret)
+        if err != nil {
+            return err
+        }
+        if step, ok := totp.Validate(secret, code, now, twoFactorSkew); ok {
+            result := config.DB.Model(&models.TwoFactor{}).
+                Where("id = ? AND last_used_step < ?", tf.ID, step).
+                Updates(map[string]interface{}{"last_used_step": step, "failed_attempts": 0, "locked_until": nil})
+            if result.Error != nil {
+                return result.Error
+            }
+            if result.RowsAffected == 1 {
+                return nil
+            }
+        }
+    } else if allowRecovery {
+        result := config.DB.Model(&models.RecoveryCode{}).
+            Where("user_id = ? AND code_hash = ? AND used_at IS NULL", tf.UserID, hashRecoveryCode(code)).
+            Update("used_at", now)
+        if result.Error != nil {
+            return result.Error
+        }
+        if result.RowsAffected == 1 {
+            return config.DB.Model(&models.TwoFactor{}).Where("id = ?", tf.ID).
+                Updates(map[string]interface{}{"failed_attempts": 0, "locked_until": nil}).Error
+        }
+    }
+
+    updates := map[string]interface{}{"failed_attempts": gorm.Expr("failed_attempts + 1")}
+    if tf.FailedAttempts+1 >= twoFactorMaxAttempts {
+        updates = map[string]interface{}{"failed_attempts": 0, "locked_until": now.Add(twoFactorLockDuration)}
+    }
+    if err := config.DB.Model(&models.TwoFactor{}).Where("id = ?", tf.ID).Updates(updates).Error; err != nil {
+        return err
+    }
+    return ErrInvalidTwoFactorCode
+}
+
+// replaceRecoveryCodes xóa mã khôi phục cũ và tạo recoveryCodeCount mã mới dạng xxxxx-xxxxx
+func replaceRecoveryCodes(tx *gorm.DB, userID uuid.UUID) ([]string, error) {
+    if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+        return nil, err
+    }
+
+    now := time.Now()
+    codes := make([]string, 0, recoveryCodeCount)
+    rows := make([]models.RecoveryCode, 0, recoveryCodeCount)
+    for i := 0; i < recoveryCodeCount; i++ {
+        raw := make([]byte, 7)
+        if _, err := rand.Read(raw); err != nil {
+            return nil, err
+        }
+        encoded := strings.ToLower(recoveryEncoding.EncodeToString(raw))[:10]
+        code := encoded[:5] + "-" + encoded[5:]
+        codes = append(codes, code)
+        rows = append(rows, models.RecoveryCode{UserID: userID, CodeHash: hashRecoveryCode(code), CreatedAt: now})
+    }
+    if err := tx.Create(&rows).Error; err != nil {
+        return nil, err
+    }
+    return codes, nil
+}
+
+// hashRecoveryCode chuẩn hóa (bỏ dấu gạch, chữ thường) trước khi hash để user nhập kiểu nào cũng khớp
+func hashRecoveryCode(code string) string {
+    normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+    return hashUserToken(normalized)
+}
+
+func findTwoFactor(userID uuid.UUID) (*models.TwoFactor, error) {
+    var tf models.TwoFactor
+    if err := config.DB.Where("user_id = ?", userID).First(&tf).Error; err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            return nil, nil
+        }
+        return nil, err
+    }
+    return &tf, nil
+}
+
+func signChallengeToken(userID uuid.UUID, purpose string, ttl time.Duration) (string, error) {
+    claims := twoFactorClaims{
+        Purpose: purpose,
+        UserID:  userID,
+        RegisteredClaims: jwt.RegisteredClaims{
+            ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+        },
+    }
+    key, err := derivedKey("challenge:" + purpose)
+    if err != nil {
+        return "", err
+    }
+    return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+}
+
+func parseChallengeToken(token, purpose string) (*twoFactorClaims, error) {
+    claims := &twoFactorClaims{}
+    _, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+        return derivedKey("challenge:" + purpose)
+    }, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+    if err != nil || claims.Purpose != purpose || claims.ExpiresAt == nil {
+        return nil, ErrInvalidChallenge
+    }
+    return claims, nil
+}
+
+// totpEncryptionKey dùng TOTP_ENCRYPTION_KEY nếu có, nếu không thì khóa dẫn xuất từ secret chung
+func totpEncryptionKey() ([]byte, error) {
+    return encryptionKey("TOTP_ENCRYPTION_KEY", "totp-secret")
+}
+
+// totpIssuer là tên hiển thị trong app authenticator
+func totpIssuer() string {
+    if issuer := os.Getenv("TOTP_ISSUER"); issuer != "" {
+        return issuer
+    }
+    return "VieTick"
+}
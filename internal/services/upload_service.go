@@ -0,0 +1,283 @@
+package services
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "image"
+    _ "image/gif"
+    "image/jpeg"
+    "image/png"
+    "io"
+    "net/http"
+    "path/filepath"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+    "vietick/config"
+    "vietick/internal/models"
+    "vietick/pkg/imaging"
+    "vietick/pkg/storage"
+)
+
+const (
+    MaxAvatarSize     = 2 << 20  // 2MB
+    MaxAttachmentSize = 10 << 20 // 10MB
+    MaxAttachments    = 10       // Số file đính kèm tối đa cho một câu hỏi/câu trả lời
+
+    avatarSize     = 256
+    thumbnailSize  = 320
+    maxImagePixels = 40_000_000 // Chặn ảnh "bom giải nén" trước khi decode
+)
+
+var (
+    ErrFileTooLarge       = errors.New("file too large")
+    ErrUnsupportedFile    = errors.New("unsupported file type")
+    ErrAttachmentNotFound = errors.New("attachment not found")
+)
+
+// Loại file được chấp nhận (theo MIME phát hiện từ nội dung) và phần mở rộng tương ứng
+var uploadExtensions = map[string]string{
+    "image/jpeg":      ".jpg",
+    "image/png":       ".png",
+    "image/gif":       ".gif",
+    "image/webp":      ".webp",
+    "application/pdf": ".pdf",
+    "text/plain":      ".txt",
+}
+
+// Ảnh stdlib decode được để tạo thumbnail (webp chỉ lưu, không tạo thumbnail)
+var thumbnailableTypes = map[string]bool{
+    "image/jpeg": true,
+    "image/png":  true,
+    "image/gif":  true,
+}
+
+var (
+    defaultStorage     storage.Storage
+    defaultStorageOnce sync.Once
+)
+
+// DefaultStorage trả về storage dùng chung của ứng dụng, khởi tạo lần đầu khi env đã được nạp
+func DefaultStorage() storage.Storage {
+    defaultStorageOnce.Do(func() {
+        defaultStorage = storage.NewFromEnv()
+    })
+    return defaultStorage
+}
+
+type UploadService struct {
+    storage storage.Storage
+}
+
+func NewUploadService() *UploadService {
+    return &UploadService{
+        storage: DefaultStorage(),
+    }
+}
+
+// UploadAttachment lưu file đính kèm để gắn vào câu hỏi hoặc câu trả lời
+func (s *UploadService) UploadAttachment(userID uuid.UUID, fileName string, r io.Reader) (*models.Upload, error) {
+    return s.store(userID, models.UploadKindAttachment, fileName, r, MaxAttachmentSize)
+}
+
+// UploadAvatar lưu ảnh avatar (cắt vuông, thu nhỏ) và cập nhật hồ sơ user
+func (s *UploadService) UploadAvatar(userID uuid.UUID, fileName string, r io.Reader) (*models.User, error) {
+    upload, err := s.store(userID, models.UploadKindAvatar, fileName, r, MaxAvatarSize)
+    if err != nil {
+        return nil, err
+    }
+    if upload.ThumbnailURL == "" {
+        return nil, ErrUnsupportedFile
+    }
+
+    if err := config.DB.Model(&models.User{}).Where("id = ?", userID).
+        Updates(map[string]interface{}{
+            "avatar_upload_id": upload.ID,
+            "avatar_url":       upload.ThumbnailURL,
+            "updated_at":       time.Now(),
+        }).Error; err != nil {
+        return nil, err
+    }
+
+    return NewUserService().GetProfile(userID)
+}
+
+// RemoveAvatar gỡ avatar của user; file vẫn giữ trong storage vì có thể được dùng chung
+func (s *UploadService) RemoveAvatar(userID uuid.UUID) error {
+    return config.DB.Model(&models.User{}).Where("id = ?", userID).
+        Updates(map[string]interface{}{
+            "avatar_upload_id": nil,
+            "avatar_url":       "",
+            "updated_at":       time.Now(),
+        }).Error
+}
+
+// GetUpload lấy thông tin một file đã tải lên
+func (s *UploadService) GetUpload(uploadID uuid.UUID) (*models.Upload, error) {
+    var upload models.Upload
+    if err := config.DB.First(&upload, "id = ?", uploadID).Error; err != nil {
+        return nil, errors.New("upload not found")
+    }
+    return &upload, nil
+}
+
+// store đọc file (tối đa maxSize), xác định MIME từ nội dung, lưu theo SHA-256 và tạo thumbnail cho ảnh
+func (s *UploadService) store(userID uuid.UUID, kind models.UploadKind, fileName string, r io.Reader, maxSize int64) (*models.Upload, error) {
+    data, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+    if err != nil {
+        return nil, err
+    }
+    if int64(len(data)) > maxSize {
+        return nil, ErrFileTooLarge
+    }
+    if len(data) == 0 {
+        return nil, errors.New("file is empty")
+    }
+
+    contentType := sniffContentType(data)
+    ext, ok := uploadExtensions[contentType]
+    if !ok || (kind == models.UploadKindAvatar && !thumbnailableTypes[contentType]) {
+        return nil, ErrUnsupportedFile
+    }
+
+    sum := sha256.Sum256(data)
+    checksum := hex.EncodeToString(sum[:])
+    key := contentKey(checksum, ext)
+
+    if err := s.putIfMissing(key, data, contentType); err != nil {
+        return nil, err
+    }
+
+    upload := models.Upload{
+        UserID:      userID,
+        Kind:        kind,
+        FileName:    filepath.Base(fileName),
+        ContentType: contentType,
+        Size:        int64(len(data)),
+        Checksum:    checksum,
+        StorageKey:  key,
+        URL:         s.storage.URL(key),
+        CreatedAt:   time.Now(),
+    }
+
+    if thumbnailableTypes[contentType] {
+        if err := s.attachThumbnail(&upload, data, kind); err != nil {
+            return nil, err
+        }
+    }
+
+    if err := config.DB.Create(&upload).Error; err != nil {
+        return nil, err
+    }
+    return &upload, nil
+}
+
+// attachThumbnail decode ảnh, tạo thumbnail (avatar thì cắt vuông) và lưu vào storage
+func (s *UploadService) attachThumbnail(upload *models.Upload, data []byte, kind models.UploadKind) error {
+    cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+    if err != nil {
+        return ErrUnsupportedFile
+    }
+    if cfg.Width*cfg.Height > maxImagePixels {
+        return errors.New("image dimensions too large")
+    }
+    upload.Width, upload.Height = cfg.Width, cfg.Height
+
+    src, _, err := image.Decode(bytes.NewReader(data))
+    if err != nil {
+        return ErrUnsupportedFile
+    }
+
+    var thumb image.Image
+    var variant string
+    if kind == models.UploadKindAvatar {
+        thumb = imaging.SquareThumbnail(src, avatarSize)
+        variant = fmt.Sprintf("avatar%d", avatarSize)
+    } else {
+        thumb = imaging.Thumbnail(src, thumbnailSize)
+        variant = fmt.Sprintf("thumb%d", thumbnailSize)
+    }
+
+    // Giữ PNG cho ảnh có nền trong suốt, còn lại nén JPEG cho nhẹ
+    var buf bytes.Buffer
+    ext, contentType := ".jpg", "image/jpeg"
+    if upload.ContentType == "image/jpeg" {
+        err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 85})
+    } else {
+        ext, contentType = ".png", "image/png"
+        err = png.Encode(&buf, thumb)
+    }
+    if err != nil {
+        return err
+    }
+
+    key := "thumbs/" + upload.Checksum[:2] + "/" + upload.Checksum + "_" + variant + ext
+    if err := s.putIfMissing(key, buf.Bytes(), contentType); err != nil {
+        return err
+    }
+    upload.ThumbnailKey = key
+    upload.ThumbnailURL = s.storage.URL(key)
+    return nil
+}
+
+// putIfMissing chỉ ghi khi key chưa có; key theo nội dung nên file đã có chắc chắn giống hệt
+func (s *UploadService) putIfMissing(key string, data []byte, contentType string) error {
+    exists, err := s.storage.Exists(key)
+    if err != nil {
+        return err
+    }
+    if exists {
+        return nil
+    }
+    return s.storage.Put(key, bytes.NewReader(data), contentType)
+}
+
+// resolveAttachments kiểm tra các upload thuộc về user và là file đính kèm
+func resolveAttachments(tx *gorm.DB, userID uuid.UUID, ids []string) ([]models.Upload, error) {
+    if len(ids) == 0 {
+        return nil, nil
+    }
+    if len(ids) > MaxAttachments {
+        return nil, fmt.Errorf("at most %d attachments are allowed", MaxAttachments)
+    }
+
+    uploadIDs := make([]uuid.UUID, 0, len(ids))
+    seen := make(map[uuid.UUID]bool, len(ids))
+    for _, id := range ids {
+        uploadID, err := uuid.Parse(id)
+        if err != nil {
+            return nil, ErrAttachmentNotFound
+        }
+        if !seen[uploadID] {
+            seen[uploadID] = true
+            uploadIDs = append(uploadIDs, uploadID)
+        }
+    }
+
+    var uploads []models.Upload
+    if err := tx.Where("id IN ? AND user_id = ? AND kind = ?", uploadIDs, userID, models.UploadKindAttachment).
+        Find(&uploads).Error; err != nil {
+        return nil, err
+    }
+    if len(uploads) != len(uploadIDs) {
+        return nil, ErrAttachmentNotFound
+    }
+    return uploads, nil
+}
+
+// sniffContentType xác định MIME từ nội dung file (bỏ phần charset)
+func sniffContentType(data []byte) string {
+    contentType, _, _ := strings.Cut(http.DetectContentType(data), ";")
+    return contentType
+}
+
+// contentKey đặt tên file theo SHA-256, chia thư mục theo 2 ký tự đầu để tránh thư mục quá lớn
+func contentKey(checksum, ext string) string {
+    return checksum[:2] + "/" + checksum + ext
+}
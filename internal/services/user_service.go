@@ -2,9 +2,12 @@ package services
 
 import (
     "errors"
+    "fmt"
     "log"
+    "strings"
     "time"
 
+    "github.com/go-sql-driver/mysql"
     "github.com/google/uuid"
     "golang.org/x/crypto/bcrypt"
     "gorm.io/gorm"
@@ -26,9 +29,47 @@ type LoginRequest struct {
     Password string `json:"password" binding:"required"`
 }
 
+type UpdateProfileRequest struct {
+    DisplayName *string `json:"display_name" binding:"omitempty,max=50"`
+    Bio         *string `json:"bio" binding:"omitempty,max=500"`
+    Location    *string `json:"location" binding:"omitempty,max=100"`
+    Website     *string `json:"website" binding:"omitempty,url,max=255"`
+    AvatarURL   *string `json:"avatar_url" binding:"omitempty,url,max=500"`
+}
+
+type ChangePasswordRequest struct {
+    CurrentPassword string `json:"current_password" binding:"required"`
+    NewPassword     string `json:"new_password" binding:"required,min=8"`
+}
+
+// ChangeEmailRequest cần current_password; tài khoản không có mật khẩu gửi reauth_token hoặc code (2FA) thay thế
+type ChangeEmailRequest struct {
+    Email           string `json:"email" binding:"required,email"`
+    CurrentPassword string `json:"current_password"`
+    Code            string `json:"code"`
+    ReauthToken     string `json:"reauth_token"`
+}
+
+type ChangeUsernameRequest struct {
+    Username string `json:"username" binding:"required,min=3,max=20"`
+}
+
+// UsernameChangeCooldown là khoảng thời gian tối thiểu giữa hai lần đổi username
+const UsernameChangeCooldown = 30 * 24 * time.Hour
+
+var (
+    ErrIncorrectPassword = errors.New("current password is incorrect")
+    ErrReauthRequired    = errors.New("this account has no password; confirm with a two-factor code or a reauth_token from a linked provider")
+)
+
+// LoginResponse có token khi đăng nhập xong; tài khoản bật 2FA (hoặc bắt buộc 2FA mà chưa bật)
+// nhận challenge_token để hoàn tất qua /auth/2fa/verify (hoặc /auth/2fa/enroll)
 type LoginResponse struct {
-    Token string      `json:"token"`
-    User  models.User `json:"user"`
+    Token                  string       `json:"token,omitempty"`
+    User                   *models.User `json:"user,omitempty"`
+    TwoFactorRequired      bool         `json:"two_factor_required,omitempty"`
+    TwoFactorSetupRequired bool         `json:"two_factor_setup_required,omitempty"`
+    ChallengeToken         string       `json:"challenge_token,omitempty"`
 }
 
 func NewUserService() *UserService {
@@ -69,6 +110,11 @@ func (s *UserService) Register(req RegisterRequest) (*LoginResponse, error) {
     }
     log.Printf("User registered with ID: %s", user.ID)
 
+    // Send email verification link
+    if err := NewAuthService().SendVerificationEmail(user.ID); err != nil {
+        log.Printf("Error sending verification email: %v", err)
+    }
+
     // Generate JWT token
     token, err := utils.GenerateToken(user.ID)
     if err != nil {
@@ -77,7 +123,7 @@ func (s *UserService) Register(req RegisterRequest) (*LoginResponse, error) {
 
     return &LoginResponse{
         Token: token,
-        User:  user,
+        User:  &user,
     }, nil
 }
 
@@ -92,16 +138,8 @@ func (s *UserService) Login(req LoginRequest) (*LoginResponse, error) {
         return nil, errors.New("invalid email or password")
     }
 
-    // Generate JWT token
-    token, err := utils.GenerateToken(user.ID)
-    if err != nil {
-        return nil, err
-    }
-
-    return &LoginResponse{
-        Token: token,
-        User:  user,
-    }, nil
+    // Issue JWT, or a challenge token if the account uses 2FA
+    return completeLogin(user)
 }
 
 func (s *UserService) GetProfile(userID uuid.UUID) (*models.User, error) {
@@ -113,7 +151,171 @@ func (s *UserService) GetProfile(userID uuid.UUID) (*models.User, error) {
     return &user, nil
 }
 
+// HasRole kiểm tra user có một trong các role cho trước không
+func (s *UserService) HasRole(userID uuid.UUID, roles ...models.UserRole) (bool, error) {
+    var count int64
+    if err := config.DB.Model(&models.User{}).
+        Where("id = ? AND role IN ?", userID, roles).
+        Count(&count).Error; err != nil {
+        return false, err
+    }
+    return count > 0, nil
+}
+
 func (s *UserService) AddPoint(userID uuid.UUID, points int) error {
     return config.DB.Model(&models.User{}).Where("id = ?", userID).
         UpdateColumn("point", gorm.Expr("point + ?", points)).Error
-} 
\ No newline at end of file
+} 
+
+// UpdateProfile cập nhật thông tin hồ sơ; trường không gửi lên giữ nguyên, gửi chuỗi rỗng để xóa
+func (s *UserService) UpdateProfile(userID uuid.UUID, req UpdateProfileRequest) (*models.User, error) {
+    updates := map[string]interface{}{}
+    if req.DisplayName != nil {
+        updates["display_name"] = strings.TrimSpace(*req.DisplayName)
+    }
+    if req.Bio != nil {
+        updates["bio"] = strings.TrimSpace(*req.Bio)
+    }
+    if req.Location != nil {
+        updates["location"] = strings.TrimSpace(*req.Location)
+    }
+    if req.Website != nil {
+        updates["website"] = *req.Website
+    }
+    if req.AvatarURL != nil {
+        updates["avatar_url"] = *req.AvatarURL
+    }
+
+    if len(updates) > 0 {
+        updates["updated_at"] = time.Now()
+        if err := config.DB.Model(&models.User{}).Where("id = ?", userID).Updates(updates).Error; err != nil {
+            return nil, err
+        }
+    }
+
+    return s.GetProfile(userID)
+}
+
+// ChangePassword đổi mật khẩu, yêu cầu mật khẩu hiện tại; các link đặt lại mật khẩu còn hạn bị vô hiệu
+func (s *UserService) ChangePassword(userID uuid.UUID, req ChangePasswordRequest) error {
+    user, err := s.checkPassword(userID, req.CurrentPassword)
+    if err != nil {
+        return err
+    }
+
+    hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+    if err != nil {
+        return err
+    }
+
+    now := time.Now()
+    return config.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Model(&models.User{}).Where("id = ?", user.ID).
+            Updates(map[string]interface{}{"password": string(hashedPassword), "updated_at": now}).Error; err != nil {
+            return err
+        }
+        return tx.Model(&models.UserToken{}).
+            Where("user_id = ? AND purpose = ? AND used_at IS NULL", user.ID, models.UserTokenPasswordReset).
+            Update("used_at", now).Error
+    })
+}
+
+// ChangeEmail gửi link xác nhận tới email mới; email chỉ thay đổi sau khi user xác nhận
+func (s *UserService) ChangeEmail(userID uuid.UUID, req ChangeEmailRequest) error {
+    user, err := s.confirmIdentity(userID, req.CurrentPassword, req.Code, req.ReauthToken)
+    if err != nil {
+        return err
+    }
+
+    if strings.EqualFold(user.Email, req.Email) {
+        return errors.New("new email is the same as current email")
+    }
+
+    var existingUser models.User
+    if err := config.DB.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
+        return errors.New("email already exists")
+    }
+
+    return NewAuthService().SendEmailChangeVerification(*user, req.Email)
+}
+
+// ChangeUsername đổi username, mỗi lần cách nhau ít nhất UsernameChangeCooldown
+func (s *UserService) ChangeUsername(userID uuid.UUID, req ChangeUsernameRequest) (*models.User, error) {
+    user, err := s.GetProfile(userID)
+    if err != nil {
+        return nil, err
+    }
+
+    if user.Username == req.Username {
+        return user, nil
+    }
+    if user.UsernameChangedAt != nil && time.Since(*user.UsernameChangedAt) < UsernameChangeCooldown {
+        nextChange := user.UsernameChangedAt.Add(UsernameChangeCooldown)
+        return nil, fmt.Errorf("username can be changed again after %s", nextChange.Format(time.RFC3339))
+    }
+
+    var existingUser models.User
+    if err := config.DB.Where("username = ? AND id <> ?", req.Username, userID).First(&existingUser).Error; err == nil {
+        return nil, errors.New("username already exists")
+    }
+
+    now := time.Now()
+    if err := config.DB.Model(&models.User{}).Where("id = ?", userID).
+        Updates(map[string]interface{}{"username": req.Username, "username_changed_at": now, "updated_at": now}).Error; err != nil {
+        // Unique index trên username chặn trường hợp hai user đổi cùng lúc
+        var mysqlErr *mysql.MySQLError
+        if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+            return nil, errors.New("username already exists")
+        }
+        return nil, err
+    }
+
+    return s.GetProfile(userID)
+}
+
+func (s *UserService) checkPassword(userID uuid.UUID, password string) (*models.User, error) {
+    user, err := s.GetProfile(userID)
+    if err != nil {
+        return nil, err
+    }
+    if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+        return nil, ErrIncorrectPassword
+    }
+    return user, nil
+}
+
+// confirmIdentity xác nhận user trước thao tác nhạy cảm. Tài khoản có mật khẩu phải nhập đúng mật khẩu;
+// tài khoản tạo qua OAuth (không có mật khẩu) dùng reauth token từ provider đã liên kết hoặc mã 2FA.
+func (s *UserService) confirmIdentity(userID uuid.UUID, password, code, reauthToken string) (*models.User, error) {
+    user, err := s.GetProfile(userID)
+    if err != nil {
+        return nil, err
+    }
+    if user.Password != "" {
+        if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+            return nil, ErrIncorrectPassword
+        }
+        return user, nil
+    }
+
+    switch {
+    case reauthToken != "":
+        if err := verifyReauthToken(reauthToken, userID); err != nil {
+            return nil, err
+        }
+    case code != "":
+        tf, err := findTwoFactor(userID)
+        if err != nil {
+            return nil, err
+        }
+        if tf == nil || tf.EnabledAt == nil {
+            return nil, ErrTwoFactorNotEnabled
+        }
+        if err := verifyTwoFactorCode(tf, code, true); err != nil {
+            return nil, err
+        }
+    default:
+        return nil, ErrReauthRequired
+    }
+    return user, nil
+}
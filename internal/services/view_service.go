@@ -0,0 +1,171 @@
+package services
+
+import (
+    "log"
+    "os"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/google/uuid"
+    "gorm.io/gorm"
+    "vietick/config"
+    "vietick/internal/models"
+)
+
+const (
+    viewFlushInterval      = 30 * time.Second
+    viewFlushBatchSize     = 500
+    defaultViewDedupWindow = 30 * time.Minute
+)
+
+// ViewService đếm lượt xem câu hỏi. Lượt xem được gom trong bộ nhớ và ghi xuống DB theo lô,
+// nên mỗi request xem câu hỏi không phải UPDATE questions. Cùng một người xem (user hoặc IP)
+// chỉ được tính một lần trong cửa sổ VIEW_DEDUP_WINDOW.
+type ViewService struct {
+    mutex   sync.Mutex
+    seen    map[string]time.Time // viewer|question -> hết hạn chống trùng
+    pending map[uuid.UUID]int64  // lượt xem chưa ghi xuống DB
+    window  time.Duration
+    stop    chan struct{}
+    done    chan struct{}
+}
+
+// defaultViewService dùng chung để controller và scheduler cùng một bộ đệm
+var defaultViewService = &ViewService{
+    seen:    make(map[string]time.Time),
+    pending: make(map[uuid.UUID]int64),
+    window:  defaultViewDedupWindow,
+}
+
+func NewViewService() *ViewService {
+    return defaultViewService
+}
+
+// RecordView ghi nhận một lượt xem; viewerKey là "user:<id>" hoặc "ip:<địa chỉ>"
+func (s *ViewService) RecordView(questionID uuid.UUID, viewerKey string) {
+    now := time.Now()
+    key := viewerKey + "|" + questionID.String()
+
+    s.mutex.Lock()
+    defer s.mutex.Unlock()
+
+    if expiresAt, ok := s.seen[key]; ok && now.Before(expiresAt) {
+        return
+    }
+    s.seen[key] = now.Add(s.window)
+    s.pending[questionID]++
+}
+
+// StartFlusher chạy định kỳ để ghi lượt xem đang đệm xuống DB
+func (s *ViewService) StartFlusher() {
+    if s.stop != nil {
+        return
+    }
+    s.mutex.Lock()
+    s.window = viewDedupWindow()
+    s.mutex.Unlock()
+    s.stop = make(chan struct{})
+    s.done = make(chan struct{})
+
+    go func() {
+        defer close(s.done)
+        ticker := time.NewTicker(viewFlushInterval)
+        defer ticker.Stop()
+
+        for {
+            select {
+            case <-s.stop:
+                if err := s.Flush(); err != nil {
+                    log.Printf("Error flushing question views: %v", err)
+                }
+                return
+            case <-ticker.C:
+                if err := s.Flush(); err != nil {
+                    log.Printf("Error flushing question views: %v", err)
+                }
+            }
+        }
+    }()
+
+    log.Println("Question view flusher started")
+}
+
+// StopFlusher dừng flusher sau khi ghi nốt lượt xem còn trong bộ đệm
+func (s *ViewService) StopFlusher() {
+    if s.stop == nil {
+        return
+    }
+    close(s.stop)
+    <-s.done
+    s.stop = nil
+}
+
+// Flush ghi lượt xem đang đệm xuống DB, mỗi lô một câu UPDATE, và dọn các mục chống trùng đã hết hạn.
+// Lô ghi lỗi được trả lại bộ đệm để lần sau ghi tiếp.
+func (s *ViewService) Flush() error {
+    now := time.Now()
+
+    s.mutex.Lock()
+    pending := s.pending
+    s.pending = make(map[uuid.UUID]int64)
+    for key, expiresAt := range s.seen {
+        if !now.Before(expiresAt) {
+            delete(s.seen, key)
+        }
+    }
+    s.mutex.Unlock()
+
+    if len(pending) == 0 {
+        return nil
+    }
+
+    ids := make([]uuid.UUID, 0, len(pending))
+    for id := range pending {
+        ids = append(ids, id)
+    }
+
+    for start := 0; start < len(ids); start += viewFlushBatchSize {
+        end := start + viewFlushBatchSize
+        if end > len(ids) {
+            end = len(ids)
+        }
+        batch := ids[start:end]
+
+        if err := addQuestionViews(batch, pending); err != nil {
+            s.mutex.Lock()
+            for _, id := range ids[start:] {
+                s.pending[id] += pending[id]
+            }
+            s.mutex.Unlock()
+            return err
+        }
+    }
+    return nil
+}
+
+// addQuestionViews cộng lượt xem cho nhiều câu hỏi bằng một câu UPDATE ... CASE
+func addQuestionViews(ids []uuid.UUID, counts map[uuid.UUID]int64) error {
+    var increment strings.Builder
+    args := make([]interface{}, 0, len(ids)*2)
+    increment.WriteString("view_count + CASE id")
+    for _, id := range ids {
+        increment.WriteString(" WHEN ? THEN ?")
+        args = append(args, id, counts[id])
+    }
+    increment.WriteString(" ELSE 0 END")
+
+    return config.DB.Model(&models.Question{}).Where("id IN ?", ids).
+        UpdateColumn("view_count", gorm.Expr(increment.String(), args...)).Error
+}
+
+// viewDedupWindow đọc VIEW_DEDUP_WINDOW (vd: "30m", "1h"), mặc định 30 phút
+func viewDedupWindow() time.Duration {
+    if value := os.Getenv("VIEW_DEDUP_WINDOW"); value != "" {
+        if window, err := time.ParseDuration(value); err == nil && window > 0 {
+            return window
+        }
+        log.Printf("Invalid VIEW_DEDUP_WINDOW %q, using %s", value, defaultViewDedupWindow)
+    }
+    return defaultViewDedupWindow
+}
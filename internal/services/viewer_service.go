@@ -0,0 +1,174 @@
+package services
+
+import (
+    "github.com/google/uuid"
+    "vietick/config"
+    "vietick/internal/models"
+)
+
+// ViewerService bổ sung số vote và trạng thái riêng của người xem vào câu hỏi/câu trả lời.
+// Mỗi loại dữ liệu lấy bằng một query cho cả trang, không query theo từng câu trả lời.
+type ViewerService struct{}
+
+// QuestionView là câu hỏi kèm bounty đang mở và trạng thái của người xem; Viewer nil với khách
+type QuestionView struct {
+    models.Question
+    Bounty *models.Bounty       `json:"bounty,omitempty"`
+    Viewer *QuestionViewerState `json:"viewer,omitempty"`
+}
+
+type QuestionViewerState struct {
+    Watching        bool       `json:"watching"`
+    Bookmarked      bool       `json:"bookmarked"`
+    CollectionID    *uuid.UUID `json:"collection_id"` // Collection chứa bookmark, nil nếu không có
+    FollowingAuthor bool       `json:"following_author"`
+}
+
+// AnswerView là câu trả lời kèm số vote và trạng thái của người xem; Viewer nil với khách
+type AnswerView struct {
+    models.Answer
+    UpVotes   int64              `json:"up_votes"`
+    DownVotes int64              `json:"down_votes"`
+    Viewer    *AnswerViewerState `json:"viewer,omitempty"`
+}
+
+type AnswerViewerState struct {
+    Vote            *models.VoteType `json:"vote"` // nil nếu chưa vote
+    FollowingAuthor bool             `json:"following_author"`
+}
+
+func NewViewerService() *ViewerService {
+    return &ViewerService{}
+}
+
+// QuestionView dựng response chi tiết câu hỏi; viewerID uuid.Nil nghĩa là khách
+func (s *ViewerService) QuestionView(question *models.Question, viewerID uuid.UUID) (*QuestionView, error) {
+    bounty, err := NewBountyService().GetOpenBounty(question.ID)
+    if err != nil {
+        return nil, err
+    }
+
+    view := &QuestionView{Question: *question, Bounty: bounty}
+    if viewerID == uuid.Nil {
+        return view, nil
+    }
+
+    var watches int64
+    if err := config.DB.Model(&models.QuestionWatch{}).
+        Where("user_id = ? AND question_id = ?", viewerID, question.ID).
+        Count(&watches).Error; err != nil {
+        return nil, err
+    }
+
+    var bookmarks []models.Bookmark
+    if err := config.DB.Select("collection_id").
+        Where("user_id = ? AND question_id = ?", viewerID, question.ID).
+        Limit(1).Find(&bookmarks).Error; err != nil {
+        return nil, err
+    }
+
+    following, err := followedAuthors(viewerID, []uuid.UUID{question.UserID})
+    if err != nil {
+        return nil, err
+    }
+
+    view.Viewer = &QuestionViewerState{
+        Watching:        watches > 0,
+        Bookmarked:      len(bookmarks) > 0,
+        FollowingAuthor: following[question.UserID],
+    }
+    if len(bookmarks) > 0 {
+        view.Viewer.CollectionID = bookmarks[0].CollectionID
+    }
+    return view, nil
+}
+
+// AnswerViews dựng response danh sách câu trả lời; viewerID uuid.Nil nghĩa là khách
+func (s *ViewerService) AnswerViews(answers []models.Answer, viewerID uuid.UUID) ([]AnswerView, error) {
+    views := make([]AnswerView, 0, len(answers))
+    if len(answers) == 0 {
+        return views, nil
+    }
+
+    answerIDs := make([]uuid.UUID, 0, len(answers))
+    authorIDs := make([]uuid.UUID, 0, len(answers))
+    for _, answer := range answers {
+        answerIDs = append(answerIDs, answer.ID)
+        authorIDs = append(authorIDs, answer.UserID)
+    }
+
+    var tallies []struct {
+        AnswerID uuid.UUID
+        Type     models.VoteType
+        Count    int64
+    }
+    if err := config.DB.Model(&models.Vote{}).
+        Select("answer_id, type, COUNT(*) AS count").
+        Where("answer_id IN ?", answerIDs).
+        Group("answer_id, type").
+        Scan(&tallies).Error; err != nil {
+        return nil, err
+    }
+    upVotes := make(map[uuid.UUID]int64, len(answers))
+    downVotes := make(map[uuid.UUID]int64, len(answers))
+    for _, tally := range tallies {
+        switch tally.Type {
+        case models.UpVote:
+            upVotes[tally.AnswerID] = tally.Count
+        case models.DownVote:
+            downVotes[tally.AnswerID] = tally.Count
+        }
+    }
+
+    var myVotes map[uuid.UUID]models.VoteType
+    var following map[uuid.UUID]bool
+    if viewerID != uuid.Nil {
+        var votes []models.Vote
+        if err := config.DB.Select("answer_id", "type").
+            Where("user_id = ? AND answer_id IN ?", viewerID, answerIDs).
+            Find(&votes).Error; err != nil {
+            return nil, err
+        }
+        myVotes = make(map[uuid.UUID]models.VoteType, len(votes))
+        for _, vote := range votes {
+            myVotes[vote.AnswerID] = vote.Type
+        }
+
+        var err error
+        if following, err = followedAuthors(viewerID, authorIDs); err != nil {
+            return nil, err
+        }
+    }
+
+    for _, answer := range answers {
+        view := AnswerView{
+            Answer:    answer,
+            UpVotes:   upVotes[answer.ID],
+            DownVotes: downVotes[answer.ID],
+        }
+        if viewerID != uuid.Nil {
+            view.Viewer = &AnswerViewerState{FollowingAuthor: following[answer.UserID]}
+            if vote, ok := myVotes[answer.ID]; ok {
+                view.Viewer.Vote = &vote
+            }
+        }
+        views = append(views, view)
+    }
+    return views, nil
+}
+
+// followedAuthors trả tập tác giả (trong authorIDs) mà viewer đang follow, bằng một query
+func followedAuthors(viewerID uuid.UUID, authorIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+    var followingIDs []uuid.UUID
+    if err := config.DB.Model(&models.Follow{}).
+        Where("follower_id = ? AND following_id IN ?", viewerID, authorIDs).
+        Pluck("following_id", &followingIDs).Error; err != nil {
+        return nil, err
+    }
+
+    following := make(map[uuid.UUID]bool, len(followingIDs))
+    for _, id := range followingIDs {
+        following[id] = true
+    }
+    return following, nil
+}
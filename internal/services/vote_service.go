@@ -2,9 +2,14 @@ package services
 
 import (
     "errors"
+    "fmt"
+    "log"
     "time"
 
+    "github.com/go-sql-driver/mysql"
     "github.com/google/uuid"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
     "vietick/config"
     "vietick/internal/models"
 )
@@ -30,57 +35,118 @@ func (s *VoteService) CreateVote(userID, answerID uuid.UUID, req CreateVoteReque
         return nil, errors.New("answer not found")
     }
 
-    // Check if user has already voted
-    var existingVote models.Vote
-    if err := config.DB.Where("user_id = ? AND answer_id = ?", userID, answerID).First(&existingVote).Error; err == nil {
+    vote, err := s.applyVote(userID, answer, req.Type)
+    var mysqlErr *mysql.MySQLError
+    if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+        // Một request đồng thời của cùng user vừa tạo vote, làm lại để đi theo nhánh đã có vote
+        vote, err = s.applyVote(userID, answer, req.Type)
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    // Kiểm tra lại số upvote sau khi tạo, đổi hoặc xóa vote
+    if err := s.checkAndUpdateVerification(userID, answerID); err != nil {
+        return nil, err
+    }
+    if vote != nil && vote.Type == models.UpVote {
+        s.notifyUpVote(userID, answer)
+        PublishBadgeEvent(BadgeEventAnswerUpvoted, answer.UserID, answer.ID)
+    }
+
+    return vote, nil
+}
+
+// applyVote đọc vote hiện có (khóa dòng) rồi tạo, đổi hoặc bỏ vote và cộng reputation cho tác giả câu trả lời
+// trong cùng một transaction. Vote cùng loại với vote hiện có là bỏ vote, khi đó trả nil.
+func (s *VoteService) applyVote(userID uuid.UUID, answer models.Answer, voteType models.VoteType) (*models.Vote, error) {
+    var result *models.Vote
+    err := config.DB.Transaction(func(tx *gorm.DB) error {
+        var existingVote models.Vote
+        err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+            Where("user_id = ? AND answer_id = ?", userID, answer.ID).
+            First(&existingVote).Error
+        if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+            return err
+        }
+        hasVoted := err == nil
+
         // If vote type is the same, remove the vote
-        if existingVote.Type == req.Type {
-            if err := config.DB.Delete(&existingVote).Error; err != nil {
-                return nil, err
-            }
-            // Kiểm tra lại số upvote sau khi xóa vote
-            if err := s.checkAndUpdateVerification(answerID); err != nil {
-                return nil, err
+        if hasVoted && existingVote.Type == voteType {
+            if err := tx.Delete(&existingVote).Error; err != nil {
+                return err
             }
-            return nil, nil
+            return addVoteReputation(tx, answer, userID, existingVote.Type, "")
         }
-        // If vote type is different, update the vote
-        existingVote.Type = req.Type
-        existingVote.UpdatedAt = time.Now()
-        if err := config.DB.Save(&existingVote).Error; err != nil {
-            return nil, err
-        }
-        // Kiểm tra lại số upvote sau khi thay đổi vote
-        if err := s.checkAndUpdateVerification(answerID); err != nil {
-            return nil, err
+
+        if voteType == models.DownVote {
+            if err := requirePrivilege(userID, PrivilegeVoteDown); err != nil {
+                return err
+            }
         }
-        return &existingVote, nil
-    }
 
-    // Create new vote
-    now := time.Now()
-    vote := models.Vote{
-        UserID:    userID,
-        AnswerID:  answerID,
-        Type:      req.Type,
-        CreatedAt: now,
-        UpdatedAt: now,
-    }
+        now := time.Now()
+        if hasVoted {
+            // If vote type is different, update the vote
+            previousType := existingVote.Type
+            existingVote.Type = voteType
+            existingVote.UpdatedAt = now
+            if err := tx.Save(&existingVote).Error; err != nil {
+                return err
+            }
+            result = &existingVote
+            return addVoteReputation(tx, answer, userID, previousType, voteType)
+        }
 
-    if err := config.DB.Create(&vote).Error; err != nil {
+        // Create new vote
+        vote := models.Vote{
+            UserID:    userID,
+            AnswerID:  answer.ID,
+            Type:      voteType,
+            CreatedAt: now,
+            UpdatedAt: now,
+        }
+        if err := tx.Create(&vote).Error; err != nil {
+            return err
+        }
+        result = &vote
+        return addVoteReputation(tx, answer, userID, "", voteType)
+    })
+    if err != nil {
         return nil, err
     }
+    return result, nil
+}
 
-    // Kiểm tra số upvote sau khi tạo vote mới
-    if err := s.checkAndUpdateVerification(answerID); err != nil {
-        return nil, err
+// notifyUpVote gộp upvote vào một notification chưa đọc cho tác giả câu trả lời,
+// thay vì gửi một notification cho mỗi vote
+func (s *VoteService) notifyUpVote(voterID uuid.UUID, answer models.Answer) {
+    // Không thông báo khi tự vote cho câu trả lời của mình
+    if voterID == answer.UserID {
+        return
     }
 
-    return &vote, nil
+    notificationService := NewNotificationService()
+    if err := notificationService.SendAggregatedNotificationToUser(
+        answer.UserID,
+        voterID,
+        "vote:"+answer.ID.String(),
+        models.NotificationTypeVote,
+        map[string]interface{}{
+            "answer_id":   answer.ID,
+            "question_id": answer.QuestionID,
+        },
+        func(count int) (string, string) {
+            return "Câu trả lời của bạn được upvote",
+                fmt.Sprintf("Câu trả lời của bạn nhận được %d upvote mới.", count)
+        },
+    ); err != nil {
+        log.Printf("Error sending vote notification: %v", err)
+    }
 }
 
 // checkAndUpdateVerification kiểm tra và cập nhật trạng thái xác minh của câu trả lời
-func (s *VoteService) checkAndUpdateVerification(answerID uuid.UUID) error {
+func (s *VoteService) checkAndUpdateVerification(voterID, answerID uuid.UUID) error {
     var upVotes int64
     if err := config.DB.Model(&models.Vote{}).
         Where("answer_id = ? AND type = ?", answerID, models.UpVote).
@@ -95,17 +161,18 @@ func (s *VoteService) checkAndUpdateVerification(answerID uuid.UUID) error {
 
     // Nếu số upvote đạt ngưỡng và câu trả lời chưa được xác minh
     if upVotes >= VERIFICATION_THRESHOLD && !answer.IsVerified {
-        answer.IsVerified = true
         // Lấy ID của người tạo câu trả lời làm người xác minh
-        answer.VerifiedBy = &answer.UserID
-        if err := config.DB.Save(&answer).Error; err != nil {
+        changed, err := setAnswerVerified(answer, true, &answer.UserID)
+        if err != nil || !changed {
             return err
         }
+        answer.IsVerified = true
+        answer.VerifiedBy = &answer.UserID
+        notifyAnswerVerified(answer, voterID, true)
+        PublishBadgeEvent(BadgeEventAnswerVerified, answer.UserID, answer.ID)
     } else if upVotes < VERIFICATION_THRESHOLD && answer.IsVerified && answer.VerifiedBy != nil && *answer.VerifiedBy == answer.UserID {
         // Nếu số upvote giảm xuống dưới ngưỡng và câu trả lời đã được xác minh tự động
-        answer.IsVerified = false
-        answer.VerifiedBy = nil
-        if err := config.DB.Save(&answer).Error; err != nil {
+        if _, err := setAnswerVerified(answer, false, nil); err != nil {
             return err
         }
     }
@@ -113,6 +180,27 @@ func (s *VoteService) checkAndUpdateVerification(answerID uuid.UUID) error {
     return nil
 }
 
+// RemoveDuplicateVotes xóa vote trùng (cùng user, cùng câu trả lời) do request đồng thời tạo ra trước khi có
+// unique index idx_vote_user_answer, giữ vote cập nhật gần nhất. Chạy trước AutoMigrate để tạo được index.
+func RemoveDuplicateVotes() error {
+    migrator := config.DB.Migrator()
+    if !migrator.HasTable(&models.Vote{}) || migrator.HasIndex(&models.Vote{}, "idx_vote_user_answer") {
+        return nil
+    }
+    result := config.DB.Exec(
+        "DELETE older FROM votes AS older JOIN votes AS newer " +
+            "ON newer.user_id = older.user_id AND newer.answer_id = older.answer_id " +
+            "AND (newer.updated_at > older.updated_at OR (newer.updated_at = older.updated_at AND newer.id > older.id))",
+    )
+    if result.Error != nil {
+        return result.Error
+    }
+    if result.RowsAffected > 0 {
+        log.Printf("Removed %d duplicate votes", result.RowsAffected)
+    }
+    return nil
+}
+
 func (s *VoteService) GetVotesByAnswer(answerID uuid.UUID) (int64, int64, error) {
     var upVotes, downVotes int64
 
@@ -129,4 +217,16 @@ func (s *VoteService) GetVotesByAnswer(answerID uuid.UUID) (int64, int64, error)
     }
 
     return upVotes, downVotes, nil
-} 
\ No newline at end of file
+}
+
+// GetUserVote trả loại vote của user cho câu trả lời, nil nếu user chưa vote
+func (s *VoteService) GetUserVote(userID, answerID uuid.UUID) (*models.VoteType, error) {
+    var vote models.Vote
+    if err := config.DB.Where("user_id = ? AND answer_id = ?", userID, answerID).First(&vote).Error; err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            return nil, nil
+        }
+        return nil, err
+    }
+    return &vote.Type, nil
+}
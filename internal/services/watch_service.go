@@ -0,0 +1,98 @@
+package services
+
+import (
+    "errors"
+    "time"
+
+    "github.com/google/uuid"
+    "vietick/config"
+    "vietick/internal/models"
+)
+
+type WatchService struct{}
+
+func NewWatchService() *WatchService {
+    return &WatchService{}
+}
+
+// WatchQuestion theo dõi một câu hỏi để nhận thông báo khi có câu trả lời, xác minh, chỉnh sửa
+func (s *WatchService) WatchQuestion(userID, questionID uuid.UUID) (*models.QuestionWatch, error) {
+    var question models.Question
+    if err := config.DB.First(&question, "id = ?", questionID).Error; err != nil {
+        return nil, errors.New("question not found")
+    }
+
+    var existingWatch models.QuestionWatch
+    if err := config.DB.Where("user_id = ? AND question_id = ?", userID, questionID).First(&existingWatch).Error; err == nil {
+        return nil, errors.New("already watching this question")
+    }
+
+    watch := models.QuestionWatch{
+        UserID:     userID,
+        QuestionID: questionID,
+        CreatedAt:  time.Now(),
+    }
+    if err := config.DB.Create(&watch).Error; err != nil {
+        return nil, err
+    }
+
+    return &watch, nil
+}
+
+// UnwatchQuestion bỏ theo dõi câu hỏi
+func (s *WatchService) UnwatchQuestion(userID, questionID uuid.UUID) error {
+    result := config.DB.Where("user_id = ? AND question_id = ?", userID, questionID).Delete(&models.QuestionWatch{})
+    if result.Error != nil {
+        return result.Error
+    }
+    if result.RowsAffected == 0 {
+        return errors.New("not watching this question")
+    }
+    return nil
+}
+
+// IsWatching kiểm tra user có đang theo dõi câu hỏi không
+func (s *WatchService) IsWatching(userID, questionID uuid.UUID) (bool, error) {
+    var count int64
+    if err := config.DB.Model(&models.QuestionWatch{}).
+        Where("user_id = ? AND question_id = ?", userID, questionID).
+        Count(&count).Error; err != nil {
+        return false, err
+    }
+    return count > 0, nil
+}
+
+// GetWatchedQuestions lấy danh sách câu hỏi user đang theo dõi
+func (s *WatchService) GetWatchedQuestions(userID uuid.UUID, p Pagination) ([]models.Question, int64, string, error) {
+    var watches []models.QuestionWatch
+
+    // Get total count
+    total, err := p.Count(config.DB.Model(&models.QuestionWatch{}).Where("user_id = ?", userID))
+    if err != nil {
+        return nil, 0, "", err
+    }
+
+    if err := p.Apply(config.DB.Preload("Question.User", publicUserColumns).Preload("Question.Tags").Where("user_id = ?", userID), "created_at", "id").
+        Find(&watches).Error; err != nil {
+        return nil, 0, "", err
+    }
+
+    watches, nextCursor := trimPage(watches, p.Limit, func(w models.QuestionWatch) (time.Time, uuid.UUID) {
+        return w.CreatedAt, w.ID
+    })
+
+    questions := make([]models.Question, 0, len(watches))
+    for _, watch := range watches {
+        questions = append(questions, watch.Question)
+    }
+    return questions, total, nextCursor, nil
+}
+
+// ensureWatch tự động theo dõi câu hỏi (khi hỏi hoặc trả lời), không lỗi nếu đã theo dõi
+func ensureWatch(userID, questionID uuid.UUID) error {
+    var watch models.QuestionWatch
+    return config.DB.
+        Where(models.QuestionWatch{UserID: userID, QuestionID: questionID}).
+        Attrs(models.QuestionWatch{CreatedAt: time.Now()}).
+        FirstOrCreate(&watch).Error
+}
@@ -0,0 +1,70 @@
+package imaging
+
+import (
+    "image"
+    "image/color"
+)
+
+// Thumbnail thu nhỏ ảnh để cạnh dài nhất không vượt quá maxSize, giữ tỉ lệ.
+// Ảnh nhỏ hơn maxSize được giữ nguyên kích thước.
+func Thumbnail(src image.Image, maxSize int) image.Image {
+    bounds := src.Bounds()
+    width, height := bounds.Dx(), bounds.Dy()
+    if width <= maxSize && height <= maxSize {
+        return resize(src, bounds, width, height)
+    }
+
+    if width >= height {
+        height = max(1, height*maxSize/width)
+        width = maxSize
+    } else {
+        width = max(1, width*maxSize/height)
+        height = maxSize
+    }
+    return resize(src, bounds, width, height)
+}
+
+// SquareThumbnail cắt phần vuông ở giữa ảnh rồi thu nhỏ về size x size (dùng cho avatar)
+func SquareThumbnail(src image.Image, size int) image.Image {
+    bounds := src.Bounds()
+    side := min(bounds.Dx(), bounds.Dy())
+    x0 := bounds.Min.X + (bounds.Dx()-side)/2
+    y0 := bounds.Min.Y + (bounds.Dy()-side)/2
+    crop := image.Rect(x0, y0, x0+side, y0+side)
+    return resize(src, crop, min(size, side), min(size, side))
+}
+
+// resize lấy mẫu vùng area của src về width x height bằng trung bình các điểm ảnh
+// nằm trong mỗi ô (box filter), đủ tốt cho thumbnail mà không cần thư viện ngoài
+func resize(src image.Image, area image.Rectangle, width, height int) *image.NRGBA {
+    dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+    srcW, srcH := area.Dx(), area.Dy()
+
+    for y := 0; y < height; y++ {
+        sy0 := area.Min.Y + y*srcH/height
+        sy1 := max(sy0+1, area.Min.Y+(y+1)*srcH/height)
+        for x := 0; x < width; x++ {
+            sx0 := area.Min.X + x*srcW/width
+            sx1 := max(sx0+1, area.Min.X+(x+1)*srcW/width)
+
+            var r, g, b, a, n uint64
+            for sy := sy0; sy < sy1; sy++ {
+                for sx := sx0; sx < sx1; sx++ {
+                    c := color.NRGBA64Model.Convert(src.At(sx, sy)).(color.NRGBA64)
+                    r += uint64(c.R)
+                    g += uint64(c.G)
+                    b += uint64(c.B)
+                    a += uint64(c.A)
+                    n++
+                }
+            }
+            dst.SetNRGBA(x, y, color.NRGBA{
+                R: uint8(r / n >> 8),
+                G: uint8(g / n >> 8),
+                B: uint8(b / n >> 8),
+                A: uint8(a / n >> 8),
+            })
+        }
+    }
+    return dst
+}
@@ -0,0 +1,46 @@
+package mailer
+
+import (
+    "fmt"
+    "log"
+    "os"
+    "path/filepath"
+    "time"
+
+    "github.com/google/uuid"
+)
+
+// FileMailer ghi email ra file .eml để kiểm tra khi phát triển local; Dir rỗng thì chỉ ghi log
+type FileMailer struct {
+    Dir  string
+    From string
+}
+
+func (m *FileMailer) Send(msg Message) error {
+    if err := validate(msg); err != nil {
+        return err
+    }
+
+    if m.Dir == "" {
+        log.Printf("Mail to %s: %s", msg.To, msg.Subject)
+        return nil
+    }
+
+    body, err := buildMIME(m.From, msg)
+    if err != nil {
+        return err
+    }
+
+    if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+        return err
+    }
+
+    name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102150405"), uuid.NewString()[:8])
+    path := filepath.Join(m.Dir, name)
+    if err := os.WriteFile(path, body, 0o644); err != nil {
+        return err
+    }
+
+    log.Printf("Mail to %s written to %s", msg.To, path)
+    return nil
+}
@@ -0,0 +1,116 @@
+package mailer
+
+import (
+    "bytes"
+    "fmt"
+    "mime"
+    "mime/multipart"
+    "net/textproto"
+    "os"
+    "strings"
+    "time"
+)
+
+// Message là một email cần gửi, có thể có cả phần text và HTML
+type Message struct {
+    To       string
+    Subject  string
+    TextBody string
+    HTMLBody string
+    Headers  map[string]string
+}
+
+// Mailer là abstraction gửi email, cho phép thay SMTP bằng sink ghi file khi chạy local
+type Mailer interface {
+    Send(msg Message) error
+}
+
+// NewFromEnv tạo mailer theo MAIL_DRIVER: "smtp" hoặc "file" (mặc định, ghi ra MAIL_FILE_DIR hoặc chỉ log)
+func NewFromEnv() Mailer {
+    from := getEnv("MAIL_FROM", "VieTick <no-reply@vietick.local>")
+
+    switch getEnv("MAIL_DRIVER", "file") {
+    case "smtp":
+        return &SMTPMailer{
+            Host:     getEnv("SMTP_HOST", "localhost"),
+            Port:     getEnv("SMTP_PORT", "587"),
+            Username: os.Getenv("SMTP_USERNAME"),
+            Password: os.Getenv("SMTP_PASSWORD"),
+            From:     from,
+        }
+    default:
+        return &FileMailer{
+            Dir:  os.Getenv("MAIL_FILE_DIR"),
+            From: from,
+        }
+    }
+}
+
+// buildMIME dựng email multipart/alternative (text + HTML) theo RFC 5322
+func buildMIME(from string, msg Message) ([]byte, error) {
+    var buf bytes.Buffer
+    writer := multipart.NewWriter(&buf)
+
+    headers := []string{
+        "From: " + from,
+        "To: " + msg.To,
+        "Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject),
+        "Date: " + time.Now().Format(time.RFC1123Z),
+        "MIME-Version: 1.0",
+        "Content-Type: multipart/alternative; boundary=" + writer.Boundary(),
+    }
+    for key, value := range msg.Headers {
+        headers = append(headers, key+": "+value)
+    }
+
+    var out bytes.Buffer
+    out.WriteString(strings.Join(headers, "\r\n"))
+    out.WriteString("\r\n\r\n")
+
+    parts := []struct {
+        contentType string
+        body        string
+    }{
+        {"text/plain; charset=utf-8", msg.TextBody},
+        {"text/html; charset=utf-8", msg.HTMLBody},
+    }
+    for _, part := range parts {
+        if part.body == "" {
+            continue
+        }
+        w, err := writer.CreatePart(textproto.MIMEHeader{
+            "Content-Type":              {part.contentType},
+            "Content-Transfer-Encoding": {"8bit"},
+        })
+        if err != nil {
+            return nil, err
+        }
+        if _, err := w.Write([]byte(part.body)); err != nil {
+            return nil, err
+        }
+    }
+    if err := writer.Close(); err != nil {
+        return nil, err
+    }
+
+    out.Write(buf.Bytes())
+    return out.Bytes(), nil
+}
+
+func validate(msg Message) error {
+    if msg.To == "" {
+        return fmt.Errorf("mail recipient is required")
+    }
+    if strings.ContainsAny(msg.To, "\r\n") || strings.ContainsAny(msg.Subject, "\r\n") {
+        return fmt.Errorf("invalid mail header")
+    }
+    return nil
+}
+
+func getEnv(key, defaultValue string) string {
+    value := os.Getenv(key)
+    if value == "" {
+        return defaultValue
+    }
+    return value
+}
@@ -0,0 +1,39 @@
+package mailer
+
+import (
+    "net"
+    "net/mail"
+    "net/smtp"
+)
+
+// SMTPMailer gửi email qua SMTP server
+type SMTPMailer struct {
+    Host     string
+    Port     string
+    Username string
+    Password string
+    From     string
+}
+
+func (m *SMTPMailer) Send(msg Message) error {
+    if err := validate(msg); err != nil {
+        return err
+    }
+
+    body, err := buildMIME(m.From, msg)
+    if err != nil {
+        return err
+    }
+
+    sender := m.From
+    if address, err := mail.ParseAddress(m.From); err == nil {
+        sender = address.Address
+    }
+
+    var auth smtp.Auth
+    if m.Username != "" {
+        auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+    }
+
+    return smtp.SendMail(net.JoinHostPort(m.Host, m.Port), auth, sender, []string{msg.To}, body)
+}
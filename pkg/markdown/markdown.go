@@ -0,0 +1,107 @@
+package markdown
+
+import (
+    "bytes"
+    "regexp"
+    "strings"
+    "unicode/utf8"
+
+    "github.com/microcosm-cc/bluemonday"
+    "github.com/yuin/goldmark"
+    "github.com/yuin/goldmark/ast"
+    "github.com/yuin/goldmark/text"
+)
+
+// Rendered là kết quả render một nội dung Markdown
+type Rendered struct {
+    HTML string // HTML đã sanitize, an toàn để chèn thẳng vào trang
+    Text string // Văn bản thuần, dùng cho tìm kiếm và preview
+}
+
+// goldmark mặc định không render HTML thô trong Markdown (thay bằng comment),
+// bluemonday vẫn sanitize lại đầu ra để phòng link javascript:, thuộc tính lạ...
+var (
+    renderer = goldmark.New()
+    policy   = newPolicy()
+
+    whitespace = regexp.MustCompile(`[ \t]+`)
+    blankLines = regexp.MustCompile(`\n{3,}`)
+)
+
+func newPolicy() *bluemonday.Policy {
+    p := bluemonday.UGCPolicy()
+    // Giữ class language-xxx của fenced code block để client tô màu cú pháp
+    p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#.-]+$`)).OnElements("code")
+    p.RequireNoFollowOnLinks(true)
+    p.AddTargetBlankToFullyQualifiedLinks(true)
+    return p
+}
+
+// Render chuyển Markdown (CommonMark) thành HTML đã sanitize và văn bản thuần
+func Render(source string) (Rendered, error) {
+    src := []byte(source)
+    doc := renderer.Parser().Parse(text.NewReader(src))
+
+    var buf bytes.Buffer
+    if err := renderer.Renderer().Render(&buf, src, doc); err != nil {
+        return Rendered{}, err
+    }
+
+    return Rendered{
+        HTML: policy.Sanitize(buf.String()),
+        Text: plainText(doc, src),
+    }, nil
+}
+
+// Preview cắt văn bản thuần còn tối đa maxRunes ký tự, thêm "…" nếu bị cắt
+func Preview(plain string, maxRunes int) string {
+    plain = strings.Join(strings.Fields(plain), " ")
+    if utf8.RuneCountInString(plain) <= maxRunes {
+        return plain
+    }
+    runes := []rune(plain)
+    return strings.TrimSpace(string(runes[:maxRunes])) + "…"
+}
+
+// plainText lấy chữ từ cây Markdown: giữ nội dung text, code, alt của ảnh; bỏ cú pháp và HTML thô
+func plainText(doc ast.Node, src []byte) string {
+    var buf strings.Builder
+
+    ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+        if !entering {
+            if n.Type() == ast.TypeBlock {
+                buf.WriteString("\n")
+            }
+            return ast.WalkContinue, nil
+        }
+
+        switch node := n.(type) {
+        case *ast.Text:
+            buf.Write(node.Segment.Value(src))
+            if node.SoftLineBreak() || node.HardLineBreak() {
+                buf.WriteString("\n")
+            }
+        case *ast.String:
+            buf.Write(node.Value)
+        case *ast.AutoLink:
+            buf.Write(node.URL(src))
+        case *ast.FencedCodeBlock, *ast.CodeBlock:
+            lines := n.Lines()
+            for i := 0; i < lines.Len(); i++ {
+                line := lines.At(i)
+                buf.Write(line.Value(src))
+            }
+            return ast.WalkSkipChildren, nil
+        case *ast.RawHTML, *ast.HTMLBlock:
+            return ast.WalkSkipChildren, nil
+        }
+        return ast.WalkContinue, nil
+    })
+
+    result := whitespace.ReplaceAllString(buf.String(), " ")
+    lines := strings.Split(result, "\n")
+    for i, line := range lines {
+        lines[i] = strings.TrimSpace(line)
+    }
+    return strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
@@ -0,0 +1,92 @@
+package oauth
+
+import (
+    "context"
+    "fmt"
+    "net/http"
+    "net/url"
+    "strconv"
+)
+
+// GitHubProvider đăng nhập bằng GitHub OAuth App. GitHub không hỗ trợ OIDC cho user nên
+// thông tin user lấy qua REST API (/user, /user/emails) bằng access token.
+type GitHubProvider struct {
+    ClientID     string
+    ClientSecret string
+    AuthURL      string
+    TokenURL     string
+    APIURL       string
+    HTTPClient   *http.Client
+}
+
+func NewGitHubProvider(clientID, clientSecret string) *GitHubProvider {
+    return &GitHubProvider{
+        ClientID:     clientID,
+        ClientSecret: clientSecret,
+        AuthURL:      "https://github.com/login/oauth/authorize",
+        TokenURL:     "https://github.com/login/oauth/access_token",
+        APIURL:       "https://api.github.com",
+    }
+}
+
+func (p *GitHubProvider) Name() string {
+    return "github"
+}
+
+// AuthCodeURL không dùng nonce: GitHub không trả ID token, state đã chống CSRF
+func (p *GitHubProvider) AuthCodeURL(ctx context.Context, state, nonce, redirectURI string) (string, error) {
+    query := url.Values{
+        "client_id":    {p.ClientID},
+        "redirect_uri": {redirectURI},
+        "scope":        {"read:user user:email"},
+        "state":        {state},
+    }
+    return appendQuery(p.AuthURL, query), nil
+}
+
+func (p *GitHubProvider) Exchange(ctx context.Context, code, nonce, redirectURI string) (*Identity, error) {
+    token, err := exchangeCode(ctx, p.HTTPClient, p.TokenURL, p.ClientID, p.ClientSecret, code, redirectURI)
+    if err != nil {
+        return nil, err
+    }
+
+    var user struct {
+        ID        int64  `json:"id"`
+        Login     string `json:"login"`
+        Name      string `json:"name"`
+        AvatarURL string `json:"avatar_url"`
+    }
+    if err := getJSON(ctx, p.HTTPClient, p.APIURL+"/user", token.AccessToken, &user); err != nil {
+        return nil, err
+    }
+    if user.ID == 0 {
+        return nil, fmt.Errorf("%w: missing GitHub user id", ErrProviderResponse)
+    }
+
+    identity := &Identity{
+        Provider:  p.Name(),
+        Subject:   strconv.FormatInt(user.ID, 10), // login có thể đổi, id thì không
+        Name:      user.Name,
+        Username:  user.Login,
+        AvatarURL: user.AvatarURL,
+    }
+
+    // Email công khai trên profile chưa chắc đã xác minh, lấy email chính đã xác minh từ /user/emails
+    var emails []struct {
+        Email    string `json:"email"`
+        Primary  bool   `json:"primary"`
+        Verified bool   `json:"verified"`
+    }
+    if err := getJSON(ctx, p.HTTPClient, p.APIURL+"/user/emails", token.AccessToken, &emails); err != nil {
+        return nil, err
+    }
+    for _, e := range emails {
+        if e.Primary {
+            identity.Email = e.Email
+            identity.EmailVerified = e.Verified
+            break
+        }
+    }
+
+    return identity, nil
+}
@@ -0,0 +1,108 @@
+package oauth
+
+import (
+    "crypto/ecdsa"
+    "crypto/ed25519"
+    "crypto/elliptic"
+    "crypto/rsa"
+    "encoding/base64"
+    "errors"
+    "math/big"
+)
+
+// JSONWebKey là một public key theo RFC 7517 (chỉ các trường cần để xác thực chữ ký)
+type JSONWebKey struct {
+    Kty string `json:"kty"`
+    Kid string `json:"kid"`
+    Use string `json:"use,omitempty"`
+    Alg string `json:"alg,omitempty"`
+    N   string `json:"n,omitempty"`   // RSA modulus
+    E   string `json:"e,omitempty"`   // RSA exponent
+    Crv string `json:"crv,omitempty"` // EC: P-256/P-384/P-521, OKP: Ed25519
+    X   string `json:"x,omitempty"`
+    Y   string `json:"y,omitempty"`
+}
+
+type JSONWebKeySet struct {
+    Keys []JSONWebKey `json:"keys"`
+}
+
+// PublicKeys chuyển các key dùng để ký thành crypto public key theo kid, bỏ qua key không hỗ trợ
+func (s JSONWebKeySet) PublicKeys() map[string]interface{} {
+    keys := make(map[string]interface{}, len(s.Keys))
+    for _, jwk := range s.Keys {
+        if jwk.Use != "" && jwk.Use != "sig" {
+            continue
+        }
+        if key := jwk.PublicKey(); key != nil {
+            keys[jwk.Kid] = key
+        }
+    }
+    return keys
+}
+
+// PublicKey trả *rsa.PublicKey, *ecdsa.PublicKey hoặc ed25519.PublicKey; nil nếu key không hợp lệ
+func (k JSONWebKey) PublicKey() interface{} {
+    switch k.Kty {
+    case "RSA":
+        n, errN := base64.RawURLEncoding.DecodeString(k.N)
+        e, errE := base64.RawURLEncoding.DecodeString(k.E)
+        if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+            return nil
+        }
+        return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+    case "EC":
+        var curve elliptic.Curve
+        switch k.Crv {
+        case "P-256":
+            curve = elliptic.P256()
+        case "P-384":
+            curve = elliptic.P384()
+        case "P-521":
+            curve = elliptic.P521()
+        default:
+            return nil
+        }
+        x, errX := base64.RawURLEncoding.DecodeString(k.X)
+        y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+        if errX != nil || errY != nil {
+            return nil
+        }
+        key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+        if !curve.IsOnCurve(key.X, key.Y) {
+            return nil
+        }
+        return key
+    case "OKP":
+        x, err := base64.RawURLEncoding.DecodeString(k.X)
+        if k.Crv != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+            return nil
+        }
+        return ed25519.PublicKey(x)
+    }
+    return nil
+}
+
+// NewJSONWebKey mã hóa public key thành JWK để công bố trong JWKS (RSA, EC hoặc Ed25519)
+func NewJSONWebKey(kid, alg string, publicKey interface{}) (JSONWebKey, error) {
+    jwk := JSONWebKey{Kid: kid, Use: "sig", Alg: alg}
+    switch key := publicKey.(type) {
+    case *rsa.PublicKey:
+        jwk.Kty = "RSA"
+        jwk.N = base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+        jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+    case *ecdsa.PublicKey:
+        size := (key.Curve.Params().BitSize + 7) / 8
+        jwk.Kty = "EC"
+        jwk.Crv = key.Curve.Params().Name
+        jwk.X = base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, size)))
+        jwk.Y = base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, size)))
+    case ed25519.PublicKey:
+        jwk.Kty = "OKP"
+        jwk.Crv = "Ed25519"
+        jwk.X = base64.RawURLEncoding.EncodeToString(key)
+    default:
+        return JSONWebKey{}, errors.New("unsupported public key type")
+    }
+    return jwk, nil
+}
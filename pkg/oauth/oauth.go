@@ -0,0 +1,152 @@
+package oauth
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "net/url"
+    "os"
+    "strings"
+    "time"
+)
+
+// ErrProviderResponse được trả về khi nhà cung cấp định danh từ chối code hoặc trả dữ liệu không hợp lệ
+var ErrProviderResponse = errors.New("identity provider rejected the request")
+
+// Identity là thông tin user lấy từ nhà cung cấp định danh sau khi đăng nhập thành công
+type Identity struct {
+    Provider      string
+    Subject       string // ID ổn định của user phía nhà cung cấp
+    Email         string
+    EmailVerified bool
+    Name          string
+    Username      string // preferred_username (OIDC) hoặc login (GitHub), dùng để gợi ý username
+    AvatarURL     string
+}
+
+// Provider là một nhà cung cấp định danh theo luồng OAuth2 authorization code
+type Provider interface {
+    Name() string
+    // AuthCodeURL trả về URL chuyển user sang trang đăng nhập của nhà cung cấp
+    AuthCodeURL(ctx context.Context, state, nonce, redirectURI string) (string, error)
+    // Exchange đổi authorization code lấy thông tin user; nonce phải khớp với nonce đã gửi ở AuthCodeURL
+    Exchange(ctx context.Context, code, nonce, redirectURI string) (*Identity, error)
+}
+
+// NewProvidersFromEnv tạo các provider được cấu hình qua biến môi trường; provider thiếu client ID bị bỏ qua
+func NewProvidersFromEnv() map[string]Provider {
+    providers := make(map[string]Provider)
+
+    if clientID := os.Getenv("OAUTH_GOOGLE_CLIENT_ID"); clientID != "" {
+        p := NewGoogleProvider(clientID, os.Getenv("OAUTH_GOOGLE_CLIENT_SECRET"))
+        providers[p.Name()] = p
+    }
+    if clientID := os.Getenv("OAUTH_GITHUB_CLIENT_ID"); clientID != "" {
+        p := NewGitHubProvider(clientID, os.Getenv("OAUTH_GITHUB_CLIENT_SECRET"))
+        providers[p.Name()] = p
+    }
+    if issuer := os.Getenv("OIDC_ISSUER_URL"); issuer != "" {
+        p := &OIDCProvider{
+            ProviderName: getEnv("OIDC_PROVIDER_NAME", "oidc"),
+            IssuerURL:    issuer,
+            ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+            ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+            Scopes:       strings.Fields(getEnv("OIDC_SCOPES", "openid email profile")),
+        }
+        providers[p.Name()] = p
+    }
+
+    return providers
+}
+
+var defaultHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+func httpClient(c *http.Client) *http.Client {
+    if c != nil {
+        return c
+    }
+    return defaultHTTPClient
+}
+
+type tokenResponse struct {
+    AccessToken string `json:"access_token"`
+    TokenType   string `json:"token_type"`
+    IDToken     string `json:"id_token"`
+    Error       string `json:"error"`
+    ErrorDesc   string `json:"error_description"`
+}
+
+// exchangeCode gọi token endpoint theo RFC 6749 §4.1.3
+func exchangeCode(ctx context.Context, client *http.Client, tokenURL, clientID, clientSecret, code, redirectURI string) (*tokenResponse, error) {
+    form := url.Values{
+        "grant_type":    {"authorization_code"},
+        "code":          {code},
+        "redirect_uri":  {redirectURI},
+        "client_id":     {clientID},
+        "client_secret": {clientSecret},
+    }
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+    if err != nil {
+        return nil, err
+    }
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    req.Header.Set("Accept", "application/json")
+
+    var token tokenResponse
+    if err := doJSON(httpClient(client), req, &token, true); err != nil {
+        return nil, err
+    }
+    if token.Error != "" {
+        return nil, fmt.Errorf("%w: %s %s", ErrProviderResponse, token.Error, token.ErrorDesc)
+    }
+    if token.AccessToken == "" {
+        return nil, fmt.Errorf("%w: missing access token", ErrProviderResponse)
+    }
+    return &token, nil
+}
+
+// getJSON gọi API của nhà cung cấp bằng access token
+func getJSON(ctx context.Context, client *http.Client, endpoint, accessToken string, out interface{}) error {
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Accept", "application/json")
+    if accessToken != "" {
+        req.Header.Set("Authorization", "Bearer "+accessToken)
+    }
+    return doJSON(httpClient(client), req, out, false)
+}
+
+// doJSON gửi request và decode JSON. Token endpoint trả lỗi OAuth dạng JSON với status 400,
+// khi oauthErrors = true vẫn decode để lấy mã lỗi.
+func doJSON(client *http.Client, req *http.Request, out interface{}, oauthErrors bool) error {
+    resp, err := client.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+
+    body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+    if err != nil {
+        return err
+    }
+    if resp.StatusCode >= 300 && !(oauthErrors && resp.StatusCode == http.StatusBadRequest) {
+        return fmt.Errorf("%w: %s returned %d", ErrProviderResponse, req.URL.Host, resp.StatusCode)
+    }
+    if err := json.Unmarshal(body, out); err != nil {
+        return fmt.Errorf("%w: invalid JSON from %s", ErrProviderResponse, req.URL.Host)
+    }
+    return nil
+}
+
+func getEnv(key, defaultValue string) string {
+    value := os.Getenv(key)
+    if value == "" {
+        return defaultValue
+    }
+    return value
+}
@@ -0,0 +1,246 @@
+package oauth
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net/http"
+    "net/url"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/golang-jwt/jwt/v5"
+)
+
+const (
+    discoveryTTL     = 24 * time.Hour
+    jwksMinRefresh   = time.Minute // Không tải lại JWKS quá một lần mỗi phút khi gặp kid lạ
+    idTokenClockSkew = time.Minute
+)
+
+var idTokenAlgorithms = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// OIDCProvider là provider OpenID Connect tổng quát, tự lấy endpoint qua discovery
+// (<issuer>/.well-known/openid-configuration) và xác thực ID token bằng JWKS của issuer
+type OIDCProvider struct {
+    ProviderName string
+    IssuerURL    string
+    ClientID     string
+    ClientSecret string
+    Scopes       []string
+    HTTPClient   *http.Client // nil thì dùng client mặc định (timeout 10s)
+
+    mu            sync.Mutex
+    discovery     *discoveryDocument
+    discoveredAt  time.Time
+    keys          map[string]interface{}
+    keysFetchedAt time.Time
+}
+
+type discoveryDocument struct {
+    Issuer                string `json:"issuer"`
+    AuthorizationEndpoint string `json:"authorization_endpoint"`
+    TokenEndpoint         string `json:"token_endpoint"`
+    UserinfoEndpoint      string `json:"userinfo_endpoint"`
+    JWKSURI               string `json:"jwks_uri"`
+}
+
+type idTokenClaims struct {
+    Nonce             string   `json:"nonce"`
+    Email             string   `json:"email"`
+    EmailVerified     jsonBool `json:"email_verified"`
+    Name              string   `json:"name"`
+    PreferredUsername string   `json:"preferred_username"`
+    Picture           string   `json:"picture"`
+    jwt.RegisteredClaims
+}
+
+// jsonBool chấp nhận cả true và "true" (một số provider trả email_verified dạng chuỗi)
+type jsonBool bool
+
+func (b *jsonBool) UnmarshalJSON(data []byte) error {
+    *b = jsonBool(strings.Trim(string(data), `"`) == "true")
+    return nil
+}
+
+// NewGoogleProvider tạo provider Google (Google là một OIDC issuer chuẩn)
+func NewGoogleProvider(clientID, clientSecret string) *OIDCProvider {
+    return &OIDCProvider{
+        ProviderName: "google",
+        IssuerURL:    "https://accounts.google.com",
+        ClientID:     clientID,
+        ClientSecret: clientSecret,
+        Scopes:       []string{"openid", "email", "profile"},
+    }
+}
+
+func (p *OIDCProvider) Name() string {
+    return p.ProviderName
+}
+
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state, nonce, redirectURI string) (string, error) {
+    doc, err := p.getDiscovery(ctx)
+    if err != nil {
+        return "", err
+    }
+
+    query := url.Values{
+        "response_type": {"code"},
+        "client_id":     {p.ClientID},
+        "redirect_uri":  {redirectURI},
+        "scope":         {strings.Join(p.Scopes, " ")},
+        "state":         {state},
+        "nonce":         {nonce},
+    }
+    return appendQuery(doc.AuthorizationEndpoint, query), nil
+}
+
+func (p *OIDCProvider) Exchange(ctx context.Context, code, nonce, redirectURI string) (*Identity, error) {
+    doc, err := p.getDiscovery(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    token, err := exchangeCode(ctx, p.HTTPClient, doc.TokenEndpoint, p.ClientID, p.ClientSecret, code, redirectURI)
+    if err != nil {
+        return nil, err
+    }
+    if token.IDToken == "" {
+        return nil, fmt.Errorf("%w: missing id_token", ErrProviderResponse)
+    }
+
+    claims, err := p.verifyIDToken(ctx, doc, token.IDToken, nonce)
+    if err != nil {
+        return nil, err
+    }
+
+    identity := &Identity{
+        Provider:      p.ProviderName,
+        Subject:       claims.Subject,
+        Email:         claims.Email,
+        EmailVerified: bool(claims.EmailVerified),
+        Name:          claims.Name,
+        Username:      claims.PreferredUsername,
+        AvatarURL:     claims.Picture,
+    }
+
+    // Một số issuer không đưa email vào ID token, khi đó lấy thêm từ userinfo endpoint
+    if identity.Email == "" && doc.UserinfoEndpoint != "" {
+        var info idTokenClaims
+        if err := getJSON(ctx, p.HTTPClient, doc.UserinfoEndpoint, token.AccessToken, &info); err != nil {
+            return nil, err
+        }
+        if info.Subject == claims.Subject {
+            identity.Email = info.Email
+            identity.EmailVerified = bool(info.EmailVerified)
+            if identity.Name == "" {
+                identity.Name = info.Name
+            }
+            if identity.Username == "" {
+                identity.Username = info.PreferredUsername
+            }
+            if identity.AvatarURL == "" {
+                identity.AvatarURL = info.Picture
+            }
+        }
+    }
+
+    return identity, nil
+}
+
+// verifyIDToken kiểm tra chữ ký (theo kid trong JWKS), iss, aud, exp và nonce của ID token
+func (p *OIDCProvider) verifyIDToken(ctx context.Context, doc *discoveryDocument, rawToken, nonce string) (*idTokenClaims, error) {
+    claims := &idTokenClaims{}
+    _, err := jwt.ParseWithClaims(rawToken, claims, func(token *jwt.Token) (interface{}, error) {
+        kid, _ := token.Header["kid"].(string)
+        return p.getKey(ctx, doc, kid)
+    },
+        jwt.WithValidMethods(idTokenAlgorithms),
+        jwt.WithIssuer(doc.Issuer),
+        jwt.WithAudience(p.ClientID),
+        jwt.WithLeeway(idTokenClockSkew),
+    )
+    if err != nil {
+        return nil, fmt.Errorf("%w: invalid id_token: %v", ErrProviderResponse, err)
+    }
+    if claims.ExpiresAt == nil || claims.Subject == "" {
+        return nil, fmt.Errorf("%w: id_token missing exp or sub", ErrProviderResponse)
+    }
+    if claims.Nonce != nonce {
+        return nil, fmt.Errorf("%w: id_token nonce mismatch", ErrProviderResponse)
+    }
+    return claims, nil
+}
+
+func (p *OIDCProvider) getDiscovery(ctx context.Context) (*discoveryDocument, error) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+
+    if p.discovery != nil && time.Since(p.discoveredAt) < discoveryTTL {
+        return p.discovery, nil
+    }
+
+    issuer := strings.TrimRight(p.IssuerURL, "/")
+    var doc discoveryDocument
+    if err := getJSON(ctx, p.HTTPClient, issuer+"/.well-known/openid-configuration", "", &doc); err != nil {
+        return nil, err
+    }
+    // OIDC Discovery §4.3: issuer trong tài liệu phải trùng với issuer đã cấu hình
+    if strings.TrimRight(doc.Issuer, "/") != issuer {
+        return nil, fmt.Errorf("%w: discovery issuer %q does not match %q", ErrProviderResponse, doc.Issuer, p.IssuerURL)
+    }
+    if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+        return nil, fmt.Errorf("%w: incomplete discovery document", ErrProviderResponse)
+    }
+
+    p.discovery = &doc
+    p.discoveredAt = time.Now()
+    return p.discovery, nil
+}
+
+// getKey trả public key theo kid; gặp kid chưa biết (issuer vừa xoay khóa) thì tải lại JWKS
+func (p *OIDCProvider) getKey(ctx context.Context, doc *discoveryDocument, kid string) (interface{}, error) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+
+    if key, ok := p.lookupKey(kid); ok {
+        return key, nil
+    }
+    if p.keys != nil && time.Since(p.keysFetchedAt) < jwksMinRefresh {
+        return nil, errors.New("unknown signing key")
+    }
+
+    var set JSONWebKeySet
+    if err := getJSON(ctx, p.HTTPClient, doc.JWKSURI, "", &set); err != nil {
+        return nil, err
+    }
+    p.keys = set.PublicKeys()
+    p.keysFetchedAt = time.Now()
+
+    if key, ok := p.lookupKey(kid); ok {
+        return key, nil
+    }
+    return nil, errors.New("unknown signing key")
+}
+
+// lookupKey tìm key theo kid; token không có kid chỉ dùng được khi JWKS có đúng một key
+func (p *OIDCProvider) lookupKey(kid string) (interface{}, bool) {
+    if kid != "" {
+        key, ok := p.keys[kid]
+        return key, ok
+    }
+    if len(p.keys) == 1 {
+        for _, key := range p.keys {
+            return key, true
+        }
+    }
+    return nil, false
+}
+
+func appendQuery(endpoint string, query url.Values) string {
+    if strings.Contains(endpoint, "?") {
+        return endpoint + "&" + query.Encode()
+    }
+    return endpoint + "?" + query.Encode()
+}
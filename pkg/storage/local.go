@@ -0,0 +1,96 @@
+package storage
+
+import (
+    "errors"
+    "io"
+    "os"
+    "path"
+    "path/filepath"
+    "strings"
+)
+
+// LocalPublicPath là prefix route phục vụ file của LocalStorage
+const LocalPublicPath = "/files"
+
+// LocalStorage lưu file trên filesystem, dùng khi phát triển hoặc chạy một instance
+type LocalStorage struct {
+    Dir     string
+    BaseURL string
+}
+
+func (s *LocalStorage) Put(key string, r io.Reader, contentType string) error {
+    target, err := s.path(key)
+    if err != nil {
+        return err
+    }
+    if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+        return err
+    }
+
+    // Ghi ra file tạm rồi rename để không ai đọc được file ghi dở
+    tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+    if err != nil {
+        return err
+    }
+    defer os.Remove(tmp.Name())
+
+    if _, err := io.Copy(tmp, r); err != nil {
+        tmp.Close()
+        return err
+    }
+    if err := tmp.Close(); err != nil {
+        return err
+    }
+    if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+        return err
+    }
+    return os.Rename(tmp.Name(), target)
+}
+
+func (s *LocalStorage) Open(key string) (io.ReadCloser, error) {
+    target, err := s.path(key)
+    if err != nil {
+        return nil, err
+    }
+    file, err := os.Open(target)
+    if errors.Is(err, os.ErrNotExist) {
+        return nil, ErrNotFound
+    }
+    return file, err
+}
+
+func (s *LocalStorage) Exists(key string) (bool, error) {
+    target, err := s.path(key)
+    if err != nil {
+        return false, err
+    }
+    _, err = os.Stat(target)
+    if errors.Is(err, os.ErrNotExist) {
+        return false, nil
+    }
+    return err == nil, err
+}
+
+func (s *LocalStorage) Delete(key string) error {
+    target, err := s.path(key)
+    if err != nil {
+        return err
+    }
+    if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+        return err
+    }
+    return nil
+}
+
+func (s *LocalStorage) URL(key string) string {
+    return s.BaseURL + "/" + key
+}
+
+// path chuyển key thành đường dẫn trong Dir, chặn key thoát ra ngoài thư mục gốc
+func (s *LocalStorage) path(key string) (string, error) {
+    cleaned := path.Clean("/" + key)
+    if cleaned == "/" || strings.Contains(key, "\\") {
+        return "", errors.New("invalid storage key")
+    }
+    return filepath.Join(s.Dir, filepath.FromSlash(cleaned)), nil
+}
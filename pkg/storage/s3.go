@@ -0,0 +1,50 @@
+package storage
+
+import (
+    "io"
+    "strings"
+)
+
+// S3Client là tập thao tác tối thiểu cần từ một client S3-compatible (AWS S3, MinIO, R2...).
+// Có thể bọc SDK bất kỳ theo interface này khi cần chuyển sang S3.
+type S3Client interface {
+    PutObject(bucket, key string, body io.Reader, contentType string) error
+    GetObject(bucket, key string) (io.ReadCloser, error)
+    HeadObject(bucket, key string) (bool, error)
+    DeleteObject(bucket, key string) error
+}
+
+// S3Storage lưu file trên bucket S3-compatible thông qua S3Client
+type S3Storage struct {
+    Client  S3Client
+    Bucket  string
+    Prefix  string // Thư mục con trong bucket, vd: "uploads"
+    BaseURL string // CDN hoặc endpoint public của bucket
+}
+
+func (s *S3Storage) Put(key string, r io.Reader, contentType string) error {
+    return s.Client.PutObject(s.Bucket, s.objectKey(key), r, contentType)
+}
+
+func (s *S3Storage) Open(key string) (io.ReadCloser, error) {
+    return s.Client.GetObject(s.Bucket, s.objectKey(key))
+}
+
+func (s *S3Storage) Exists(key string) (bool, error) {
+    return s.Client.HeadObject(s.Bucket, s.objectKey(key))
+}
+
+func (s *S3Storage) Delete(key string) error {
+    return s.Client.DeleteObject(s.Bucket, s.objectKey(key))
+}
+
+func (s *S3Storage) URL(key string) string {
+    return strings.TrimRight(s.BaseURL, "/") + "/" + s.objectKey(key)
+}
+
+func (s *S3Storage) objectKey(key string) string {
+    if s.Prefix == "" {
+        return key
+    }
+    return strings.Trim(s.Prefix, "/") + "/" + key
+}
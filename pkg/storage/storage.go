@@ -0,0 +1,45 @@
+package storage
+
+import (
+    "errors"
+    "io"
+    "os"
+    "strings"
+)
+
+// ErrNotFound được trả về khi key không tồn tại trong storage
+var ErrNotFound = errors.New("file not found")
+
+// Storage là abstraction lưu file upload, cho phép thay filesystem local bằng S3 mà không đổi service
+type Storage interface {
+    // Put ghi nội dung vào key, ghi đè nếu đã tồn tại
+    Put(key string, r io.Reader, contentType string) error
+    Open(key string) (io.ReadCloser, error)
+    Exists(key string) (bool, error)
+    Delete(key string) error
+    // URL trả về địa chỉ public để client tải file
+    URL(key string) string
+}
+
+// NewFromEnv tạo storage theo STORAGE_DRIVER; hiện chỉ hỗ trợ "local" (mặc định)
+func NewFromEnv() Storage {
+    switch getEnv("STORAGE_DRIVER", "local") {
+    default:
+        baseURL := os.Getenv("UPLOAD_BASE_URL")
+        if baseURL == "" {
+            baseURL = strings.TrimRight(getEnv("APP_BASE_URL", "http://localhost:8080"), "/") + LocalPublicPath
+        }
+        return &LocalStorage{
+            Dir:     getEnv("UPLOAD_DIR", "./uploads"),
+            BaseURL: strings.TrimRight(baseURL, "/"),
+        }
+    }
+}
+
+func getEnv(key, defaultValue string) string {
+    value := os.Getenv(key)
+    if value == "" {
+        return defaultValue
+    }
+    return value
+}
@@ -1,60 +1,205 @@
 package utils
 
 import (
+    "crypto"
+    "errors"
     "fmt"
     "log"
     "os"
+    "sync"
     "time"
 
     "github.com/golang-jwt/jwt/v5"
     "github.com/google/uuid"
 )
 
+// TokenTTL là thời gian sống của JWT đăng nhập
+const TokenTTL = 72 * time.Hour
+
 type Claims struct {
     UserID uuid.UUID `json:"user_id"`
     jwt.RegisteredClaims
 }
 
-func GenerateToken(userID uuid.UUID) (string, error) {
-    secret := os.Getenv("JWT_SECRET")
-    if secret == "" {
-        return "", fmt.Errorf("JWT_SECRET environment variable is not set")
+// SigningKey là một khóa bất đối xứng (RS256 hoặc EdDSA) định danh bằng kid.
+// Private nil nghĩa là khóa chỉ còn dùng để xác thực token cũ, hoặc chưa tới lúc dùng để ký.
+type SigningKey struct {
+    ID        string
+    Algorithm string
+    Private   crypto.Signer
+    Public    crypto.PublicKey
+}
+
+// keyRing giữ khóa đang ký và các khóa còn được chấp nhận, do SigningKeyService nạp từ DB
+var keyRing struct {
+    sync.RWMutex
+    signing *SigningKey
+    verify  map[string]SigningKey
+}
+
+// SetSigningKeys thay toàn bộ khóa: signing dùng để ký token mới, verify gồm mọi khóa còn hợp lệ (kể cả signing)
+func SetSigningKeys(signing *SigningKey, verify []SigningKey) {
+    keys := make(map[string]SigningKey, len(verify))
+    for _, key := range verify {
+        keys[key.ID] = key
+    }
+
+    keyRing.Lock()
+    defer keyRing.Unlock()
+    keyRing.signing = signing
+    keyRing.verify = keys
+}
+
+// VerificationKeys trả các khóa đang được chấp nhận, dùng để công bố JWKS
+func VerificationKeys() []SigningKey {
+    keyRing.RLock()
+    defer keyRing.RUnlock()
+
+    keys := make([]SigningKey, 0, len(keyRing.verify))
+    for _, key := range keyRing.verify {
+        keys = append(keys, SigningKey{ID: key.ID, Algorithm: key.Algorithm, Public: key.Public})
+    }
+    return keys
+}
+
+// JWTAlgorithm là thuật toán ký token mới: HS256 (mặc định, dùng JWT_SECRET), RS256 hoặc EdDSA
+func JWTAlgorithm() string {
+    switch alg := os.Getenv("JWT_ALGORITHM"); alg {
+    case "RS256", "EdDSA":
+        return alg
+    default:
+        return "HS256"
     }
+}
+
+// JWTIssuer là giá trị claim iss của token do VieTick phát hành
+func JWTIssuer() string {
+    if issuer := os.Getenv("JWT_ISSUER"); issuer != "" {
+        return issuer
+    }
+    return "vietick"
+}
+
+// JWTAudience là giá trị claim aud mà API chấp nhận
+func JWTAudience() string {
+    if audience := os.Getenv("JWT_AUDIENCE"); audience != "" {
+        return audience
+    }
+    return "vietick-api"
+}
 
+func GenerateToken(userID uuid.UUID) (string, error) {
+    now := time.Now()
     claims := Claims{
         UserID: userID,
         RegisteredClaims: jwt.RegisteredClaims{
-            ExpiresAt: jwt.NewNumericDate(time.Now().Add(72 * time.Hour)),
+            Issuer:    JWTIssuer(),
+            Audience:  jwt.ClaimStrings{JWTAudience()},
+            IssuedAt:  jwt.NewNumericDate(now),
+            ExpiresAt: jwt.NewNumericDate(now.Add(TokenTTL)),
         },
     }
-    token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-    return token.SignedString([]byte(secret))
+
+    if JWTAlgorithm() == "HS256" {
+        secret := os.Getenv("JWT_SECRET")
+        if secret == "" {
+            return "", fmt.Errorf("JWT_SECRET environment variable is not set")
+        }
+        token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+        return token.SignedString([]byte(secret))
+    }
+
+    keyRing.RLock()
+    key := keyRing.signing
+    keyRing.RUnlock()
+    if key == nil || key.Private == nil {
+        return "", errors.New("no active JWT signing key")
+    }
+
+    token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+    token.Header["kid"] = key.ID
+    return token.SignedString(key.Private)
 }
 
 func ParseToken(tokenString string) (*Claims, error) {
-    secret := os.Getenv("JWT_SECRET")
-    if secret == "" {
-        return nil, fmt.Errorf("JWT_SECRET environment variable is not set")
+    options := []jwt.ParserOption{jwt.WithValidMethods([]string{"HS256", "RS256", "EdDSA"})}
+    acceptLegacy := acceptLegacyClaims()
+    if !acceptLegacy {
+        options = append(options, jwt.WithIssuer(JWTIssuer()), jwt.WithAudience(JWTAudience()))
     }
-
-    log.Printf("Parsing token with secret length: %d", len(secret))
-    
-    token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-        if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-            return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-        }
-        return []byte(secret), nil
-    })
+    token, err := jwt.ParseWithClaims(tokenString, &Claims{}, lookupVerificationKey, options...)
 
     if err != nil {
         log.Printf("Token parsing error: %v", err)
         return nil, err
     }
 
-    if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-        log.Printf("Token is valid, user_id: %s", claims.UserID)
+    if claims, ok := token.Claims.(*Claims); ok && token.Valid && claims.ExpiresAt != nil {
+        if acceptLegacy && !legacyOrExpectedClaims(claims) {
+            return nil, fmt.Errorf("invalid token issuer or audience")
+        }
         return claims, nil
     }
 
     return nil, fmt.Errorf("invalid token claims")
-} 
\ No newline at end of file
+}
+
+// acceptLegacyClaims: mặc định vẫn chấp nhận token không có iss/aud (phát hành trước khi có hai claim này) để
+// user không bị đăng xuất khi nâng cấp. JWT_STRICT_CLAIMS=true bắt buộc iss/aud ngay; JWT_LEGACY_CLAIMS_UNTIL
+// (RFC 3339) là mốc ngừng chấp nhận token cũ, thường đặt sau thời điểm deploy một TokenTTL. Mốc sai định dạng
+// được coi như đã qua để không vô tình mở rộng thời gian chấp nhận.
+func acceptLegacyClaims() bool {
+    if os.Getenv("JWT_STRICT_CLAIMS") == "true" {
+        return false
+    }
+    until := os.Getenv("JWT_LEGACY_CLAIMS_UNTIL")
+    if until == "" {
+        return true
+    }
+    cutoff, err := time.Parse(time.RFC3339, until)
+    if err != nil {
+        log.Printf("Invalid JWT_LEGACY_CLAIMS_UNTIL %q, requiring iss/aud: %v", until, err)
+        return false
+    }
+    return time.Now().Before(cutoff)
+}
+
+// legacyOrExpectedClaims: token không có cả iss lẫn aud là token cũ; token có một trong hai phải đúng cả hai
+func legacyOrExpectedClaims(claims *Claims) bool {
+    if claims.Issuer == "" && len(claims.Audience) == 0 {
+        return true
+    }
+    if claims.Issuer != JWTIssuer() {
+        return false
+    }
+    for _, audience := range claims.Audience {
+        if audience == JWTAudience() {
+            return true
+        }
+    }
+    return false
+}
+
+// lookupVerificationKey chọn khóa theo kid. Token HS256 chỉ được chấp nhận khi đang dùng HS256,
+// hoặc khi JWT_ACCEPT_HS256=true trong thời gian chuyển sang khóa bất đối xứng.
+func lookupVerificationKey(token *jwt.Token) (interface{}, error) {
+    if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+        secret := os.Getenv("JWT_SECRET")
+        if secret == "" || (JWTAlgorithm() != "HS256" && os.Getenv("JWT_ACCEPT_HS256") != "true") {
+            return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+        }
+        return []byte(secret), nil
+    }
+
+    kid, _ := token.Header["kid"].(string)
+    keyRing.RLock()
+    key, ok := keyRing.verify[kid]
+    keyRing.RUnlock()
+    if !ok {
+        return nil, errors.New("unknown signing key")
+    }
+    if key.Algorithm != token.Method.Alg() {
+        return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+    }
+    return key.Public, nil
+}
@@ -1,15 +1,26 @@
 package routes
 
 import (
+    "log"
+    "os"
+    "strings"
+
     "github.com/gin-gonic/gin"
     "vietick/internal/controllers"
     "vietick/internal/middleware"
+    "vietick/internal/models"
     "vietick/internal/services"
+    "vietick/pkg/storage"
 )
 
 func SetupRouter() *gin.Engine {
     r := gin.Default()
 
+    // ClientIP (view dedup, token last-used IP, logs) only honours X-Forwarded-For from these proxies
+    if err := r.SetTrustedProxies(trustedProxies()); err != nil {
+        log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+    }
+
     // Add CORS middleware
     r.Use(middleware.CORSMiddleware())
 
@@ -24,28 +35,155 @@ func SetupRouter() *gin.Engine {
     tagController := controllers.NewTagController()
     searchController := controllers.NewSearchController()
     followController := controllers.NewFollowController()
+    feedController := controllers.NewFeedController()
+    watchController := controllers.NewWatchController()
+    bookmarkController := controllers.NewBookmarkController()
+    badgeController := controllers.NewBadgeController()
+    leaderboardController := controllers.NewLeaderboardController()
+    bountyController := controllers.NewBountyController()
+    privilegeController := controllers.NewPrivilegeController()
+    profileController := controllers.NewProfileController()
+    uploadController := controllers.NewUploadController()
+    oauthController := controllers.NewOAuthController()
+    twoFactorController := controllers.NewTwoFactorController()
+    jwksController := controllers.NewJWKSController()
+    personalTokenController := controllers.NewPersonalTokenController()
+    notificationController := controllers.NewNotificationController()
+    jobController := controllers.NewJobController(services.DefaultJobQueue())
+
+    // Uploaded files (local storage only; other backends serve files from their own URL)
+    if local, ok := services.DefaultStorage().(*storage.LocalStorage); ok {
+        r.Static(storage.LocalPublicPath, local.Dir)
+    }
 
     // Public routes
+    r.GET("/.well-known/jwks.json", jwksController.GetJWKS)
     r.POST("/register", userController.Register)
     r.POST("/login", userController.Login)
+    r.POST("/auth/verify-email", userController.VerifyEmail)
+    r.POST("/auth/forgot-password", userController.ForgotPassword)
+    r.POST("/auth/reset-password", userController.ResetPassword)
+    r.GET("/email/unsubscribe", notificationController.UnsubscribeDigestPage)
+    r.POST("/email/unsubscribe", notificationController.UnsubscribeDigest)
+    r.GET("/auth/oauth/providers", oauthController.GetProviders)
+    r.GET("/auth/oauth/:provider/authorize", oauthController.Authorize)
+    r.POST("/auth/oauth/:provider/callback", oauthController.Callback)
+    r.POST("/auth/oauth/signup", oauthController.Signup)
+    r.POST("/auth/2fa/verify", twoFactorController.Verify)
+    r.POST("/auth/2fa/enroll", twoFactorController.Enroll)
+    r.POST("/auth/2fa/enroll/confirm", twoFactorController.ConfirmEnrollment)
+
+    // Personal access tokens may only call the routes below; GET routes need the read scope,
+    // except the session-only ones. Everything else requires a login JWT.
+    tokenScopes := map[string][]models.TokenScope{
+        "POST /questions":              {models.ScopeWriteQuestions},
+        "PUT /questions/:id":           {models.ScopeWriteQuestions},
+        "DELETE /questions/:id":        {models.ScopeWriteQuestions},
+        "POST /uploads":                {models.ScopeWriteQuestions, models.ScopeWriteAnswers},
+        "POST /questions/:id/answers":  {models.ScopeWriteAnswers},
+        "POST /answers/:id/verify":     {models.ScopeWriteAnswers},
+        "POST /answers/:id/vote/:type": {models.ScopeVote},
+    }
+    sessionOnly := []string{
+        "GET /users/me/export",
+        "GET /users/me/identities",
+        "GET /users/me/identities/:provider/authorize",
+        "GET /users/me/identities/:provider/reauth/authorize",
+        "GET /users/me/tokens",
+        "GET /auth/2fa",
+    }
+
+    tokenScope := middleware.TokenScopeMiddleware(tokenScopes, sessionOnly...)
+
+    // Public read-only routes; a token is optional and only adds viewer-specific data
+    public := r.Group("/")
+    public.Use(middleware.OptionalAuthMiddleware(), tokenScope)
+    {
+        public.GET("/questions", questionController.GetQuestions)
+        public.GET("/questions/trending", questionController.GetTrendingQuestions)
+        public.GET("/questions/featured", bountyController.GetFeaturedQuestions)
+        public.GET("/questions/:id", questionController.GetQuestionByID)
+        public.GET("/questions/:id/answers", answerController.GetAnswers)
+        public.GET("/answers/:id/votes", voteController.GetVotes)
+
+        public.GET("/tags", tagController.GetTags)
+        public.GET("/tags/:id", tagController.GetTagByID)
+
+        public.GET("/users/by-username/:username", profileController.GetUserProfileByUsername)
+        public.GET("/users/:id", profileController.GetUserProfile)
+        public.GET("/users/:id/questions", profileController.GetUserQuestions)
+        public.GET("/users/:id/answers", profileController.GetUserAnswers)
+        public.GET("/users/:id/tags", profileController.GetUserTopTags)
+        public.GET("/users/:id/activity", profileController.GetUserActivity)
+
+        public.GET("/badges", badgeController.GetBadges)
+        public.GET("/users/:id/badges", badgeController.GetUserBadges)
+        public.GET("/leaderboard", leaderboardController.GetLeaderboard)
+        public.GET("/privileges", privilegeController.GetPrivileges)
+
+        public.GET("/collections/:id", bookmarkController.GetCollection)
+        public.GET("/collections/:id/bookmarks", bookmarkController.GetCollectionBookmarks)
+
+        // Search routes
+        searchGroup := public.Group("/search")
+        {
+            searchGroup.GET("/questions", searchController.SearchQuestions)     // GET /search/questions?q=query
+            searchGroup.GET("/tags", searchController.SearchTags)               // GET /search/tags?q=query
+            searchGroup.GET("/questions/tag/:tag", searchController.GetQuestionsByTag) // GET /search/questions/tag/:tag
+        }
+    }
 
     // Protected routes
     protected := r.Group("/")
-    protected.Use(middleware.AuthMiddleware())
+    protected.Use(middleware.AuthMiddleware(), tokenScope)
+    requireVerified := middleware.VerifiedEmailMiddleware()
+    requireAdmin := middleware.RoleMiddleware(models.RoleAdmin)
     {
         // User routes
         protected.GET("/users/me", userController.GetProfile)
+        protected.PATCH("/users/me", userController.UpdateProfile)
+        protected.DELETE("/users/me", userController.DeleteAccount)
+        protected.GET("/users/me/export", userController.ExportData)
+        protected.PUT("/users/me/password", userController.ChangePassword)
+        protected.PUT("/users/me/email", userController.ChangeEmail)
+        protected.PUT("/users/me/username", userController.ChangeUsername)
+        protected.POST("/users/me/avatar", uploadController.UploadAvatar)
+        protected.DELETE("/users/me/avatar", uploadController.DeleteAvatar)
+        protected.GET("/users/me/identities", oauthController.GetMyIdentities)
+        protected.GET("/users/me/identities/:provider/authorize", oauthController.AuthorizeLink)
+        protected.POST("/users/me/identities/:provider", oauthController.LinkIdentity)
+        protected.GET("/users/me/identities/:provider/reauth/authorize", oauthController.AuthorizeReauth)
+        protected.POST("/users/me/identities/:provider/reauth", oauthController.Reauthenticate)
+        protected.DELETE("/users/me/identities/:provider", oauthController.UnlinkIdentity)
+        protected.GET("/users/me/tokens", personalTokenController.GetMyTokens)
+        protected.POST("/users/me/tokens", personalTokenController.CreateToken)
+        protected.DELETE("/users/me/tokens/:id", personalTokenController.RevokeToken)
+        protected.GET("/users/:id/collections", bookmarkController.GetUserCollections)
+        protected.POST("/auth/resend-verification", userController.ResendVerification)
+        protected.GET("/auth/2fa", twoFactorController.GetStatus)
+        protected.POST("/auth/2fa/setup", twoFactorController.Setup)
+        protected.POST("/auth/2fa/enable", twoFactorController.Enable)
+        protected.POST("/auth/2fa/disable", twoFactorController.Disable)
+        protected.POST("/auth/2fa/recovery-codes", twoFactorController.RegenerateRecoveryCodes)
 
         // Question routes
-        protected.POST("/questions", questionController.CreateQuestion)
-        protected.GET("/questions", questionController.GetQuestions)
-        protected.GET("/questions/:id", questionController.GetQuestionByID)
-        protected.PUT("/questions/:id", questionController.UpdateQuestion)
+        protected.POST("/questions", requireVerified, questionController.CreateQuestion)
+        protected.PUT("/questions/:id", requireVerified, questionController.UpdateQuestion)
         protected.DELETE("/questions/:id", questionController.DeleteQuestion)
+        protected.POST("/questions/:id/watch", watchController.WatchQuestion)
+        protected.DELETE("/questions/:id/watch", watchController.UnwatchQuestion)
+        protected.POST("/questions/:id/bookmark", bookmarkController.BookmarkQuestion)
+        protected.DELETE("/questions/:id/bookmark", bookmarkController.RemoveBookmark)
+        protected.POST("/questions/:id/bounty", bountyController.StartBounty)
+        protected.DELETE("/questions/:id/bounty", bountyController.CancelBounty)
+        protected.POST("/questions/:id/bounty/award", bountyController.AwardBounty)
+
+        // Upload routes
+        protected.POST("/uploads", requireVerified, uploadController.UploadAttachment)
+        protected.GET("/uploads/:id", uploadController.GetUpload)
 
         // Answer routes
-        protected.POST("/questions/:id/answers", answerController.CreateAnswer)
-        protected.GET("/questions/:id/answers", answerController.GetAnswers)
+        protected.POST("/questions/:id/answers", requireVerified, answerController.CreateAnswer)
 
         // Answer group for specific answer operations
         answerGroup := protected.Group("/answers")
@@ -54,7 +192,6 @@ func SetupRouter() *gin.Engine {
             {
                 answerIDGroup.POST("/verify", answerController.VerifyAnswer)    // /answers/:id/verify
                 answerIDGroup.POST("/vote/:type", voteController.VoteAnswer)    // /answers/:id/vote/:type
-                answerIDGroup.GET("/votes", voteController.GetVotes)            // /answers/:id/votes
             }
         }
 
@@ -62,18 +199,10 @@ func SetupRouter() *gin.Engine {
         tagGroup := protected.Group("/tags")
         {
             tagGroup.POST("", tagController.CreateTag)                    // POST /tags
-            tagGroup.GET("", tagController.GetTags)                       // GET /tags
-            tagGroup.GET("/:id", tagController.GetTagByID)                // GET /tags/:id
             tagGroup.PUT("/:id", tagController.UpdateTag)                 // PUT /tags/:id
             tagGroup.DELETE("/:id", tagController.DeleteTag)              // DELETE /tags/:id
-        }
-
-        // Search routes
-        searchGroup := protected.Group("/search")
-        {
-            searchGroup.GET("/questions", searchController.SearchQuestions)     // GET /search/questions?q=query
-            searchGroup.GET("/tags", searchController.SearchTags)               // GET /search/tags?q=query
-            searchGroup.GET("/questions/tag/:tag", searchController.GetQuestionsByTag) // GET /search/questions/tag/:tag
+            tagGroup.POST("/:id/follow", followController.FollowTag)      // POST /tags/:id/follow
+            tagGroup.DELETE("/:id/follow", followController.UnfollowTag)  // DELETE /tags/:id/follow
         }
 
         // Follow routes
@@ -89,7 +218,59 @@ func SetupRouter() *gin.Engine {
 
         // My follow stats
         protected.GET("/me/follows/stats", followController.GetMyFollowStats)  // GET /me/follows/stats (get my follow stats)
+        protected.GET("/me/tags", followController.GetMyFollowedTags)          // GET /me/tags (tags I follow)
+        protected.GET("/me/watches", watchController.GetMyWatchedQuestions)    // GET /me/watches (questions I watch)
+        protected.GET("/me/bookmarks", bookmarkController.GetMyBookmarks)      // GET /me/bookmarks?collection_id= (questions I saved)
+
+        // Point history (bounty escrow, award, refund)
+        protected.GET("/me/point-transactions", bountyController.GetMyPointTransactions)
+        protected.GET("/me/privileges", privilegeController.GetMyPrivileges)
+
+        // Bookmark collections
+        collectionGroup := protected.Group("/me/collections")
+        {
+            collectionGroup.GET("", bookmarkController.GetMyCollections)        // GET /me/collections
+            collectionGroup.POST("", bookmarkController.CreateCollection)       // POST /me/collections
+            collectionGroup.PATCH("/:id", bookmarkController.UpdateCollection)  // PATCH /me/collections/:id
+            collectionGroup.DELETE("/:id", bookmarkController.DeleteCollection) // DELETE /me/collections/:id
+        }
+
+        // Notification routes
+        notificationGroup := protected.Group("/notifications")
+        {
+            notificationGroup.GET("", notificationController.GetNotifications)                 // GET /notifications
+            notificationGroup.GET("/stream", notificationController.SSEStream)                 // GET /notifications/stream (SSE)
+            notificationGroup.GET("/unread-count", notificationController.GetUnreadCount)      // GET /notifications/unread-count
+            notificationGroup.POST("/read-all", notificationController.MarkAllAsRead)          // POST /notifications/read-all
+            notificationGroup.POST("/:id/read", notificationController.MarkAsRead)             // POST /notifications/:id/read
+            notificationGroup.DELETE("/:id", notificationController.DeleteNotification)        // DELETE /notifications/:id
+            notificationGroup.GET("/preferences", notificationController.GetPreferences)       // GET /notifications/preferences
+            notificationGroup.PUT("/preferences", notificationController.UpdatePreferences)    // PUT /notifications/preferences
+            notificationGroup.GET("/mutes", notificationController.GetMutes)                   // GET /notifications/mutes
+            notificationGroup.POST("/mutes", notificationController.CreateMute)                // POST /notifications/mutes
+            notificationGroup.DELETE("/mutes/:id", notificationController.DeleteMute)          // DELETE /notifications/mutes/:id
+            notificationGroup.GET("/digest", notificationController.GetDigestSettings)         // GET /notifications/digest
+            notificationGroup.PUT("/digest", notificationController.UpdateDigestSettings)      // PUT /notifications/digest
+        }
+
+        // Background job metrics
+        protected.GET("/jobs/metrics", requireAdmin, jobController.GetMetrics) // GET /jobs/metrics (admin)
+
+        // Personalized feed
+        protected.GET("/feed", feedController.GetFeed)                         // GET /feed?mode=chronological|hot&cursor=
     }
 
     return r
-} 
\ No newline at end of file
+}
+
+// trustedProxies đọc TRUSTED_PROXIES (IP hoặc CIDR, cách nhau bởi dấu phẩy). Không cấu hình thì không tin
+// proxy nào và ClientIP là địa chỉ kết nối trực tiếp, để client không tự khai IP qua X-Forwarded-For.
+func trustedProxies() []string {
+    var proxies []string
+    for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+        if proxy = strings.TrimSpace(proxy); proxy != "" {
+            proxies = append(proxies, proxy)
+        }
+    }
+    return proxies
+}